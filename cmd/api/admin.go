@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	osuser "os/user"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/term"
+
+	"khalif-identify/internal/config"
+	"khalif-identify/internal/domain"
+	"khalif-identify/pkg/database"
+	"khalif-identify/pkg/reqinfo"
+
+)
+
+const adminUsage = `Pemakaian: server admin <perintah> [opsi]
+
+  reset                         Hapus & buat ulang schema (hanya APP_ENV=development/test)
+  seed                          Terapkan fixture seed (roles, permissions, admin, demo)
+  create-admin                  Buat admin baru (kuota admin tetap berlaku)
+  set-role <user> <role-id>     Ganti role user
+  reset-password <user>         Set password baru & cabut semua sesi
+  disable-user <user>           Nonaktifkan akun (-enable untuk mengaktifkan lagi)
+  delete-user <user>            Jadwalkan hapus akun setelah masa tenggang (-restore untuk membatalkan)
+  purge-deleted                 Anonimkan sekarang akun yang masa tenggang hapusnya sudah lewat
+  list-users                    Tampilkan daftar user
+  verify-audit                  Cek rantai hash audit log (exit 1 jika ada yang diubah)
+
+<user> boleh berupa email atau UUID. Jalankan "server admin <perintah> -h" untuk opsi.`
+
+// runAdmin menjalankan subcommand `admin`. Operasi user lewat use case supaya
+// aturan bisnis (kuota admin, validasi nomor HP, revoke sesi) tetap berlaku.
+func runAdmin(args []string) {
+	if len(args) == 0 {
+		fmt.Println(adminUsage)
+		os.Exit(2)
+	}
+
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "reset":
+		adminReset(args)
+	case "seed":
+		cfg := config.MustLoad()
+		if err := runSeed(ProvideDB(cfg, slog.Default()), cfg); err != nil {
+			log.Fatalf("❌ Seeding gagal: %v", err)
+		}
+	case "create-admin":
+		adminCreate(args)
+	case "set-role":
+		adminSetRole(args)
+	case "reset-password":
+		adminResetPassword(args)
+	case "disable-user":
+		adminDisableUser(args)
+	case "delete-user":
+		adminDeleteUser(args)
+	case "purge-deleted":
+		adminPurgeDeleted(args)
+	case "list-users":
+		adminListUsers(args)
+	case "verify-audit":
+		adminVerifyAudit(args)
+	default:
+		fmt.Println(adminUsage)
+		os.Exit(2)
+	}
+}
+
+func adminReset(args []string) {
+	fs := flag.NewFlagSet("reset", flag.ExitOnError)
+	yes := fs.Bool("yes", false, "Lewati konfirmasi")
+	fs.Parse(args)
+
+	cfg := config.MustLoad()
+
+	// Guard environment: DROP SCHEMA hanya untuk APP_ENV yang eksplisit development/test,
+	// bukan sekadar "bukan production"
+	env := strings.ToLower(cfg.App.Env)
+	if env != "development" && env != "test" {
+		log.Fatalf("❌ Reset database ditolak: hanya untuk APP_ENV=development/test (sekarang %q)", cfg.App.Env)
+	}
+
+	dbName := database.DatabaseName(cfg.Database.URL)
+	if dbName == "" {
+		log.Fatal("❌ Nama database tidak bisa dibaca dari DATABASE_URL, reset dibatalkan")
+	}
+	if !*yes {
+		fmt.Printf("⚠️  Semua tabel di database %q (APP_ENV=%s) akan DIHAPUS.\n", dbName, cfg.App.Env)
+		if prompt("Ketik nama database untuk konfirmasi: ") != dbName {
+			log.Fatal("Dibatalkan.")
+		}
+	}
+
+	db := ProvideDB(cfg, slog.Default())
+	fmt.Println("🔄 Resetting Schema...")
+	database.ResetSchema(db)
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := database.NewMigrator(sqlDB).Up(context.Background()); err != nil {
+		log.Fatalf("❌ Migrasi gagal: %v", err)
+	}
+	if err := runSeed(db, cfg); err != nil {
+		log.Fatalf("❌ Seeding gagal: %v", err)
+	}
+	fmt.Println("✅ Database di-reset, dimigrasi & di-seed ulang.")
+}
+
+func adminCreate(args []string) {
+	fs := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	name := fs.String("name", "", "Nama admin")
+	email := fs.String("email", "", "Email admin")
+	phone := fs.String("phone", "", "Nomor HP")
+	country := fs.String("country", "ID", "Kode negara ISO nomor HP")
+	password := fs.String("password", "", "Password (kosong = ditanya)")
+	fs.Parse(args)
+
+	if *name == "" || *email == "" || *phone == "" {
+		log.Fatal("-name, -email dan -phone wajib diisi")
+	}
+	if *password == "" {
+		*password = promptPassword()
+	}
+
+	app := mustInitApp()
+	user, err := app.UserUseCase.Register(cliContext(), *name, *email, *phone, *country, *password, nil, nil)
+	if err != nil {
+		log.Fatalf("❌ Gagal membuat admin: %v", err)
+	}
+	fmt.Printf("✅ Admin dibuat: %s (%s)\n", user.Email, user.UUID)
+}
+
+func adminSetRole(args []string) {
+	if len(args) != 2 {
+		log.Fatal("Pemakaian: admin set-role <user> <role-id>")
+	}
+	roleID, err := strconv.ParseUint(args[1], 10, 32)
+	if err != nil {
+		log.Fatalf("Role ID tidak valid: %s", args[1])
+	}
+
+	app := mustInitApp()
+	user, err := app.UserUseCase.SetRole(cliContext(), args[0], uint(roleID))
+	if err != nil {
+		log.Fatalf("❌ Gagal mengganti role: %v", err)
+	}
+	fmt.Printf("✅ Role %s sekarang %s\n", user.Email, user.Role.Name)
+}
+
+func adminResetPassword(args []string) {
+	fs := flag.NewFlagSet("reset-password", flag.ExitOnError)
+	password := fs.String("password", "", "Password baru (kosong = ditanya)")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		log.Fatal("Pemakaian: admin reset-password [-password ...] <user>")
+	}
+	if *password == "" {
+		*password = promptPassword()
+	}
+
+	app := mustInitApp()
+	user, err := app.UserUseCase.ResetPassword(cliContext(), fs.Arg(0), *password)
+	if err != nil {
+		log.Fatalf("❌ Gagal reset password: %v", err)
+	}
+	fmt.Printf("✅ Password %s diganti, semua sesi dicabut\n", user.Email)
+}
+
+func adminDisableUser(args []string) {
+	fs := flag.NewFlagSet("disable-user", flag.ExitOnError)
+	enable := fs.Bool("enable", false, "Aktifkan kembali akun")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		log.Fatal("Pemakaian: admin disable-user [-enable] <user>")
+	}
+
+	app := mustInitApp()
+	user, err := app.UserUseCase.SetDisabled(cliContext(), fs.Arg(0), !*enable)
+	if err != nil {
+		log.Fatalf("❌ Gagal mengubah status akun: %v", err)
+	}
+	if *enable {
+		fmt.Printf("✅ Akun %s aktif kembali\n", user.Email)
+	} else {
+		fmt.Printf("✅ Akun %s dinonaktifkan, semua sesi dicabut\n", user.Email)
+	}
+}
+
+func adminDeleteUser(args []string) {
+	fs := flag.NewFlagSet("delete-user", flag.ExitOnError)
+	restore := fs.Bool("restore", false, "Batalkan hapus akun selama masa tenggang")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		log.Fatal("Pemakaian: admin delete-user [-restore] <user>")
+	}
+
+	app := mustInitApp()
+	user, err := app.UserUseCase.SetPendingDeletion(cliContext(), fs.Arg(0), !*restore)
+	if err != nil {
+		log.Fatalf("❌ Gagal mengubah status hapus akun: %v", err)
+	}
+	if *restore {
+		fmt.Printf("✅ Hapus akun %s dibatalkan\n", user.Email)
+	} else {
+		fmt.Printf("✅ Akun %s dijadwalkan dihapus pada %s, semua sesi dicabut\n", user.Email, user.DeletionScheduledAt.Format(time.RFC3339))
+	}
+}
+
+// adminPurgeDeleted cadangan untuk job anonimisasi yang hilang dari Redis (misal Redis di-flush)
+func adminPurgeDeleted(args []string) {
+	fs := flag.NewFlagSet("purge-deleted", flag.ExitOnError)
+	fs.Parse(args)
+
+	app := mustInitApp()
+	count, err := app.Deletions.PurgeDue(context.Background())
+	if err != nil {
+		log.Fatalf("❌ Anonimisasi berhenti setelah %d akun: %v", count, err)
+	}
+	fmt.Printf("✅ %d akun dianonimkan\n", count)
+}
+
+func adminListUsers(args []string) {
+	fs := flag.NewFlagSet("list-users", flag.ExitOnError)
+	page := fs.Int("page", 1, "Halaman")
+	limit := fs.Int("limit", 50, "Jumlah per halaman")
+	fs.Parse(args)
+
+	app := mustInitApp()
+	users, total, err := app.UserUseCase.GetAllAdmins(context.Background(), *page, *limit)
+	if err != nil {
+		log.Fatalf("❌ Gagal mengambil user: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "UUID\tEMAIL\tNAMA\tROLE\tTELEPON\tSTATUS")
+	for _, u := range users {
+		status := "aktif"
+		switch {
+		case u.AnonymizedAt != nil:
+			status = "dihapus"
+		case u.DeletionScheduledAt != nil:
+			status = "menunggu hapus"
+		case u.DisabledAt != nil:
+			status = "nonaktif"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", u.UUID, u.Email, u.Name, u.Role.Name, u.PhoneNumber, status)
+	}
+	w.Flush()
+	fmt.Printf("\nHalaman %d, %d dari total %d user\n", *page, len(users), total)
+}
+
+// adminVerifyAudit bisa dijadwalkan (cron); simpan hash terakhir di luar sistem untuk
+// mendeteksi baris terakhir yang dihapus
+func adminVerifyAudit(args []string) {
+	fs := flag.NewFlagSet("verify-audit", flag.ExitOnError)
+	fs.Parse(args)
+
+	app := mustInitApp()
+	result, err := app.UserUseCase.VerifyAuditLog(cliContext())
+	if err != nil {
+		log.Fatalf("❌ Gagal memverifikasi audit log: %v", err)
+	}
+	if !result.Valid {
+		fmt.Printf("❌ Rantai audit log putus di event #%d (%d event sebelumnya valid)\n", result.BrokenAt, result.Checked)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ %d event valid, hash terakhir %s\n", result.Checked, result.LastHash)
+}
+
+// cliContext menandai perubahan lewat CLI di audit log sebagai "cli:<user OS>"
+func cliContext() context.Context {
+	actor := domain.AuditActorCLI
+	if current, err := osuser.Current(); err == nil {
+		actor += ":" + current.Username
+	}
+	return reqinfo.WithActor(context.Background(), actor)
+}
+
+func mustInitApp() *App {
+	app, err := InitializeApp()
+	if err != nil {
+		log.Fatal("Gagal wiring aplikasi:", err)
+	}
+	return app
+}
+
+func prompt(label string) string {
+	fmt.Print(label)
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+// promptPassword membaca password tanpa echo jika stdin adalah terminal
+func promptPassword() string {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return prompt("")
+	}
+	fmt.Print("Password: ")
+	password, err := term.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return string(password)
+}
\ No newline at end of file
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"khalif-identify/internal/config"
+
+)
+
+const configUsage = `Pemakaian: server config <perintah> [opsi]
+
+  print [--redacted=true]       Tampilkan konfigurasi efektif (default, file, env, *_FILE) dalam YAML
+  validate                      Cek konfigurasi dan tampilkan semua masalah
+
+Secret (password, token, connection string) disamarkan kecuali --redacted=false.`
+
+func runConfig(args []string) {
+	if len(args) == 0 {
+		fmt.Println(configUsage)
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "print":
+		fs := flag.NewFlagSet("print", flag.ExitOnError)
+		redacted := fs.Bool("redacted", true, "Samarkan nilai secret")
+		fs.Parse(args[1:])
+
+		// Konfigurasi tetap dicetak walau tidak valid, supaya mudah dicari sumber masalahnya
+		cfg, err := config.Load()
+		printed := cfg
+		if *redacted {
+			printed = cfg.Redacted()
+		}
+		out, marshalErr := printed.YAML()
+		if marshalErr != nil {
+			log.Fatalf("❌ %v", marshalErr)
+		}
+		fmt.Print(string(out))
+		exitOnInvalidConfig(err)
+
+	case "validate":
+		_, err := config.Load()
+		exitOnInvalidConfig(err)
+		fmt.Println("✅ Konfigurasi valid")
+
+	default:
+		fmt.Println(configUsage)
+		os.Exit(2)
+	}
+}
+
+func exitOnInvalidConfig(err error) {
+	if err == nil {
+		return
+	}
+	var verr *config.ValidationError
+	if errors.As(err, &verr) {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", verr)
+		os.Exit(1)
+	}
+	log.Fatalf("❌ %v", err)
+}
\ No newline at end of file
@@ -1,43 +1,171 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
-	"fmt"
 	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
-	"khalif-identify/internal/config"
-	"khalif-identify/internal/domain"
-	"khalif-identify/pkg/database"
+	"khalif-identify/pkg/metrics"
+	"khalif-identify/pkg/middleware"
+	"khalif-identify/pkg/tracing"
 
 )
 
 func main() {
-	refreshFlag := flag.Bool("refresh", false, "Reset Database")
+	// Subcommand: `server migrate ...`, `server admin ...` dan `server config ...`
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			runMigrate(os.Args[2:])
+			return
+		case "admin":
+			runAdmin(os.Args[2:])
+			return
+		case "config":
+			runConfig(os.Args[2:])
+			return
+		}
+	}
+
+	refreshFlag := flag.Bool("refresh", false, "Dihapus, gunakan `server admin reset`")
+	modeFlag := flag.String("mode", "all", "Mode proses: all (API + worker), api, worker")
 	flag.Parse()
 
-	cfg := config.LoadConfig()
+	if *refreshFlag {
+		log.Fatal("❌ Flag -refresh sudah dihapus. Gunakan `server admin reset` (dengan konfirmasi & guard environment).")
+	}
 
 	app, err := InitializeApp()
 	if err != nil {
 		log.Fatal("Gagal wiring aplikasi:", err)
 	}
+	cfg := app.Config
 
-	if *refreshFlag {
-		fmt.Println("🔄 Mode Refresh: Resetting Schema...")
-		database.ResetSchema(app.DB)
+	ensureSchemaUpToDate(app, cfg)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:     cfg.Tracing.Exporter,
+		OTLPEndpoint: cfg.Tracing.OTLPEndpoint,
+		OTLPInsecure: cfg.Tracing.OTLPInsecure,
+		ServiceName:  cfg.Tracing.ServiceName,
+		Environment:  cfg.App.Env,
+		SampleRatio:  cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		log.Fatalf("❌ Gagal init tracing: %v", err)
+	}
+	// Dipanggil paling akhir (setelah HTTP & worker berhenti) agar span terakhir ikut terkirim
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			slog.Warn("⚠️ Gagal flush span tracing", "error", err)
+		}
+	}()
+
+	if err := runSeed(app.DB, cfg); err != nil {
+		log.Fatalf("❌ Seeding gagal: %v", err)
 	}
 
-	fmt.Println("🚀 Menjalankan Auto Migrate...")
-	app.DB.AutoMigrate(&domain.Role{}, &domain.User{})
+	// SIGINT/SIGTERM (docker stop, rolling update) memicu shutdown rapi
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	defer app.Close()
 
-	database.SeedRoles(app.DB)
+	var workers sync.WaitGroup
+	runWorker := func() {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			app.Worker.Run(ctx)
+		}()
+	}
+
+	switch *modeFlag {
+	case "worker":
+		// Hanya worker (tanpa HTTP); Run menunggu job yang sedang diproses selesai
+		runWorker()
+		workers.Wait()
+		return
+	case "all":
+		runWorker()
+	case "api":
+	default:
+		log.Fatalf("Mode tidak dikenal: %s", *modeFlag)
+	}
 
-	r := gin.Default()
+	// gin.New: logger bawaan gin diganti access log JSON yang membawa request_id.
+	// ErrorHandler paling dalam agar status problem+json sudah final saat dicatat metric & access log.
+	r := gin.New()
+	r.Use(middleware.RequestID(), tracing.Middleware(), middleware.AccessLog(), metrics.Middleware(), gin.Recovery(), middleware.ErrorHandler(), middleware.Locale())
 
 	SetupRoutes(r, app, cfg)
 
-	fmt.Printf("🔥 Server berjalan di port %s\n", cfg.Port)
-	r.Run(":" + cfg.Port)
+	srv := &http.Server{
+		Addr:              ":" + cfg.HTTP.Port,
+		Handler:           r,
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("🔥 Server berjalan", "port", cfg.HTTP.Port, "env", cfg.App.Env)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+		close(serveErr)
+	}()
+
+	select {
+	case err := <-serveErr:
+		if err != nil {
+			slog.Error("❌ Server berhenti", "error", err)
+		}
+		stop()
+	case <-ctx.Done():
+		slog.Info("🛑 Sinyal shutdown diterima, menyelesaikan request yang berjalan")
+	}
+
+	// 1. Tandai tidak ready dan beri load balancer waktu melihat /readyz 503 (listener masih menerima
+	//    koneksi), lalu tunggu request berjalan (termasuk upload) selesai
+	app.Health.SetDraining()
+	if delay := cfg.HTTP.DrainDelay; delay > 0 {
+		slog.Info("⏳ Menunggu load balancer berhenti mengirim traffic", "delay", delay.String())
+		time.Sleep(delay)
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("⚠️ Shutdown HTTP tidak selesai tepat waktu", "timeout", cfg.HTTP.ShutdownTimeout.String(), "error", err)
+	}
+
+	// 2. Tunggu worker menyelesaikan job yang sedang diproses (ctx sudah dibatalkan)
+	workers.Wait()
+	slog.Info("✅ Server berhenti dengan rapi")
+}
+
+// Close menutup koneksi pool Postgres dan Redis. Dipanggil paling akhir saat
+// shutdown, setelah HTTP server dan worker berhenti memakai koneksi.
+func (a *App) Close() {
+	if sqlDB, err := a.DB.DB(); err == nil {
+		if err := sqlDB.Close(); err != nil {
+			slog.Warn("⚠️ Gagal menutup koneksi database", "error", err)
+		}
+	}
+	if err := a.RDB.Close(); err != nil {
+		slog.Warn("⚠️ Gagal menutup koneksi Redis", "error", err)
+	}
 }
\ No newline at end of file
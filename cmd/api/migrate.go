@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"gorm.io/gorm"
+
+	"khalif-identify/internal/config"
+	"khalif-identify/internal/usecase"
+	"khalif-identify/pkg/database"
+
+)
+
+const migrateUsage = `Pemakaian: server migrate <perintah>
+
+  up              Jalankan semua migrasi yang belum diterapkan
+  down [N]        Batalkan N migrasi terakhir (default 1)
+  status          Tampilkan status semua migrasi
+  create <nama>   Buat pasangan file up/down baru di ` + database.MigrationsDir
+
+// runMigrate menjalankan subcommand `migrate`
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		fmt.Println(migrateUsage)
+		os.Exit(2)
+	}
+
+	// create tidak butuh koneksi database
+	if args[0] == "create" {
+		if len(args) < 2 {
+			log.Fatal("Nama migrasi wajib diisi: migrate create <nama>")
+		}
+		upPath, downPath, err := database.CreateMigration(database.MigrationsDir, args[1])
+		if err != nil {
+			log.Fatalf("❌ Gagal membuat migrasi: %v", err)
+		}
+		fmt.Printf("✅ Dibuat:\n  %s\n  %s\n", upPath, downPath)
+		return
+	}
+
+	cfg := config.MustLoad()
+	sqlDB, err := ProvideDB(cfg, slog.Default()).DB()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer sqlDB.Close()
+
+	ctx := context.Background()
+	migrator := database.NewMigrator(sqlDB)
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("⬆️  %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		fmt.Printf("✅ %d migrasi diterapkan\n", len(applied))
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				log.Fatalf("Jumlah langkah tidak valid: %s", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("⬇️  %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		fmt.Printf("✅ %d migrasi dibatalkan\n", len(reverted))
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-40s %s\n", s.Version, s.Name, applied)
+		}
+
+	default:
+		fmt.Println(migrateUsage)
+		os.Exit(2)
+	}
+}
+
+// ensureSchemaUpToDate dipanggil sebelum serve: jalankan migrasi jika diminta,
+// lalu tolak start jika masih ada migrasi yang belum diterapkan.
+func ensureSchemaUpToDate(app *App, cfg *config.Config) {
+	sqlDB, err := app.DB.DB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := context.Background()
+	migrator := database.NewMigrator(sqlDB)
+
+	if cfg.Database.MigrateOnStart {
+		fmt.Println("🚀 Menjalankan migrasi database...")
+		if _, err := migrator.Up(ctx); err != nil {
+			log.Fatalf("❌ Migrasi gagal: %v", err)
+		}
+	}
+
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		log.Fatalf("❌ Gagal mengecek status migrasi: %v", err)
+	}
+	if pending > 0 {
+		log.Fatalf("❌ Skema database tertinggal %d migrasi. Jalankan `server migrate up` (atau set MIGRATE_ON_START=true) sebelum start.", pending)
+	}
+}
+
+// runSeed menerapkan fixture sesuai APP_ENV (+ admin default dari SEED_ADMIN_*) lalu mencetak laporannya
+func runSeed(db *gorm.DB, cfg *config.Config) error {
+	opts := database.SeedOptions{
+		Env:         cfg.App.Env,
+		FixturesDir: cfg.Seed.FixturesDir,
+		DemoUsers:   cfg.Seed.DemoUsers,
+		BcryptCost:  cfg.Auth.BcryptCost,
+	}
+	if admin := cfg.Seed.Admin; admin.Email != "" {
+		opts.Admin = &database.UserFixture{
+			Name:     admin.Name,
+			Email:    admin.Email,
+			Phone:    admin.Phone,
+			Country:  admin.Country,
+			Password: admin.Password,
+			RoleID:   usecase.AdminRoleID,
+		}
+	}
+
+	report, err := database.Seed(db, opts)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("🌱 Seed (%s): %s\n", cfg.App.Env, report)
+	return nil
+}
\ No newline at end of file
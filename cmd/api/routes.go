@@ -1,55 +1,106 @@
 package main
 
 import (
-	"time"
-
 	"github.com/gin-gonic/gin"
 
 	"khalif-identify/internal/config"
+	"khalif-identify/pkg/metrics"
 	"khalif-identify/pkg/middleware"
 
 )
 
 func SetupRoutes(r *gin.Engine, app *App, cfg *config.Config) {
-	r.Static("/uploads", "./uploads")
+	if len(cfg.CORS.AllowedOrigins) > 0 {
+		r.Use(middleware.CORS(middleware.CORSConfig{
+			AllowedOrigins:   cfg.CORS.AllowedOrigins,
+			AllowedMethods:   cfg.CORS.AllowedMethods,
+			AllowedHeaders:   cfg.CORS.AllowedHeaders,
+			AllowCredentials: cfg.CORS.AllowCredentials,
+			MaxAge:           cfg.CORS.MaxAge,
+		}))
+	}
+
+	// File hanya dilayani aplikasi untuk driver local (Azure melayani blob-nya sendiri)
+	if app.Uploads != nil {
+		r.GET("/uploads/:name", gin.WrapH(app.Uploads))
+	}
+
+	// Probe orchestrator, didaftarkan sebelum rate limit agar tidak pernah kena 429
+	r.GET("/healthz", app.Health.Liveness)
+	r.GET("/readyz", app.Health.Readiness)
+	if cfg.Metrics.Enabled {
+		r.GET(cfg.Metrics.Path, metrics.Handler(cfg.Metrics.Token))
+	}
 
 	globalLimitConfig := middleware.RateLimitConfig{
-		Limit:  60,
-		Window: time.Minute,
+		Name:   "global",
+		Limit:  cfg.RateLimit.GlobalLimit,
+		Window: cfg.RateLimit.GlobalWindow,
 	}
 	r.Use(middleware.RateLimit(app.RDB, globalLimitConfig))
 
+	loginLimit := middleware.RateLimitConfig{
+		Name:   "login",
+		Limit:  cfg.RateLimit.LoginLimit,
+		Window: cfg.RateLimit.LoginWindow,
+	}
+
 	apiAdmin := r.Group("/api/admin")
 	{
 		apiAdmin.GET("/meta/countries", app.UserHandler.GetCountryCodes)
 		apiAdmin.POST("/register", app.UserHandler.Register)
 
-		loginLimit := middleware.RateLimitConfig{Limit: 5, Window: time.Minute}
 		apiAdmin.POST("/login", middleware.RateLimit(app.RDB, loginLimit), app.UserHandler.Login)
+		apiAdmin.POST("/login/otp", middleware.RateLimit(app.RDB, loginLimit), app.UserHandler.SendLoginOTP)
+		apiAdmin.POST("/email/change/confirm", middleware.RateLimit(app.RDB, loginLimit), app.UserHandler.ConfirmEmailChange)
+		apiAdmin.POST("/email/change/cancel", middleware.RateLimit(app.RDB, loginLimit), app.UserHandler.CancelEmailChange)
+		apiAdmin.GET("/export/download", middleware.RateLimit(app.RDB, loginLimit), app.UserHandler.DownloadDataExport)
+		apiAdmin.POST("/account/delete/cancel", middleware.RateLimit(app.RDB, loginLimit), app.UserHandler.CancelAccountDeletion)
 
 		protectedAdmin := apiAdmin.Group("/")
-		protectedAdmin.Use(middleware.AuthMiddleware(cfg.JWTSecret, app.RDB))
+		protectedAdmin.Use(middleware.AuthMiddleware(cfg.Auth.JWTSecret, app.RDB, app.UserUseCase.SessionVersion), middleware.UserLocale(app.UserUseCase.PreferredLocale))
 		{
 			protectedAdmin.GET("/me", app.UserHandler.GetProfile)
 			protectedAdmin.POST("/logout", app.UserHandler.Logout)
 			protectedAdmin.POST("/profile/update", app.UserHandler.UpdateProfile)
+			protectedAdmin.POST("/email/change", app.UserHandler.RequestEmailChange)
+			protectedAdmin.POST("/password/change", app.UserHandler.ChangePassword)
+			protectedAdmin.POST("/export", app.UserHandler.RequestDataExport)
+			protectedAdmin.POST("/account/delete", app.UserHandler.DeleteAccount)
+			protectedAdmin.POST("/phone/otp/send", app.UserHandler.SendPhoneOTP)
+			protectedAdmin.POST("/phone/otp/verify", app.UserHandler.VerifyPhoneOTP)
 			protectedAdmin.GET("/list", middleware.OnlyAdmin(), app.UserHandler.GetAll)
+			protectedAdmin.GET("/audit", middleware.OnlyAdmin(), app.UserHandler.GetAuditEvents)
+			protectedAdmin.GET("/audit/export", middleware.OnlyAdmin(), app.UserHandler.ExportAuditEvents)
+			protectedAdmin.GET("/audit/verify", middleware.OnlyAdmin(), app.UserHandler.VerifyAuditLog)
 		}
 	}
 
 	apiUser := r.Group("/api/user")
 	{
 		apiUser.POST("/register", app.UserHandler.RegisterCustomer)
-		
-		loginLimit := middleware.RateLimitConfig{Limit: 5, Window: time.Minute}
+
 		apiUser.POST("/login", middleware.RateLimit(app.RDB, loginLimit), app.UserHandler.Login)
+		apiUser.POST("/login/otp", middleware.RateLimit(app.RDB, loginLimit), app.UserHandler.SendLoginOTP)
+		apiUser.POST("/login/link", middleware.RateLimit(app.RDB, loginLimit), app.UserHandler.SendLoginLink)
+		apiUser.POST("/login/link/verify", middleware.RateLimit(app.RDB, loginLimit), app.UserHandler.VerifyLoginLink)
+		apiUser.POST("/email/change/confirm", middleware.RateLimit(app.RDB, loginLimit), app.UserHandler.ConfirmEmailChange)
+		apiUser.POST("/email/change/cancel", middleware.RateLimit(app.RDB, loginLimit), app.UserHandler.CancelEmailChange)
+		apiUser.GET("/export/download", middleware.RateLimit(app.RDB, loginLimit), app.UserHandler.DownloadDataExport)
+		apiUser.POST("/account/delete/cancel", middleware.RateLimit(app.RDB, loginLimit), app.UserHandler.CancelAccountDeletion)
 
 		protectedUser := apiUser.Group("/")
-		protectedUser.Use(middleware.AuthMiddleware(cfg.JWTSecret, app.RDB))
+		protectedUser.Use(middleware.AuthMiddleware(cfg.Auth.JWTSecret, app.RDB, app.UserUseCase.SessionVersion), middleware.UserLocale(app.UserUseCase.PreferredLocale))
 		{
 			protectedUser.GET("/me", app.UserHandler.GetProfile)
 			protectedUser.POST("/logout", app.UserHandler.Logout)
 			protectedUser.POST("/profile/update", app.UserHandler.UpdateProfile)
+			protectedUser.POST("/email/change", app.UserHandler.RequestEmailChange)
+			protectedUser.POST("/password/change", app.UserHandler.ChangePassword)
+			protectedUser.POST("/export", app.UserHandler.RequestDataExport)
+			protectedUser.POST("/account/delete", app.UserHandler.DeleteAccount)
+			protectedUser.POST("/phone/otp/send", app.UserHandler.SendPhoneOTP)
+			protectedUser.POST("/phone/otp/verify", app.UserHandler.VerifyPhoneOTP)
 		}
 	}
 }
\ No newline at end of file
@@ -13,34 +13,57 @@ import (
 	"khalif-identify/internal/handler"
 	"khalif-identify/internal/repository"
 	"khalif-identify/internal/usecase"
+	"khalif-identify/pkg/mailer"
+	"khalif-identify/pkg/queue"
+	"khalif-identify/pkg/sms"
 	"khalif-identify/pkg/utils"
 
 )
 
 // 1. Buat Struct Penampung (Container)
 type App struct {
+	Config      *config.Config
 	DB          *gorm.DB
 	RDB         *redis.Client
 	UserHandler *handler.UserHandler
+	Health      *handler.HealthHandler
+	UserUseCase domain.UserUseCase
+	Worker      *queue.Worker
+	Deletions   *usecase.AccountDeletionJobHandler
+	Uploads     *utils.LocalStorage // nil jika storage bukan driver local
 }
 
 // 2. Provider untuk membuat Struct App
-func NewApp(db *gorm.DB, rdb *redis.Client, h *handler.UserHandler) *App {
+func NewApp(cfg *config.Config, db *gorm.DB, rdb *redis.Client, h *handler.UserHandler, health *handler.HealthHandler, uc domain.UserUseCase, worker *queue.Worker, deletions *usecase.AccountDeletionJobHandler, uploads *utils.LocalStorage) *App {
 	return &App{
+		Config:      cfg,
 		DB:          db,
 		RDB:         rdb,
 		UserHandler: h,
+		Health:      health,
+		UserUseCase: uc,
+		Worker:      worker,
+		Deletions:   deletions,
+		Uploads:     uploads,
 	}
 }
 
 // 3. Update InitializeApp agar me-return *App saja
 func InitializeApp() (*App, error) {
 	wire.Build(
-		config.LoadConfig,
+		config.MustLoad,
+		ProvideLogger,
 		ProvideDB,
 		ProvideRedis,
-		ProvideAzureUploader,
+		ProvideLocalStorage,
+		ProvideStorage,
 		ProvideJWTSecret,
+		ProvideSMSSender,
+		ProvideMailer,
+		ProvideUseCaseSettings,
+		ProvideJobQueue,
+		wire.Bind(new(domain.JobQueue), new(*queue.Queue)),
+		ProvideWorker,
 
 		repository.NewUserRepository,
 		wire.Bind(new(domain.UserRepository), new(*repository.UserRepo)),
@@ -49,7 +72,12 @@ func InitializeApp() (*App, error) {
 		wire.Bind(new(domain.CacheRepository), new(*repository.RedisRepo)),
 
 		NewUserUseCaseWire,
+		usecase.NewImageJobHandler,
+		usecase.NewDataExportJobHandler,
+		usecase.NewAccountDeletionJobHandler,
+		usecase.NewLoginLinkJobHandler,
 		handler.NewUserHandler,
+		ProvideHealthHandler,
 
 		// Masukkan Provider App Baru
 		NewApp,
@@ -61,8 +89,12 @@ func InitializeApp() (*App, error) {
 func NewUserUseCaseWire(
 	repo domain.UserRepository,
 	cache domain.CacheRepository,
-	uploader *utils.AzureUploader,
+	jobs domain.JobQueue,
+	uploader utils.Storage,
+	smsSender sms.Sender,
+	mail mailer.Mailer,
+	settings usecase.Settings,
 	secret JWTSecret,
 ) domain.UserUseCase {
-	return usecase.NewUserUseCase(repo, cache, uploader, string(secret))
+	return usecase.NewUserUseCase(repo, cache, jobs, uploader, smsSender, mail, settings, string(secret))
 }
\ No newline at end of file
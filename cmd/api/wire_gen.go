@@ -14,6 +14,9 @@ import (
 	"khalif-identify/internal/handler"
 	"khalif-identify/internal/repository"
 	"khalif-identify/internal/usecase"
+	"khalif-identify/pkg/mailer"
+	"khalif-identify/pkg/queue"
+	"khalif-identify/pkg/sms"
 	"khalif-identify/pkg/utils"
 )
 
@@ -21,16 +24,28 @@ import (
 
 // 3. Update InitializeApp agar me-return *App saja
 func InitializeApp() (*App, error) {
-	configConfig := config.LoadConfig()
-	db := ProvideDB(configConfig)
+	configConfig := config.MustLoad()
+	logger := ProvideLogger(configConfig)
+	db := ProvideDB(configConfig, logger)
 	client := ProvideRedis(configConfig)
 	userRepo := repository.NewUserRepository(db)
 	redisRepo := repository.NewCacheRepository(client)
-	azureUploader := ProvideAzureUploader(configConfig)
+	queue := ProvideJobQueue(client)
 	jwtSecret := ProvideJWTSecret(configConfig)
-	userUseCase := NewUserUseCaseWire(userRepo, redisRepo, azureUploader, jwtSecret)
+	localStorage := ProvideLocalStorage(configConfig, jwtSecret)
+	storage := ProvideStorage(configConfig, localStorage)
+	sender := ProvideSMSSender(configConfig)
+	mailer := ProvideMailer(configConfig)
+	settings := ProvideUseCaseSettings(configConfig)
+	userUseCase := NewUserUseCaseWire(userRepo, redisRepo, queue, storage, sender, mailer, settings, jwtSecret)
 	userHandler := handler.NewUserHandler(userUseCase)
-	app := NewApp(db, client, userHandler)
+	healthHandler := ProvideHealthHandler(db, client, storage)
+	imageJobHandler := usecase.NewImageJobHandler(userRepo, redisRepo, storage)
+	dataExportJobHandler := usecase.NewDataExportJobHandler(userRepo, redisRepo, queue, storage, mailer, settings)
+	accountDeletionJobHandler := usecase.NewAccountDeletionJobHandler(userRepo, redisRepo, storage, settings)
+	loginLinkJobHandler := usecase.NewLoginLinkJobHandler(userRepo, redisRepo, mailer, settings)
+	worker := ProvideWorker(configConfig, queue, imageJobHandler, dataExportJobHandler, accountDeletionJobHandler, loginLinkJobHandler)
+	app := NewApp(configConfig, db, client, userHandler, healthHandler, userUseCase, worker, accountDeletionJobHandler, localStorage)
 	return app, nil
 }
 
@@ -38,17 +53,29 @@ func InitializeApp() (*App, error) {
 
 // 1. Buat Struct Penampung (Container)
 type App struct {
+	Config      *config.Config
 	DB          *gorm.DB
 	RDB         *redis.Client
 	UserHandler *handler.UserHandler
+	Health      *handler.HealthHandler
+	UserUseCase domain.UserUseCase
+	Worker      *queue.Worker
+	Deletions   *usecase.AccountDeletionJobHandler
+	Uploads     *utils.LocalStorage // nil jika storage bukan driver local
 }
 
 // 2. Provider untuk membuat Struct App
-func NewApp(db *gorm.DB, rdb *redis.Client, h *handler.UserHandler) *App {
+func NewApp(cfg *config.Config, db *gorm.DB, rdb *redis.Client, h *handler.UserHandler, health *handler.HealthHandler, uc domain.UserUseCase, worker *queue.Worker, deletions *usecase.AccountDeletionJobHandler, uploads *utils.LocalStorage) *App {
 	return &App{
+		Config:      cfg,
 		DB:          db,
 		RDB:         rdb,
 		UserHandler: h,
+		Health:      health,
+		UserUseCase: uc,
+		Worker:      worker,
+		Deletions:   deletions,
+		Uploads:     uploads,
 	}
 }
 
@@ -56,8 +83,12 @@ func NewApp(db *gorm.DB, rdb *redis.Client, h *handler.UserHandler) *App {
 func NewUserUseCaseWire(
 	repo domain.UserRepository,
 	cache domain.CacheRepository,
-	uploader *utils.AzureUploader,
+	jobs domain.JobQueue,
+	uploader utils.Storage,
+	smsSender sms.Sender,
+	mail mailer.Mailer,
+	settings usecase.Settings,
 	secret JWTSecret,
 ) domain.UserUseCase {
-	return usecase.NewUserUseCase(repo, cache, uploader, string(secret))
+	return usecase.NewUserUseCase(repo, cache, jobs, uploader, smsSender, mail, settings, string(secret))
 }
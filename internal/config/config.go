@@ -1,37 +1,247 @@
 package config
 
 import (
-	"log"
-	"os"
-
-	"github.com/joho/godotenv"
+	"time"
 
 )
 
+// Config adalah seluruh konfigurasi aplikasi. Urutan sumber (yang belakang menang):
+//  1. Default()
+//  2. File YAML (CONFIG_FILE, default ./config.yaml jika ada)
+//  3. Environment variable sesuai tag `env` (termasuk isi .env)
+//  4. <ENV>_FILE: isi file dipakai sebagai nilai (untuk Docker/K8s secret)
+//
+// Field bertanda `secret:"true"` disamarkan oleh `config print --redacted`.
 type Config struct {
-	DBUrl             string
-	RedisAddr         string
-	Port              string
-	JWTSecret         string
-	AzureConnStr      string
-	AzureContainer    string
-}
-
-func LoadConfig() *Config {
-	err := godotenv.Load()
-	if err != nil {
-		err = godotenv.Load("../../.env")
-		if err != nil {
-			log.Println("⚠️  Warning: File .env tidak ditemukan, menggunakan Environment Variable sistem")
-		}
-	}
+	App       AppConfig       `yaml:"app"`
+	HTTP      HTTPConfig      `yaml:"http"`
+	Log       LogConfig       `yaml:"log"`
+	Metrics   MetricsConfig   `yaml:"metrics"`
+	Tracing   TracingConfig   `yaml:"tracing"`
+	Database  DatabaseConfig  `yaml:"database"`
+	Redis     RedisConfig     `yaml:"redis"`
+	Auth      AuthConfig      `yaml:"auth"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	Quota     QuotaConfig     `yaml:"quota"`
+	Storage   StorageConfig   `yaml:"storage"`
+	SMS       SMSConfig       `yaml:"sms"`
+	Mail      MailConfig      `yaml:"mail"`
+	CORS      CORSConfig      `yaml:"cors"`
+	Worker    WorkerConfig    `yaml:"worker"`
+	Privacy   PrivacyConfig   `yaml:"privacy"`
+	Audit     AuditConfig     `yaml:"audit"`
+	Seed      SeedConfig      `yaml:"seed"`
+}
+
+type AppConfig struct {
+	Env     string `yaml:"env" env:"APP_ENV"`           // development | test | staging | production
+	BaseURL string `yaml:"base_url" env:"APP_BASE_URL"` // URL frontend untuk link di email
+}
+
+type HTTPConfig struct {
+	Port              string        `yaml:"port" env:"PORT"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT"`
+	// ShutdownTimeout batas waktu menunggu request yang sedang berjalan saat SIGTERM
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"HTTP_SHUTDOWN_TIMEOUT"`
+	// DrainDelay jeda antara /readyz mulai 503 dan listener ditutup, agar load balancer sempat
+	// mengeluarkan instance ini sebelum koneksi baru ditolak (0 = langsung shutdown)
+	DrainDelay time.Duration `yaml:"drain_delay" env:"HTTP_DRAIN_DELAY"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL"`   // debug | info | warn | error
+	Format string `yaml:"format" env:"LOG_FORMAT"` // json | text
+}
+
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled" env:"METRICS_ENABLED"`
+	Path    string `yaml:"path" env:"METRICS_PATH"`
+	Token   string `yaml:"token" env:"METRICS_TOKEN" secret:"true"` // Kosong = tanpa autentikasi (batasi lewat network)
+}
 
+// TracingConfig: span OpenTelemetry dikirim ke collector OTLP/HTTP atau ditulis ke stdout
+type TracingConfig struct {
+	Exporter     string  `yaml:"exporter" env:"TRACING_EXPORTER"` // none | stdout | otlp
+	OTLPEndpoint string  `yaml:"otlp_endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	OTLPInsecure bool    `yaml:"otlp_insecure" env:"OTEL_EXPORTER_OTLP_INSECURE"`
+	ServiceName  string  `yaml:"service_name" env:"OTEL_SERVICE_NAME"`
+	SampleRatio  float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+type DatabaseConfig struct {
+	URL            string `yaml:"url" env:"DATABASE_URL" secret:"true"`
+	MigrateOnStart bool   `yaml:"migrate_on_start" env:"MIGRATE_ON_START"`
+}
+
+type RedisConfig struct {
+	Addr     string `yaml:"addr" env:"REDIS_ADDR"`
+	Password string `yaml:"password" env:"REDIS_PASSWORD" secret:"true"`
+	DB       int    `yaml:"db" env:"REDIS_DB"`
+}
+
+type AuthConfig struct {
+	JWTSecret      string        `yaml:"jwt_secret" env:"JWT_SECRET" secret:"true"`
+	AccessTokenTTL time.Duration `yaml:"access_token_ttl" env:"ACCESS_TOKEN_TTL"`
+	MagicLinkTTL   time.Duration `yaml:"magic_link_ttl" env:"MAGIC_LINK_TTL"`
+	EmailChangeTTL time.Duration `yaml:"email_change_ttl" env:"EMAIL_CHANGE_TTL"`
+	OTPTTL         time.Duration `yaml:"otp_ttl" env:"OTP_TTL"`
+	BcryptCost     int           `yaml:"bcrypt_cost" env:"BCRYPT_COST"`
+}
+
+type RateLimitConfig struct {
+	GlobalLimit  int           `yaml:"global_limit" env:"RATE_LIMIT_GLOBAL"`
+	GlobalWindow time.Duration `yaml:"global_window" env:"RATE_LIMIT_GLOBAL_WINDOW"`
+	LoginLimit   int           `yaml:"login_limit" env:"RATE_LIMIT_LOGIN"`
+	LoginWindow  time.Duration `yaml:"login_window" env:"RATE_LIMIT_LOGIN_WINDOW"`
+}
+
+type QuotaConfig struct {
+	MaxAdmins            int   `yaml:"max_admins" env:"MAX_ADMINS"`
+	MaxProfileImageBytes int64 `yaml:"max_profile_image_bytes" env:"MAX_PROFILE_IMAGE_BYTES"`
+}
+
+type StorageConfig struct {
+	Driver                string        `yaml:"driver" env:"STORAGE_DRIVER"` // azure | local
+	AzureConnectionString string        `yaml:"azure_connection_string" env:"AZURE_STORAGE_CONNECTION_STRING" secret:"true"`
+	AzureContainer        string        `yaml:"azure_container" env:"AZURE_CONTAINER_NAME"`
+	Private               bool          `yaml:"private" env:"STORAGE_PRIVATE"` // Berlaku untuk semua driver: URL file ditandatangani & kedaluwarsa
+	SignedURLTTL          time.Duration `yaml:"signed_url_ttl" env:"SIGNED_URL_TTL"`
+	LocalDir              string        `yaml:"local_dir" env:"STORAGE_LOCAL_DIR"`
+	LocalBaseURL          string        `yaml:"local_base_url" env:"STORAGE_LOCAL_BASE_URL"`
+}
+
+type SMSConfig struct {
+	Driver    string `yaml:"driver" env:"SMS_DRIVER"` // console | file | http
+	FilePath  string `yaml:"file_path" env:"SMS_FILE_PATH"`
+	HTTPURL   string `yaml:"http_url" env:"SMS_HTTP_URL"`
+	HTTPToken string `yaml:"http_token" env:"SMS_HTTP_TOKEN" secret:"true"`
+	SenderID  string `yaml:"sender_id" env:"SMS_SENDER_ID"`
+}
+
+type MailConfig struct {
+	Driver       string `yaml:"driver" env:"MAIL_DRIVER"` // console | file | smtp
+	FilePath     string `yaml:"file_path" env:"MAIL_FILE_PATH"`
+	From         string `yaml:"from" env:"MAIL_FROM"`
+	SMTPHost     string `yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     string `yaml:"smtp_port" env:"SMTP_PORT"`
+	SMTPUsername string `yaml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+}
+
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"` // Kosong = CORS nonaktif
+	AllowedMethods   []string      `yaml:"allowed_methods" env:"CORS_ALLOWED_METHODS"`
+	AllowedHeaders   []string      `yaml:"allowed_headers" env:"CORS_ALLOWED_HEADERS"`
+	AllowCredentials bool          `yaml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge           time.Duration `yaml:"max_age" env:"CORS_MAX_AGE"`
+}
+
+type WorkerConfig struct {
+	Concurrency       int           `yaml:"concurrency" env:"WORKER_CONCURRENCY"`
+	VisibilityTimeout time.Duration `yaml:"visibility_timeout" env:"WORKER_VISIBILITY_TIMEOUT"` // Job tanpa heartbeat selama ini dianggap yatim (worker crash) dan diantrikan ulang
+}
+
+// PrivacyConfig mengatur fitur hak data pribadi (export data, hapus akun)
+type PrivacyConfig struct {
+	DataExportTTL       time.Duration `yaml:"data_export_ttl" env:"DATA_EXPORT_TTL"`             // Masa berlaku link unduh export
+	DeletionGracePeriod time.Duration `yaml:"deletion_grace_period" env:"DELETION_GRACE_PERIOD"` // Jeda sebelum akun yang dihapus dianonimkan
+}
+
+// AuditConfig kunci rantai hash audit log. Wajib di production; di luar production kosong = pakai
+// auth.jwt_secret. Kunci terpisah membuat JWT secret bisa dirotasi tanpa membuat audit log lama
+// gagal diverifikasi.
+type AuditConfig struct {
+	HMACKey string `yaml:"hmac_key" env:"AUDIT_HMAC_KEY" secret:"true"`
+}
+
+type SeedConfig struct {
+	FixturesDir string          `yaml:"fixtures_dir" env:"SEED_FIXTURES_DIR"`
+	DemoUsers   bool            `yaml:"demo_users" env:"SEED_DEMO_USERS"` // User dari fixture (password ada di repo) hanya dibuat jika true
+	Admin       SeedAdminConfig `yaml:"admin"`
+}
+
+// SeedAdminConfig admin default yang dibuat seeder jika Email diisi
+type SeedAdminConfig struct {
+	Name     string `yaml:"name" env:"SEED_ADMIN_NAME"`
+	Email    string `yaml:"email" env:"SEED_ADMIN_EMAIL"`
+	Phone    string `yaml:"phone" env:"SEED_ADMIN_PHONE"`
+	Country  string `yaml:"country" env:"SEED_ADMIN_COUNTRY"`
+	Password string `yaml:"password" env:"SEED_ADMIN_PASSWORD" secret:"true"`
+}
+
+// Default berisi nilai bawaan; APP_ENV tetap wajib diisi
+func Default() *Config {
 	return &Config{
-		DBUrl:          os.Getenv("DATABASE_URL"),
-		RedisAddr:      os.Getenv("REDIS_ADDR"),
-		Port:           os.Getenv("PORT"),
-		JWTSecret:      os.Getenv("JWT_SECRET"),
-		AzureConnStr:   os.Getenv("AZURE_STORAGE_CONNECTION_STRING"),
-		AzureContainer: os.Getenv("AZURE_CONTAINER_NAME"),
+		// Env sengaja tanpa default: lupa APP_ENV tidak boleh diam-diam menjadi development
+		App: AppConfig{
+			BaseURL: "http://localhost:3000",
+		},
+		HTTP: HTTPConfig{
+			Port: "8081",
+			// Read/Write longgar karena register & update profil mengirim foto (multipart)
+			ReadTimeout:       30 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      60 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			ShutdownTimeout:   30 * time.Second,
+			DrainDelay:        5 * time.Second,
+		},
+		Log:     LogConfig{Level: "info", Format: "json"},
+		Metrics: MetricsConfig{Enabled: true, Path: "/metrics"},
+		Tracing: TracingConfig{
+			Exporter:     "none",
+			OTLPEndpoint: "localhost:4318",
+			OTLPInsecure: true,
+			ServiceName:  "khalif-identify",
+			SampleRatio:  1,
+		},
+		Redis: RedisConfig{
+			Addr: "localhost:6379",
+		},
+		Auth: AuthConfig{
+			AccessTokenTTL: 24 * time.Hour,
+			MagicLinkTTL:   15 * time.Minute,
+			EmailChangeTTL: 24 * time.Hour,
+			OTPTTL:         5 * time.Minute,
+			BcryptCost:     14,
+		},
+		RateLimit: RateLimitConfig{
+			GlobalLimit:  60,
+			GlobalWindow: time.Minute,
+			LoginLimit:   5,
+			LoginWindow:  time.Minute,
+		},
+		Quota: QuotaConfig{
+			MaxAdmins:            3,
+			MaxProfileImageBytes: 10 << 20,
+		},
+		Storage: StorageConfig{
+			Driver:       "azure",
+			SignedURLTTL: 15 * time.Minute,
+			LocalDir:     "./uploads",
+		},
+		SMS:  SMSConfig{Driver: "console"},
+		Mail: MailConfig{Driver: "console"},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+			AllowedHeaders: []string{"Authorization", "Content-Type", "Accept-Language", "X-Request-ID"},
+			MaxAge:         12 * time.Hour,
+		},
+		Worker: WorkerConfig{Concurrency: 2, VisibilityTimeout: time.Minute},
+		Privacy: PrivacyConfig{
+			DataExportTTL:       48 * time.Hour,
+			DeletionGracePeriod: 30 * 24 * time.Hour,
+		},
+		Seed: SeedConfig{
+			Admin: SeedAdminConfig{Name: "Administrator"},
+		},
 	}
+}
+
+// IsProduction true untuk APP_ENV=production
+func (c *Config) IsProduction() bool {
+	return c.App.Env == "production"
 }
\ No newline at end of file
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+
+)
+
+// DefaultFile dibaca jika CONFIG_FILE tidak diisi (boleh tidak ada)
+const DefaultFile = "config.yaml"
+
+// Load membaca konfigurasi dari semua sumber lalu memvalidasinya.
+// Error validasi berisi daftar lengkap masalah (*ValidationError).
+func Load() (*Config, error) {
+	err := godotenv.Load()
+	if err != nil {
+		err = godotenv.Load("../../.env")
+		if err != nil {
+			log.Println("⚠️  Warning: File .env tidak ditemukan, menggunakan Environment Variable sistem")
+		}
+	}
+
+	cfg := Default()
+	var problems []string
+
+	// 1. File YAML
+	path, explicit := os.LookupEnv("CONFIG_FILE")
+	if !explicit {
+		path = DefaultFile
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist) && !explicit:
+			// File default opsional
+		case err != nil:
+			problems = append(problems, fmt.Sprintf("CONFIG_FILE: %v", err))
+		default:
+			decoder := yaml.NewDecoder(bytes.NewReader(data))
+			decoder.KnownFields(true)
+			if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+				problems = append(problems, fmt.Sprintf("%s: %v", path, err))
+			}
+		}
+	}
+
+	// 2. Env + *_FILE
+	problems = append(problems, applyEnv(reflect.ValueOf(cfg).Elem())...)
+
+	// 3. Validasi
+	problems = append(problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return cfg, &ValidationError{Problems: problems}
+	}
+	return cfg, nil
+}
+
+// MustLoad dipakai command CLI: cetak semua masalah lalu keluar
+func MustLoad() *Config {
+	cfg, err := Load()
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	return cfg
+}
+
+// ValidationError berisi semua masalah konfigurasi sekaligus (bukan hanya yang pertama)
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("konfigurasi tidak valid (%d masalah):\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// applyEnv mengisi field bertag `env` dari environment. NAMA_FILE (jika ada) dibaca sebagai isi nilai.
+func applyEnv(v reflect.Value) []string {
+	var problems []string
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			problems = append(problems, applyEnv(value)...)
+			continue
+		}
+
+		name := field.Tag.Get("env")
+		if name == "" {
+			continue
+		}
+
+		raw, ok := os.LookupEnv(name)
+		if filePath, fileOK := os.LookupEnv(name + "_FILE"); fileOK && filePath != "" {
+			if ok && raw != "" {
+				problems = append(problems, fmt.Sprintf("%s dan %s_FILE tidak boleh diisi bersamaan", name, name))
+				continue
+			}
+			data, err := os.ReadFile(filePath)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s_FILE: %v", name, err))
+				continue
+			}
+			raw, ok = strings.TrimRight(string(data), "\r\n"), true
+		}
+		if !ok || raw == "" {
+			continue
+		}
+
+		if err := setField(value, raw); err != nil {
+			problems = append(problems, fmt.Sprintf("%s=%q: %v", name, raw, err))
+		}
+	}
+	return problems
+}
+
+func setField(value reflect.Value, raw string) error {
+	switch value.Interface().(type) {
+	case string:
+		value.SetString(raw)
+	case bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("harus true/false")
+		}
+		value.SetBool(b)
+	case int, int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("harus angka")
+		}
+		value.SetInt(n)
+	case float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("harus angka desimal, contoh 0.25")
+		}
+		value.SetFloat(f)
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("harus durasi, contoh 15m atau 24h")
+		}
+		value.SetInt(int64(d))
+	case []string:
+		var list []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		value.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("tipe %s belum didukung", value.Type())
+	}
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"bytes"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+
+)
+
+// RedactedValue pengganti nilai field secret saat konfigurasi dicetak
+const RedactedValue = "[REDACTED]"
+
+// Redacted mengembalikan salinan konfigurasi dengan semua field `secret:"true"` disamarkan.
+// Field secret yang kosong tetap kosong agar terlihat belum diisi.
+func (c *Config) Redacted() *Config {
+	clone := *c
+	redact(reflect.ValueOf(&clone).Elem())
+	return &clone
+}
+
+func redact(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			redact(value)
+			continue
+		}
+		if field.Tag.Get("secret") == "true" && value.Kind() == reflect.String && value.String() != "" {
+			value.SetString(RedactedValue)
+		}
+	}
+}
+
+// YAML mengubah konfigurasi ke YAML (format yang sama dengan file konfigurasi)
+func (c *Config) YAML() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), encoder.Close()
+}
\ No newline at end of file
@@ -0,0 +1,232 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+)
+
+// validate mengumpulkan SEMUA masalah konfigurasi agar bisa diperbaiki sekali jalan
+func (c *Config) validate() []string {
+	var p []string
+	add := func(format string, args ...any) {
+		p = append(p, fmt.Sprintf(format, args...))
+	}
+
+	switch c.App.Env {
+	case "development", "test", "staging", "production":
+	case "":
+		add("app.env (APP_ENV) wajib diisi: development, test, staging atau production")
+	default:
+		add("app.env (APP_ENV) harus development, test, staging atau production, bukan %q", c.App.Env)
+	}
+	if !isHTTPURL(c.App.BaseURL) {
+		add("app.base_url (APP_BASE_URL) harus URL http(s), bukan %q", c.App.BaseURL)
+	}
+
+	if port, err := strconv.Atoi(c.HTTP.Port); err != nil || port < 1 || port > 65535 {
+		add("http.port (PORT) harus angka 1-65535, bukan %q", c.HTTP.Port)
+	}
+	if c.HTTP.ReadTimeout <= 0 || c.HTTP.ReadHeaderTimeout <= 0 || c.HTTP.WriteTimeout <= 0 || c.HTTP.IdleTimeout <= 0 {
+		add("http.read_timeout/read_header_timeout/write_timeout/idle_timeout harus lebih dari 0")
+	}
+	if c.HTTP.DrainDelay < 0 {
+		add("http.drain_delay (HTTP_DRAIN_DELAY) tidak boleh negatif")
+	}
+	if c.HTTP.ShutdownTimeout <= 0 {
+		add("http.shutdown_timeout (HTTP_SHUTDOWN_TIMEOUT) harus lebih dari 0")
+	}
+
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		add("log.level (LOG_LEVEL) harus debug, info, warn atau error, bukan %q", c.Log.Level)
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		add("log.format (LOG_FORMAT) harus json atau text, bukan %q", c.Log.Format)
+	}
+
+	if c.Metrics.Enabled && !strings.HasPrefix(c.Metrics.Path, "/") {
+		add("metrics.path (METRICS_PATH) harus diawali \"/\", bukan %q", c.Metrics.Path)
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "otlp":
+		if c.Tracing.OTLPEndpoint == "" {
+			add("tracing.otlp_endpoint (OTEL_EXPORTER_OTLP_ENDPOINT) wajib diisi untuk exporter otlp")
+		}
+	default:
+		add("tracing.exporter (TRACING_EXPORTER) harus none, stdout atau otlp, bukan %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		add("tracing.sample_ratio (TRACING_SAMPLE_RATIO) harus di antara 0 dan 1")
+	}
+
+	if c.Database.URL == "" {
+		add("database.url (DATABASE_URL) wajib diisi")
+	}
+	if c.Redis.Addr == "" {
+		add("redis.addr (REDIS_ADDR) wajib diisi")
+	}
+	if c.Redis.DB < 0 {
+		add("redis.db (REDIS_DB) tidak boleh negatif")
+	}
+
+	if c.Auth.JWTSecret == "" {
+		add("auth.jwt_secret (JWT_SECRET) wajib diisi")
+	} else if c.IsProduction() && len(c.Auth.JWTSecret) < 32 {
+		add("auth.jwt_secret (JWT_SECRET) minimal 32 karakter di production")
+	}
+	if c.Auth.AccessTokenTTL <= 0 {
+		add("auth.access_token_ttl (ACCESS_TOKEN_TTL) harus lebih dari 0")
+	}
+	if c.Auth.MagicLinkTTL <= 0 {
+		add("auth.magic_link_ttl (MAGIC_LINK_TTL) harus lebih dari 0")
+	}
+	// Masa berlaku link ganti email ditulis dalam jam di isi email
+	if c.Auth.EmailChangeTTL < time.Hour {
+		add("auth.email_change_ttl (EMAIL_CHANGE_TTL) minimal 1h")
+	}
+	if c.Auth.OTPTTL <= 0 {
+		add("auth.otp_ttl (OTP_TTL) harus lebih dari 0")
+	}
+	// Batas bcrypt: di bawah 10 terlalu lemah, di atas 16 login terlalu lambat
+	if c.Auth.BcryptCost < 10 || c.Auth.BcryptCost > 16 {
+		add("auth.bcrypt_cost (BCRYPT_COST) harus 10-16, bukan %d", c.Auth.BcryptCost)
+	}
+
+	if c.RateLimit.GlobalLimit < 1 || c.RateLimit.GlobalWindow <= 0 {
+		add("rate_limit.global_limit/global_window harus lebih dari 0")
+	}
+	if c.RateLimit.LoginLimit < 1 || c.RateLimit.LoginWindow <= 0 {
+		add("rate_limit.login_limit/login_window harus lebih dari 0")
+	}
+
+	// Masa berlaku link export ditulis dalam jam di isi email
+	if c.Privacy.DataExportTTL < time.Hour {
+		add("privacy.data_export_ttl (DATA_EXPORT_TTL) minimal 1h")
+	}
+	if c.Privacy.DeletionGracePeriod < time.Hour {
+		add("privacy.deletion_grace_period (DELETION_GRACE_PERIOD) minimal 1h")
+	}
+	// Di production kunci audit wajib terpisah dari JWT secret (fallback hanya untuk development)
+	if c.IsProduction() && len(c.Audit.HMACKey) < 32 {
+		add("audit.hmac_key (AUDIT_HMAC_KEY) wajib diisi, minimal 32 karakter di production")
+	}
+	if c.Quota.MaxAdmins < 1 {
+		add("quota.max_admins (MAX_ADMINS) minimal 1")
+	}
+	if c.Quota.MaxProfileImageBytes < 1 {
+		add("quota.max_profile_image_bytes (MAX_PROFILE_IMAGE_BYTES) harus lebih dari 0")
+	}
+
+	switch c.Storage.Driver {
+	case "azure":
+		if c.Storage.AzureConnectionString == "" {
+			add("storage.azure_connection_string (AZURE_STORAGE_CONNECTION_STRING) wajib untuk driver azure")
+		}
+		if c.Storage.AzureContainer == "" {
+			add("storage.azure_container (AZURE_CONTAINER_NAME) wajib untuk driver azure")
+		}
+	case "local":
+		if c.Storage.LocalDir == "" {
+			add("storage.local_dir (STORAGE_LOCAL_DIR) wajib untuk driver local")
+		}
+		if c.Storage.LocalBaseURL != "" && !isHTTPURL(c.Storage.LocalBaseURL) {
+			add("storage.local_base_url (STORAGE_LOCAL_BASE_URL) harus URL http(s)")
+		}
+	default:
+		add("storage.driver (STORAGE_DRIVER) harus azure atau local, bukan %q", c.Storage.Driver)
+	}
+	if c.Storage.Private && c.Storage.SignedURLTTL <= 0 {
+		add("storage.signed_url_ttl (SIGNED_URL_TTL) harus lebih dari 0 untuk storage private")
+	}
+
+	// Driver console & file menulis OTP dan link login/konfirmasi apa adanya ke log atau disk
+	if c.IsProduction() && (c.SMS.Driver == "console" || c.SMS.Driver == "file") {
+		add("sms.driver (SMS_DRIVER) %q tidak boleh dipakai di production, gunakan http", c.SMS.Driver)
+	}
+	if c.IsProduction() && (c.Mail.Driver == "console" || c.Mail.Driver == "file") {
+		add("mail.driver (MAIL_DRIVER) %q tidak boleh dipakai di production, gunakan smtp", c.Mail.Driver)
+	}
+	switch c.SMS.Driver {
+	case "console":
+	case "file":
+		if c.SMS.FilePath == "" {
+			add("sms.file_path (SMS_FILE_PATH) wajib untuk driver file")
+		}
+	case "http":
+		if !isHTTPURL(c.SMS.HTTPURL) {
+			add("sms.http_url (SMS_HTTP_URL) wajib URL http(s) untuk driver http")
+		}
+	default:
+		add("sms.driver (SMS_DRIVER) harus console, file atau http, bukan %q", c.SMS.Driver)
+	}
+
+	switch c.Mail.Driver {
+	case "console":
+	case "file":
+		if c.Mail.FilePath == "" {
+			add("mail.file_path (MAIL_FILE_PATH) wajib untuk driver file")
+		}
+	case "smtp":
+		if c.Mail.SMTPHost == "" {
+			add("mail.smtp_host (SMTP_HOST) wajib untuk driver smtp")
+		}
+		if c.Mail.From == "" {
+			add("mail.from (MAIL_FROM) wajib untuk driver smtp")
+		}
+	default:
+		add("mail.driver (MAIL_DRIVER) harus console, file atau smtp, bukan %q", c.Mail.Driver)
+	}
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" {
+			// Browser menolak kombinasi ini, lebih baik gagal saat start
+			if c.CORS.AllowCredentials {
+				add("cors.allowed_origins tidak boleh \"*\" jika cors.allow_credentials aktif")
+			}
+			continue
+		}
+		if !isHTTPURL(origin) {
+			add("cors.allowed_origins: %q bukan origin http(s) yang valid", origin)
+		}
+	}
+	if c.CORS.MaxAge < 0 {
+		add("cors.max_age (CORS_MAX_AGE) tidak boleh negatif")
+	}
+
+	if c.Worker.Concurrency < 1 {
+		add("worker.concurrency (WORKER_CONCURRENCY) minimal 1")
+	}
+	if c.Worker.VisibilityTimeout < 3*time.Second {
+		add("worker.visibility_timeout (WORKER_VISIBILITY_TIMEOUT) minimal 3s")
+	}
+
+	// Password akun demo tersimpan di repo, jadi tidak boleh ada di production
+	if c.Seed.DemoUsers && c.IsProduction() {
+		add("seed.demo_users (SEED_DEMO_USERS) tidak boleh aktif di production")
+	}
+	if c.Seed.Admin.Email != "" {
+		if c.Seed.Admin.Password == "" {
+			add("seed.admin.password (SEED_ADMIN_PASSWORD) wajib jika seed.admin.email diisi")
+		}
+		if c.Seed.Admin.Phone == "" {
+			add("seed.admin.phone (SEED_ADMIN_PHONE) wajib jika seed.admin.email diisi")
+		}
+	}
+
+	return p
+}
+
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return strings.EqualFold(u.Scheme, "http") || strings.EqualFold(u.Scheme, "https")
+}
\ No newline at end of file
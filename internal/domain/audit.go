@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+)
+
+// Actor di audit log selain UUID user
+const (
+	AuditActorAnonymous = "anonymous" // Request tanpa login (register, link dari email)
+	AuditActorSystem    = "system"    // Background worker
+	AuditActorCLI       = "cli"       // `server admin ...`
+)
+
+// Aksi yang dicatat di audit log
+const (
+	AuditUserRegistered       = "user.registered"
+	AuditProfileUpdated       = "user.profile_updated"
+	AuditPhoneVerified        = "user.phone_verified"
+	AuditEmailChangeRequested = "user.email_change_requested"
+	AuditEmailChanged         = "user.email_changed"
+	AuditEmailChangeCancelled = "user.email_change_cancelled"
+	AuditPasswordChanged      = "user.password_changed"
+	AuditPasswordReset        = "user.password_reset"
+	AuditRoleChanged          = "user.role_changed"
+	AuditUserDisabled         = "user.disabled"
+	AuditUserEnabled          = "user.enabled"
+	AuditDataExportRequested  = "user.data_export_requested"
+	AuditDeletionScheduled    = "user.deletion_scheduled"
+	AuditDeletionCancelled    = "user.deletion_cancelled"
+	AuditUserAnonymized       = "user.anonymized"
+	AuditSessionsRevoked      = "session.revoked_all"
+	AuditSessionLoggedOut     = "session.logout"
+)
+
+// AuditEvent satu kejadian keamanan. Tabelnya append-only (UPDATE/DELETE ditolak trigger) dan setiap
+// baris dirantai ke baris sebelumnya lewat PrevHash, jadi baris yang diubah atau dihapus ketahuan saat verifikasi.
+type AuditEvent struct {
+	ID        uint64            `gorm:"primaryKey" json:"id"`
+	ActorID   string            `gorm:"type:varchar(64);index" json:"actor_id"`
+	SubjectID string            `gorm:"type:varchar(36);index" json:"subject_id"` // UUID user yang terdampak
+	Action    string            `gorm:"type:varchar(64);index" json:"action"`
+	IP        string            `gorm:"type:varchar(45)" json:"ip"`
+	UserAgent string            `json:"user_agent"`
+	RequestID string            `json:"request_id"`
+	Changes   AuditChanges      `gorm:"type:jsonb;serializer:json" json:"changes,omitempty"`
+	Details   map[string]string `gorm:"type:jsonb;serializer:json" json:"details,omitempty"` // Konteks non-diff (alasan, metode)
+	CreatedAt time.Time         `json:"created_at"`
+	PrevHash  string            `gorm:"type:varchar(64);uniqueIndex" json:"prev_hash"`
+	Hash      string            `gorm:"type:varchar(64);uniqueIndex" json:"hash"`
+}
+
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
+
+// AuditChange nilai field sebelum/sesudah; nilai rahasia (hash password) ditulis "[REDACTED]",
+// data pribadi (nama, email, nomor HP) ditulis sebagai sidik jari "hmac:<hex>"
+type AuditChange struct {
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// AuditChanges diff per nama field (nama mengikuti JSON user)
+type AuditChanges map[string]AuditChange
+
+// ComputeHash = HMAC-SHA256(key, prev_hash + JSON isi event). ID tidak ikut karena baru ada setelah
+// insert; urutan rantai mengikuti ID. Key rahasia membuat rantai tidak bisa dihitung ulang oleh
+// pihak yang hanya punya akses tulis ke database.
+func (e *AuditEvent) ComputeHash(key []byte) string {
+	changes, details := e.Changes, e.Details
+	// nil dan map kosong dianggap sama, karena bentuknya bisa berubah setelah bolak-balik jsonb
+	if len(changes) == 0 {
+		changes = nil
+	}
+	if len(details) == 0 {
+		details = nil
+	}
+	payload, _ := json.Marshal(struct {
+		ActorID   string            `json:"actor_id"`
+		SubjectID string            `json:"subject_id"`
+		Action    string            `json:"action"`
+		IP        string            `json:"ip"`
+		UserAgent string            `json:"user_agent"`
+		RequestID string            `json:"request_id"`
+		Changes   AuditChanges      `json:"changes"`
+		Details   map[string]string `json:"details"`
+		CreatedAt string            `json:"created_at"`
+	}{
+		ActorID:   e.ActorID,
+		SubjectID: e.SubjectID,
+		Action:    e.Action,
+		IP:        e.IP,
+		UserAgent: e.UserAgent,
+		RequestID: e.RequestID,
+		Changes:   changes,
+		Details:   details,
+		CreatedAt: e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(e.PrevHash))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// AuditFilter kriteria pencarian audit log; field kosong tidak ikut memfilter
+type AuditFilter struct {
+	ActorID   string
+	SubjectID string
+	Action    string
+	From      time.Time
+	To        time.Time
+}
+
+// AuditVerification hasil pengecekan rantai hash dari baris pertama
+type AuditVerification struct {
+	Valid     bool      `json:"valid"`
+	Checked   int64     `json:"checked"`
+	BrokenAt  uint64    `json:"broken_at,omitempty"` // ID baris pertama yang tidak cocok
+	LastHash  string    `json:"last_hash,omitempty"` // Simpan di luar sistem untuk mendeteksi baris terakhir yang dihapus
+	CheckedAt time.Time `json:"checked_at"`
+}
\ No newline at end of file
@@ -1,50 +1,204 @@
 package domain
 import (
 	"context" 
+	"encoding/json"
+	"io"
+	"log/slog"
 	"mime/multipart"
+	"strings"
 	"time"
 
 	"khalif-identify/pkg/utils"
 
 )
 type Role struct {
-	ID   uint   `gorm:"primaryKey" json:"id"`
-	Name string `json:"name"`
+	ID          uint         `gorm:"primaryKey" json:"id"`
+	Name        string       `json:"name"`
+	Permissions []Permission `gorm:"many2many:role_permissions" json:"permissions,omitempty"`
+}
+type Permission struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	Name        string `gorm:"uniqueIndex" json:"name"`
+	Description string `json:"description"`
 }
 type User struct {
-	ID            uint      `gorm:"primaryKey" json:"-"`
-	UUID          string    `gorm:"type:varchar(36);uniqueIndex" json:"user_id"`
-	Name          string    `json:"name"`
-	Email         string    `gorm:"uniqueIndex" json:"email"`
-	PhoneNumber   string    `json:"phone_number"`
-	Password      string    `json:"-"`
-	ProfileImage  string    `json:"profile_image"`
-	DominantColor string    `json:"dominant_color"`
-	RoleID        uint      `json:"role_id"`
-	Role          Role      `gorm:"foreignKey:RoleID" json:"role"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID                  uint             `gorm:"primaryKey" json:"-"`
+	UUID                string           `gorm:"type:varchar(36);uniqueIndex" json:"user_id"`
+	Name                string           `json:"name"`
+	Email               string           `gorm:"uniqueIndex:idx_users_email_lower,expression:lower(email)" json:"email"` // Selalu disimpan lewat NormalizeEmail
+	PhoneNumber         string           `gorm:"uniqueIndex:idx_users_phone_number,where:phone_number <> ''" json:"phone_number"`
+	PhoneRegion         string           `gorm:"type:varchar(2)" json:"phone_region"`
+	PhoneVerified       bool             `gorm:"default:false" json:"phone_verified"`
+	PhoneVerifiedAt     *time.Time       `json:"phone_verified_at"`
+	Password            string           `json:"-"`
+	PasswordChangedAt   *time.Time       `json:"password_changed_at,omitempty"`
+	SessionVersion      int              `gorm:"->" json:"-"`                             // Token dengan claim sv lebih kecil sudah dicabut; read-only, hanya diubah lewat RevokeSessions
+	SessionsRevokedAt   *time.Time       `gorm:"->" json:"sessions_revoked_at,omitempty"` // Terakhir kali semua sesi dicabut
+	ProfileImage        string           `json:"profile_image"`
+	DominantColor       string           `json:"dominant_color"`
+	Theme               utils.ColorTheme `gorm:"type:jsonb;serializer:json" json:"theme"`
+	ImageStatus         string           `gorm:"type:varchar(20);default:'ready'" json:"image_status"`
+	DisabledAt          *time.Time       `json:"disabled_at,omitempty"`
+	DeletionScheduledAt *time.Time       `gorm:"index" json:"deletion_scheduled_at,omitempty"`      // Soft delete: login ditolak, dianonimkan setelah waktu ini
+	AnonymizedAt        *time.Time       `json:"anonymized_at,omitempty"`                           // Tombstone: data pribadi sudah dihapus
+	Locale              string           `gorm:"type:varchar(8);not null;default:''" json:"locale"` // Bahasa email/SMS: id | en, kosong = ikut request
+	RoleID              uint             `json:"role_id"`
+	Role                Role             `gorm:"foreignKey:RoleID" json:"role"`
+	CreatedAt           time.Time        `json:"created_at"`
+	UpdatedAt           time.Time        `json:"updated_at"`
+
+	// Hanya untuk response: terisi jika ProfileImage adalah signed URL (container private)
+	ProfileImageExpiresAt *time.Time `gorm:"-" json:"profile_image_expires_at,omitempty"`
+}
+// LogValue: user di log hanya tampil sebagai identitas, tanpa email/nomor HP/hash password
+func (u User) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("uuid", u.UUID),
+		slog.Uint64("role_id", uint64(u.RoleID)),
+	)
+}
+
+// LoginEvent satu login yang berhasil; dipakai untuk riwayat login dan daftar sesi di export data
+type LoginEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	UserID    uint      `gorm:"index" json:"-"`
+	Method    string    `gorm:"type:varchar(20)" json:"method"` // email | phone | otp | magic_link | password_change
+	IP        string    `gorm:"type:varchar(45)" json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	RequestID string    `json:"request_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NormalizeEmail: email adalah identitas case-insensitive, disimpan & dicari dalam bentuk trim + lower-case
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// TombstoneEmail pengganti email user yang sudah dianonimkan: unik per user (unique index tetap
+// terpenuhi), tidak bisa menerima email (.invalid), dan email aslinya bebas dipakai mendaftar lagi
+func TombstoneEmail(userUUID string) string {
+	return "deleted-" + userUUID + "@deleted.invalid"
 }
+
+// Status pemrosesan foto profil (dikerjakan oleh background worker)
+const (
+	ImageStatusReady      = "ready"
+	ImageStatusProcessing = "processing"
+	ImageStatusFailed     = "failed"
+)
+
 type UserRepository interface {
 	Create(ctx context.Context, user *User) error
 	FindByEmail(ctx context.Context, email string) (*User, error)
+	FindByPhone(ctx context.Context, phone string) (*User, error)
 	FindByID(ctx context.Context, id uint) (*User, error)
+	FindByUUID(ctx context.Context, uuid string) (*User, error)
 	FindAll(ctx context.Context, page, limit int) ([]User, int64, error)
 	Update(ctx context.Context, user *User) error
+	UpdateEmail(ctx context.Context, userUUID, oldEmail, newEmail string) error
+	// UpdateProfileImage hanya menyimpan kolom foto profil dan hanya untuk akun yang tidak sedang
+	// menunggu hapus/sudah dianonimkan; selain itu ErrUserNotFound
+	UpdateProfileImage(ctx context.Context, user *User) error
+	// Anonymize menyimpan tombstone user dan menghapus riwayat loginnya dalam satu transaksi
+	Anonymize(ctx context.Context, user *User) error
+	FindDeletionDue(ctx context.Context, before time.Time, limit int) ([]User, error)
+	// RevokeSessions menaikkan session_version secara atomik dan mengembalikan versi barunya
+	RevokeSessions(ctx context.Context, userUUID string, at time.Time) (int, error)
+	RecordLogin(ctx context.Context, event *LoginEvent) error
+	FindLogins(ctx context.Context, userID uint, since time.Time) ([]LoginEvent, error)
+	// AppendAudit menulis satu baris audit; seal dipanggil dengan hash baris terakhir (di dalam lock)
+	// dan wajib mengisi PrevHash & Hash sebelum insert
+	AppendAudit(ctx context.Context, event *AuditEvent, seal func(prevHash string)) error
+	FindAudit(ctx context.Context, filter AuditFilter, page, limit int) ([]AuditEvent, int64, error)
+	// ScanAudit membaca audit log urut ID naik mulai setelah afterID (untuk export & verifikasi)
+	ScanAudit(ctx context.Context, filter AuditFilter, afterID uint64, limit int) ([]AuditEvent, error)
 	CountByRoleID(ctx context.Context, roleID uint) (int64, error)
+	FindRoleByID(ctx context.Context, id uint) (*Role, error)
 }
 type CacheRepository interface {
 	Get(ctx context.Context, key string) (string, error)
 	Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error
 	Del(ctx context.Context, key string) error
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error)
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	GetDel(ctx context.Context, key string) (string, error)
+}
+// OTPChallenge adalah info untuk client setelah kode OTP dikirim
+type OTPChallenge struct {
+	Destination string `json:"destination"` // Nomor yang disamarkan, contoh: +62812****7890
+	ExpiresIn   int    `json:"expires_in"`  // Detik sampai kode kedaluwarsa
+	ResendIn    int    `json:"resend_in"`   // Detik sampai boleh minta kode baru
+}
+// EmailChangeChallenge dikembalikan setelah link konfirmasi dikirim ke email baru
+type EmailChangeChallenge struct {
+	Email     string `json:"email"`      // Email baru (menunggu konfirmasi)
+	ExpiresIn int    `json:"expires_in"` // Detik sampai link kedaluwarsa
+}
+// DataExport arsip ZIP data pribadi user yang siap diunduh
+type DataExport struct {
+	Filename string
+	Content  io.ReadCloser // Dibaca langsung dari storage; pemanggil wajib menutupnya
+}
+// LoginLinkChallenge dikembalikan ke device yang meminta magic link; Nonce wajib dikirim balik saat verifikasi
+type LoginLinkChallenge struct {
+	Nonce     string `json:"nonce"`
+	ExpiresIn int    `json:"expires_in"`
+}
+
+type JobQueue interface {
+	Enqueue(ctx context.Context, jobType string, payload interface{}) error
+	// EnqueueAt menjadwalkan job agar baru diproses setelah waktu tertentu
+	EnqueueAt(ctx context.Context, jobType string, payload interface{}, at time.Time) error
+	// Discard membuang job yang belum diambil worker (siap/terjadwal) dengan tipe tertentu yang
+	// payload-nya cocok, lalu mengembalikan payload job yang dibuang
+	Discard(ctx context.Context, jobType string, match func(payload json.RawMessage) bool) ([]json.RawMessage, error)
 }
 type UserUseCase interface {
-	Register(ctx context.Context, name, email, phone, password string, file multipart.File, fileHeader *multipart.FileHeader) (*User, error)
-	RegisterCustomer(ctx context.Context, name, email, phone, password string, file multipart.File, fileHeader *multipart.FileHeader) (*User, error)
+	Register(ctx context.Context, name, email, phone, phoneRegion, password string, file multipart.File, fileHeader *multipart.FileHeader) (*User, error)
+	RegisterCustomer(ctx context.Context, name, email, phone, phoneRegion, password string, file multipart.File, fileHeader *multipart.FileHeader) (*User, error)
 	Login(ctx context.Context, email, password string) (string, *User, error)
+	LoginWithPhone(ctx context.Context, phone, phoneRegion, password string) (string, *User, error)
+	SendLoginOTP(ctx context.Context, phone, phoneRegion string) (*OTPChallenge, error)
+	LoginWithOTP(ctx context.Context, phone, phoneRegion, code string) (string, *User, error)
+	SendLoginLink(ctx context.Context, email string) (*LoginLinkChallenge, error)
+	LoginWithLink(ctx context.Context, token, nonce string) (string, *User, error)
+
+	// Operasi admin (dipakai CLI), identifier boleh UUID atau email
+	SetRole(ctx context.Context, identifier string, roleID uint) (*User, error)
+	ResetPassword(ctx context.Context, identifier, newPassword string) (*User, error)
+	SetDisabled(ctx context.Context, identifier string, disabled bool) (*User, error)
 	Logout(ctx context.Context, tokenString string) error
-	UpdateProfile(ctx context.Context, userID uint, name, phone, password string, file multipart.File, fileHeader *multipart.FileHeader) (*User, error)
+	UpdateProfile(ctx context.Context, userUUID string, name, phone, phoneRegion, locale string, file multipart.File, fileHeader *multipart.FileHeader) (*User, error)
 	GetAllAdmins(ctx context.Context, page, limit int) ([]User, int64, error)
-	GetCountryCodes() []utils.Country
-	GetProfile(ctx context.Context, userID uint) (*User, error)
+	GetCountryCodes(acceptLanguage, query, sortBy string) utils.CountryList
+	GetProfile(ctx context.Context, userUUID string) (*User, error)
+	// PreferredLocale bahasa yang disimpan user (kosong = ikut Accept-Language), di-cache per user
+	PreferredLocale(ctx context.Context, userUUID string) (string, error)
+	// SessionVersion versi sesi user saat ini (dicek AuthMiddleware), di-cache per user
+	SessionVersion(ctx context.Context, userUUID string) (int, error)
+	SendPhoneOTP(ctx context.Context, userUUID string) (*OTPChallenge, error)
+	VerifyPhoneOTP(ctx context.Context, userUUID, code string) (*User, error)
+
+	// Ganti email: konfirmasi lewat link di email baru, email lama dapat link pembatalan
+	RequestEmailChange(ctx context.Context, userUUID, newEmail, currentPassword string, revokeSessions bool) (*EmailChangeChallenge, error)
+	ConfirmEmailChange(ctx context.Context, token string) (*User, error)
+	CancelEmailChange(ctx context.Context, token string) error
+
+	// ChangePassword mencabut semua sesi lain dan mengembalikan token baru untuk sesi ini
+	ChangePassword(ctx context.Context, userUUID, currentPassword, newPassword string) (string, *User, error)
+
+	// Export data pribadi: arsip dibuat worker, link unduh dikirim lewat email
+	RequestDataExport(ctx context.Context, userUUID string) error
+	DownloadDataExport(ctx context.Context, token string) (*DataExport, error)
+
+	// Hapus akun: soft delete dulu (bisa dibatalkan selama masa tenggang), lalu dianonimkan worker
+	RequestAccountDeletion(ctx context.Context, userUUID, currentPassword string) (*User, error)
+	CancelAccountDeletion(ctx context.Context, token string) (*User, error)
+	SetPendingDeletion(ctx context.Context, identifier string, pending bool) (*User, error)
+
+	// Audit log (khusus admin): cari, export NDJSON, dan cek rantai hash
+	GetAuditEvents(ctx context.Context, filter AuditFilter, page, limit int) ([]AuditEvent, int64, error)
+	ExportAuditEvents(ctx context.Context, filter AuditFilter, w io.Writer) error
+	VerifyAuditLog(ctx context.Context) (*AuditVerification, error)
 }
\ No newline at end of file
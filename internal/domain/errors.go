@@ -0,0 +1,170 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+)
+
+// ErrorKind mengelompokkan error domain; middleware memetakan tiap kind ke satu status HTTP
+type ErrorKind string
+
+const (
+	KindValidation    ErrorKind = "validation"
+	KindUnauthorized  ErrorKind = "unauthorized"
+	KindForbidden     ErrorKind = "forbidden"
+	KindNotFound      ErrorKind = "not_found"
+	KindConflict      ErrorKind = "conflict"
+	KindQuotaExceeded ErrorKind = "quota_exceeded"
+	KindRateLimited   ErrorKind = "rate_limited"
+	KindInternal      ErrorKind = "internal" // Gagal di sisi server, tetapi client perlu tahu apa yang sudah terjadi
+)
+
+// FieldError menjelaskan kesalahan pada satu field input
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error adalah error domain bertipe. Code stabil, dipakai client dan sebagai key katalog
+// terjemahan ("error.<code>"); Message bahasa Inggris hanya untuk log. Params mengisi
+// placeholder di terjemahan (misal batas kuota).
+// errors.Is membandingkan Code, jadi salinan dari WithParams/OnField tetap cocok dengan sentinel-nya.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Params  map[string]interface{}
+	Fields  []FieldError
+}
+
+func (e *Error) Error() string {
+	if len(e.Params) == 0 {
+		return e.Message
+	}
+	pairs := make([]string, 0, len(e.Params))
+	for name, value := range e.Params {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", name, value))
+	}
+	sort.Strings(pairs)
+	return e.Message + " (" + strings.Join(pairs, ", ") + ")"
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithParams mengembalikan salinan error dengan parameter pesan
+func (e *Error) WithParams(params map[string]interface{}) *Error {
+	copied := *e
+	copied.Params = params
+	return &copied
+}
+
+// OnField mengembalikan salinan error yang menunjuk ke field input tertentu
+func (e *Error) OnField(field string) *Error {
+	copied := *e
+	copied.Fields = []FieldError{{Field: field, Code: e.Code, Message: e.Message}}
+	return &copied
+}
+
+func NewValidationError(code, message string, fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message, Fields: fields}
+}
+
+func NewUnauthorizedError(code, message string) *Error {
+	return &Error{Kind: KindUnauthorized, Code: code, Message: message}
+}
+
+func NewForbiddenError(code, message string) *Error {
+	return &Error{Kind: KindForbidden, Code: code, Message: message}
+}
+
+func NewNotFoundError(code, message string) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message}
+}
+
+func NewConflictError(code, message string) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message}
+}
+
+func NewQuotaExceededError(code, message string) *Error {
+	return &Error{Kind: KindQuotaExceeded, Code: code, Message: message}
+}
+
+func NewRateLimitedError(code, message string) *Error {
+	return &Error{Kind: KindRateLimited, Code: code, Message: message}
+}
+
+func NewInternalError(code, message string) *Error {
+	return &Error{Kind: KindInternal, Code: code, Message: message}
+}
+
+// ValidationFailed dipakai saat beberapa field sekaligus tidak valid
+func ValidationFailed(fields ...FieldError) *Error {
+	return NewValidationError("validation_failed", "request validation failed", fields...)
+}
+
+// ErrCacheMiss murni internal (tidak pernah sampai ke client)
+var ErrCacheMiss = errors.New("cache: key not found")
+
+var (
+	ErrInvalidCredentials = NewUnauthorizedError("invalid_credentials", "invalid credentials")
+	ErrLoginLinkInvalid   = NewUnauthorizedError("login_link_invalid", "login link is invalid or has expired")
+	ErrUnauthorized       = NewUnauthorizedError("unauthorized", "authentication required")
+	ErrTokenInvalid       = NewUnauthorizedError("token_invalid", "access token is invalid")
+	ErrTokenRevoked       = NewUnauthorizedError("token_revoked", "access token has been revoked")
+
+	ErrAccountDisabled        = NewForbiddenError("account_disabled", "account is disabled")
+	ErrAccountPendingDeletion = NewForbiddenError("account_pending_deletion", "account is scheduled for deletion")
+	ErrAdminOnly              = NewForbiddenError("admin_only", "access denied, admins only")
+
+	ErrUserNotFound = NewNotFoundError("user_not_found", "user not found")
+	ErrRoleNotFound = NewNotFoundError("role_not_found", "role not found")
+
+	ErrEmailTaken           = NewConflictError("email_taken", "email already registered").OnField("email")
+	ErrPhoneTaken           = NewConflictError("phone_taken", "phone number already registered").OnField("phone")
+	ErrPhoneMissing         = NewConflictError("phone_missing", "user has no phone number")
+	ErrPhoneAlreadyVerified = NewConflictError("phone_already_verified", "phone number already verified")
+	ErrLocaleUnsupported    = NewValidationError("locale_unsupported", "unsupported locale").OnField("locale")
+
+	ErrCurrentPasswordInvalid = NewValidationError("current_password_invalid", "current password is incorrect").OnField("current_password")
+	ErrPasswordUnchanged      = NewValidationError("password_unchanged", "new password is the same as the current one").OnField("new_password")
+	ErrPasswordWeak           = NewValidationError("password_weak", "password does not meet the password policy").OnField("password")
+	ErrEmailUnchanged         = NewValidationError("email_unchanged", "new email is the same as the current one").OnField("email")
+	ErrEmailChangeInvalid     = NewValidationError("email_change_invalid", "email change link is invalid or has expired").OnField("token")
+	ErrAccountDeletionInvalid = NewValidationError("account_deletion_invalid", "account deletion cancel link is invalid or has expired").OnField("token")
+	ErrAccountDeleted         = NewConflictError("account_deleted", "account has already been deleted")
+	ErrSessionsNotRevoked     = NewInternalError("sessions_not_revoked", "password was changed, but other sessions could not be revoked")
+
+	ErrAdminQuotaFull = NewQuotaExceededError("admin_quota_full", "admin quota is full")
+	ErrImageTooLarge  = NewValidationError("image_too_large", "profile image is too large").OnField("image")
+	ErrImageInvalid   = NewValidationError("image_invalid", "profile image cannot be read").OnField("image")
+
+	ErrOTPInvalid         = NewValidationError("otp_invalid", "invalid verification code").OnField("code")
+	ErrOTPExpired         = NewValidationError("otp_expired", "verification code expired or not requested").OnField("code")
+	ErrOTPTooManyAttempts = NewValidationError("otp_too_many_attempts", "too many wrong codes, request a new one").OnField("code")
+	ErrDataExportPending  = NewRateLimitedError("data_export_pending", "a data export is already being prepared")
+	ErrDataExportInvalid  = NewNotFoundError("data_export_invalid", "data export link is invalid or has expired")
+	ErrOTPCooldown        = NewRateLimitedError("otp_cooldown", "please wait before requesting a new code")
+	ErrTooManyRequests    = NewRateLimitedError("too_many_requests", "too many requests, please slow down")
+)
+
+// OTPCooldownError membawa sisa waktu tunggu sebelum boleh kirim ulang (errors.Is -> ErrOTPCooldown)
+type OTPCooldownError struct {
+	RetryAfter time.Duration
+}
+
+func (e *OTPCooldownError) Error() string {
+	return fmt.Sprintf("%s (%ds)", ErrOTPCooldown.Error(), int(e.RetryAfter.Seconds()))
+}
+
+// Unwrap: errors.Is(err, ErrOTPCooldown) dan errors.As(*Error) tetap berlaku
+func (e *OTPCooldownError) Unwrap() error {
+	return ErrOTPCooldown.WithParams(map[string]interface{}{"seconds": int(e.RetryAfter.Seconds())})
+}
\ No newline at end of file
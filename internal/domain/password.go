@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"sync"
+	"unicode"
+
+)
+
+// PasswordPolicy menentukan apakah password baru boleh dipakai. Default-nya
+// DefaultPasswordPolicy, bisa diganti lewat SetPasswordPolicy (misal cek daftar password bocor).
+// Usecase menegakkannya untuk semua jalur (HTTP maupun CLI admin); validator request
+// memakai ValidPassword yang sama agar pesan error per field tetap muncul lebih awal.
+type PasswordPolicy func(password string) bool
+
+var (
+	passwordPolicyMu sync.RWMutex
+	passwordPolicy   PasswordPolicy = DefaultPasswordPolicy
+)
+
+// SetPasswordPolicy mengganti aturan password untuk semua pemanggilan berikutnya
+func SetPasswordPolicy(policy PasswordPolicy) {
+	passwordPolicyMu.Lock()
+	defer passwordPolicyMu.Unlock()
+	passwordPolicy = policy
+}
+
+// ValidPassword mengecek password dengan aturan yang sedang berlaku
+func ValidPassword(password string) bool {
+	passwordPolicyMu.RLock()
+	policy := passwordPolicy
+	passwordPolicyMu.RUnlock()
+	return policy(password)
+}
+
+// DefaultPasswordPolicy: 8-72 byte (batas bcrypt), minimal satu huruf dan satu angka
+func DefaultPasswordPolicy(password string) bool {
+	if len(password) < 8 || len(password) > 72 {
+		return false
+	}
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	return hasLetter && hasDigit
+}
\ No newline at end of file
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"khalif-identify/pkg/logger"
+
+)
+
+// HealthCheck satu dependency yang dicek /readyz (Postgres, Redis, storage)
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// ReadyCheckTimeout batas waktu semua pengecekan /readyz
+const ReadyCheckTimeout = 3 * time.Second
+
+type HealthHandler struct {
+	checks   []HealthCheck
+	draining atomic.Bool
+}
+
+func NewHealthHandler(checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{checks: checks}
+}
+
+// SetDraining dipanggil saat shutdown dimulai: /readyz langsung 503 supaya
+// load balancer berhenti mengirim request baru selama request lama diselesaikan.
+func (h *HealthHandler) SetDraining() {
+	h.draining.Store(true)
+}
+
+// Liveness: proses hidup dan bisa menjawab HTTP, tanpa cek dependency
+// (dependency mati tidak perlu membuat container di-restart).
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readiness mengecek semua dependency secara paralel
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	if h.draining.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "shutting_down"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), ReadyCheckTimeout)
+	defer cancel()
+
+	results := make(map[string]string, len(h.checks))
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	ready := true
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func(check HealthCheck) {
+			defer wg.Done()
+			status := "ok"
+			if err := check.Check(ctx); err != nil {
+				// Detail error (host, kredensial) hanya ke log, bukan ke response publik
+				logger.FromContext(ctx).Warn("⚠️ Readiness check gagal", "check", check.Name, "error", err)
+				status = "unavailable"
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			results[check.Name] = status
+			if status != "ok" {
+				ready = false
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "checks": results})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "checks": results})
+}
\ No newline at end of file
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"mime/multipart"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"khalif-identify/internal/domain"
+
+)
+
+// RegisterRequest dipakai Register (admin) dan RegisterCustomer. Bisa dikirim sebagai
+// multipart/form-data (dengan foto) atau JSON (tanpa foto).
+type RegisterRequest struct {
+	Name     string                `form:"name" json:"name" binding:"required,min=2,max=100,person_name"`
+	Email    string                `form:"email" json:"email" binding:"required,max=254,email"`
+	Phone    string                `form:"phone" json:"phone" binding:"required,max=32"`
+	Country  string                `form:"country" json:"country"`
+	Password string                `form:"password" json:"password" binding:"required,password"`
+	Image    *multipart.FileHeader `form:"image" json:"-" binding:"omitempty,upload"`
+}
+
+// UpdateProfileRequest: semua field opsional, field kosong berarti tidak diubah
+type UpdateProfileRequest struct {
+	Name    string                `form:"name" json:"name" binding:"omitempty,min=2,max=100,person_name"`
+	Phone   string                `form:"phone" json:"phone" binding:"omitempty,max=32"`
+	Country string                `form:"country" json:"country"`
+	Locale  string                `form:"locale" json:"locale" binding:"omitempty,max=8"`
+	Image   *multipart.FileHeader `form:"image" json:"-" binding:"omitempty,upload"`
+}
+
+// ChangeEmailRequest: ganti email wajib menyertakan password saat ini
+type ChangeEmailRequest struct {
+	Email           string `json:"email" binding:"required,max=254,email"`
+	CurrentPassword string `json:"current_password" binding:"required"`
+	RevokeSessions  bool   `json:"revoke_sessions"` // Cabut semua sesi setelah email baru dikonfirmasi
+}
+
+// ChangePasswordRequest: password baru ikut aturan domain.PasswordPolicy
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,password"`
+}
+
+// DeleteAccountRequest: hapus akun sendiri wajib menyertakan password saat ini
+type DeleteAccountRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+}
+
+// CancelAccountDeletionRequest: token dari link di email pemberitahuan hapus akun
+type CancelAccountDeletionRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// AuditQueryRequest filter audit log dari query string; from/to format RFC3339, to eksklusif
+type AuditQueryRequest struct {
+	ActorID   string    `form:"actor_id" binding:"max=64"`
+	SubjectID string    `form:"subject_id" binding:"max=36"`
+	Action    string    `form:"action" binding:"max=64"`
+	From      time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To        time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page      int       `form:"page,default=1" binding:"min=1"`
+	Limit     int       `form:"limit,default=50" binding:"min=1,max=200"`
+}
+
+func (r *AuditQueryRequest) filter() domain.AuditFilter {
+	return domain.AuditFilter{
+		ActorID:   strings.TrimSpace(r.ActorID),
+		SubjectID: strings.TrimSpace(r.SubjectID),
+		Action:    strings.TrimSpace(r.Action),
+		From:      r.From,
+		To:        r.To,
+	}
+}
+
+func (r *RegisterRequest) normalize() {
+	r.Name = normalizeName(r.Name)
+	r.Email = domain.NormalizeEmail(r.Email)
+	r.Phone = strings.TrimSpace(r.Phone)
+	r.Country = strings.TrimSpace(r.Country)
+}
+
+func (r *UpdateProfileRequest) normalize() {
+	r.Name = normalizeName(r.Name)
+	r.Phone = strings.TrimSpace(r.Phone)
+	r.Country = strings.TrimSpace(r.Country)
+	r.Locale = strings.TrimSpace(r.Locale)
+}
+
+func (r *ChangeEmailRequest) normalize() {
+	r.Email = domain.NormalizeEmail(r.Email)
+}
+
+// normalizer dijalankan sebelum validasi, jadi aturan binding melihat nilai yang sudah dirapikan
+type normalizer interface {
+	normalize()
+}
+
+type normalizingValidator struct {
+	binding.StructValidator
+}
+
+func (v normalizingValidator) ValidateStruct(obj any) error {
+	if n, ok := obj.(normalizer); ok {
+		n.normalize()
+	}
+	return v.StructValidator.ValidateStruct(obj)
+}
+
+var registerValidationsOnce sync.Once
+
+// registerValidations memasang aturan custom di validator Gin (sekali per proses)
+func registerValidations() {
+	registerValidationsOnce.Do(func() {
+		if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+			_ = v.RegisterValidation("person_name", validPersonName)
+			_ = v.RegisterValidation("password", validPassword)
+			_ = v.RegisterValidation("upload", validUpload)
+		}
+		binding.Validator = normalizingValidator{binding.Validator}
+	})
+}
+
+// validPersonName: huruf (termasuk beraksen), spasi, titik, apostrof dan tanda hubung
+func validPersonName(fl validator.FieldLevel) bool {
+	for _, r := range fl.Field().String() {
+		if unicode.IsLetter(r) || unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if !strings.ContainsRune(" .'-", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// validPassword memakai aturan yang sama dengan usecase (domain.ValidPassword)
+func validPassword(fl validator.FieldLevel) bool {
+	return domain.ValidPassword(fl.Field().String())
+}
+
+// validUpload: part "image" harus berupa file yang berisi, bukan part kosong
+func validUpload(fl validator.FieldLevel) bool {
+	// Validator sudah men-dereference pointer, jadi yang diterima adalah nilai FileHeader
+	header, ok := fl.Field().Interface().(multipart.FileHeader)
+	return ok && header.Filename != "" && header.Size > 0
+}
+
+// normalizeName merapikan spasi berlebih ("  Khalif   Ali " -> "Khalif Ali")
+func normalizeName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
\ No newline at end of file
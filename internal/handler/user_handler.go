@@ -1,7 +1,8 @@
 package handler
 
 import (
-	"log"
+	"errors"
+	"mime/multipart"
 	"net/http"
 	"strconv"
 	"strings"
@@ -9,6 +10,9 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"khalif-identify/internal/domain"
+	"khalif-identify/pkg/i18n"
+	"khalif-identify/pkg/logger"
+	"khalif-identify/pkg/metrics"
 
 )
 
@@ -17,48 +21,55 @@ type UserHandler struct {
 }
 
 func NewUserHandler(u domain.UserUseCase) *UserHandler {
+	registerValidations()
 	return &UserHandler{useCase: u}
 }
 
 func (h *UserHandler) Register(c *gin.Context) {
-	name := c.PostForm("name")
-	email := c.PostForm("email")
-	phone := c.PostForm("phone")
-	password := c.PostForm("password")
-	file, header, err := c.Request.FormFile("image")
-
-	if err != nil && err != http.ErrMissingFile {
-		log.Printf("[Register Failed] Upload Error: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Image upload error"})
+	var req RegisterRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logger.FromContext(c.Request.Context()).Warn("[Register Failed] invalid request", "error", err)
+		abortWithBindError(c, err)
+		return
+	}
+	file, err := openUpload(req.Image)
+	if err != nil {
+		abortWithBindError(c, err)
 		return
 	}
+	if file != nil {
+		defer file.Close()
+	}
 
-	user, err := h.useCase.Register(c.Request.Context(), name, email, phone, password, file, header)
+	user, err := h.useCase.Register(c.Request.Context(), req.Name, req.Email, req.Phone, req.Country, req.Password, file, req.Image)
 	if err != nil {
-		log.Printf("[Register Failed] Usecase Error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		logger.FromContext(c.Request.Context()).Warn("[Register Failed] usecase error", "email", req.Email, "error", err)
+		abortWithError(c, err)
 		return
 	}
 
-	log.Printf("[Register Success] Data: %+v", user)
+	logger.FromContext(c.Request.Context()).Info("[Register Success]", "user", user)
 	c.JSON(http.StatusCreated, gin.H{"data": user})
 }
 
 func (h *UserHandler) RegisterCustomer(c *gin.Context) {
-	name := c.PostForm("name")
-	email := c.PostForm("email")
-	phone := c.PostForm("phone")
-	password := c.PostForm("password")
-	file, header, err := c.Request.FormFile("image")
-
-	if err != nil && err != http.ErrMissingFile {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Image upload error"})
+	var req RegisterRequest
+	if err := c.ShouldBind(&req); err != nil {
+		abortWithBindError(c, err)
+		return
+	}
+	file, err := openUpload(req.Image)
+	if err != nil {
+		abortWithBindError(c, err)
 		return
 	}
+	if file != nil {
+		defer file.Close()
+	}
 
-	user, err := h.useCase.RegisterCustomer(c.Request.Context(), name, email, phone, password, file, header)
+	user, err := h.useCase.RegisterCustomer(c.Request.Context(), req.Name, req.Email, req.Phone, req.Country, req.Password, file, req.Image)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abortWithError(c, err)
 		return
 	}
 
@@ -66,24 +77,133 @@ func (h *UserHandler) RegisterCustomer(c *gin.Context) {
 }
 
 func (h *UserHandler) Login(c *gin.Context) {
+	// Login bisa pakai email + password, nomor HP + password, atau nomor HP + OTP
 	var input struct {
 		Email    string `json:"email"`
+		Phone    string `json:"phone"`
+		Country  string `json:"country"`
 		Password string `json:"password"`
+		OTP      string `json:"otp"`
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
-		log.Printf("[Login Failed] Bind JSON Error: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		logger.FromContext(c.Request.Context()).Warn("[Login Failed] bind JSON error", "error", err)
+		abortWithBindError(c, err)
 		return
 	}
 
-	token, user, err := h.useCase.Login(c.Request.Context(), input.Email, input.Password)
+	ctx := c.Request.Context()
+	var (
+		token  string
+		user   *domain.User
+		err    error
+		method string
+	)
+	switch {
+	case input.Phone != "" && input.OTP != "":
+		method = "otp"
+		token, user, err = h.useCase.LoginWithOTP(ctx, input.Phone, input.Country, input.OTP)
+	case input.Phone != "":
+		method = "phone"
+		token, user, err = h.useCase.LoginWithPhone(ctx, input.Phone, input.Country, input.Password)
+	default:
+		method = "email"
+		token, user, err = h.useCase.Login(ctx, input.Email, input.Password)
+	}
+	observeLogin(method, user, err)
+	var domainErr *domain.Error
+	if method == "otp" && errors.As(err, &domainErr) && domainErr.Kind == domain.KindValidation {
+		// Di endpoint login, kode OTP salah berarti autentikasi gagal (401), bukan kesalahan isi form
+		loginErr := *domainErr
+		loginErr.Kind = domain.KindUnauthorized
+		loginErr.Fields = nil
+		err = &loginErr
+	}
 	if err != nil {
-		log.Printf("[Login Failed] Auth Error (Email: %s): %v", input.Email, err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		// email & phone otomatis disamarkan oleh logger
+		logger.FromContext(ctx).Warn("[Login Failed] auth error", "email", input.Email, "phone", input.Phone, "error", err)
+		abortWithError(c, err)
 		return
 	}
 
-	log.Printf("[Login Success] User: %s", input.Email)
+	logger.FromContext(ctx).Info("[Login Success]", "user", user)
+	c.JSON(http.StatusOK, gin.H{
+		"token": token,
+		"data":  user,
+	})
+}
+
+// SendLoginOTP mengirim kode login ke nomor HP (tanpa token)
+func (h *UserHandler) SendLoginOTP(c *gin.Context) {
+	var input struct {
+		Phone   string `json:"phone" binding:"required"`
+		Country string `json:"country"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		abortWithBindError(c, err)
+		return
+	}
+
+	challenge, err := h.useCase.SendLoginOTP(c.Request.Context(), input.Phone, input.Country)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": localizedMessage(c, "message.otp_sent"),
+		"data":    challenge,
+	})
+}
+
+// SendLoginLink mengirim magic link ke email. Nonce di response disimpan client
+// (sekaligus di cookie) dan wajib dikirim balik saat link dibuka.
+func (h *UserHandler) SendLoginLink(c *gin.Context) {
+	var input struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		abortWithBindError(c, err)
+		return
+	}
+
+	challenge, err := h.useCase.SendLoginLink(c.Request.Context(), input.Email)
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Warn("[Login Link Failed]", "error", err)
+		abortWithError(c, err)
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(loginNonceCookie, challenge.Nonce, challenge.ExpiresIn, "/", "", c.Request.TLS != nil, true)
+	c.JSON(http.StatusOK, gin.H{
+		"message": localizedMessage(c, "message.login_link_sent"),
+		"data":    challenge,
+	})
+}
+
+// VerifyLoginLink menukar token dari email dengan token login (nonce dari body atau cookie)
+func (h *UserHandler) VerifyLoginLink(c *gin.Context) {
+	var input struct {
+		Token string `json:"token" binding:"required"`
+		Nonce string `json:"nonce"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		abortWithBindError(c, err)
+		return
+	}
+	if input.Nonce == "" {
+		input.Nonce, _ = c.Cookie(loginNonceCookie)
+	}
+
+	token, user, err := h.useCase.LoginWithLink(c.Request.Context(), input.Token, input.Nonce)
+	observeLogin("magic_link", user, err)
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Warn("[Login Link Failed]", "error", err)
+		abortWithError(c, err)
+		return
+	}
+
+	c.SetCookie(loginNonceCookie, "", -1, "/", "", c.Request.TLS != nil, true)
 	c.JSON(http.StatusOK, gin.H{
 		"token": token,
 		"data":  user,
@@ -96,13 +216,12 @@ func (h *UserHandler) GetAll(c *gin.Context) {
 
 	users, total, err := h.useCase.GetAllAdmins(c.Request.Context(), page, limit)
 	if err != nil {
-		log.Printf("[GetAll Failed] Error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abortWithError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"data":  users,
+		"data": users,
 		"meta": gin.H{
 			"page":  page,
 			"limit": limit,
@@ -111,32 +230,58 @@ func (h *UserHandler) GetAll(c *gin.Context) {
 	})
 }
 
+// GetCountryCodes: ?q= untuk cari (nama/ISO/kode telepon), ?sort=name|dial_code|iso_code.
+// Nama negara mengikuti Accept-Language (id/en). Data jarang berubah, jadi boleh di-cache client.
 func (h *UserHandler) GetCountryCodes(c *gin.Context) {
-	countries := h.useCase.GetCountryCodes()
-	c.JSON(http.StatusOK, gin.H{"data": countries})
+	list := h.useCase.GetCountryCodes(c.GetHeader("Accept-Language"), c.Query("q"), c.Query("sort"))
+
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Header("Vary", "Accept-Language")
+	c.Header("Content-Language", list.Language)
+	c.Header("ETag", list.ETag)
+	if match := c.GetHeader("If-None-Match"); match != "" && strings.Contains(match, list.ETag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": list.Countries,
+		"meta": gin.H{
+			"language": list.Language,
+			"total":    len(list.Countries),
+		},
+	})
 }
 
 func (h *UserHandler) UpdateProfile(c *gin.Context) {
-	userIDInterface, exists := c.Get("user_id")
+	userID, exists := currentUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		abortWithError(c, domain.ErrUnauthorized)
 		return
 	}
-	userID := uint(userIDInterface.(float64))
 
-	name := c.PostForm("name")
-	phone := c.PostForm("phone")
-	password := c.PostForm("password")
-	file, header, _ := c.Request.FormFile("image")
+	var req UpdateProfileRequest
+	if err := c.ShouldBind(&req); err != nil {
+		abortWithBindError(c, err)
+		return
+	}
+	file, err := openUpload(req.Image)
+	if err != nil {
+		abortWithBindError(c, err)
+		return
+	}
+	if file != nil {
+		defer file.Close()
+	}
 
-	updatedUser, err := h.useCase.UpdateProfile(c.Request.Context(), userID, name, phone, password, file, header)
+	updatedUser, err := h.useCase.UpdateProfile(c.Request.Context(), userID, req.Name, req.Phone, req.Country, req.Locale, file, req.Image)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abortWithError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Profile updated successfully",
+		"message": localizedMessage(c, "message.profile_updated"),
 		"data":    updatedUser,
 	})
 }
@@ -144,39 +289,383 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 func (h *UserHandler) Logout(c *gin.Context) {
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header missing"})
+		abortWithError(c, domain.ErrUnauthorized)
 		return
 	}
 
 	tokenSplit := strings.Split(authHeader, " ")
 	if len(tokenSplit) != 2 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid authorization format"})
+		abortWithError(c, domain.ErrTokenInvalid)
 		return
 	}
 	tokenString := tokenSplit[1]
 
 	err := h.useCase.Logout(c.Request.Context(), tokenString)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout"})
+		abortWithError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Successfully logged out"})
+	c.JSON(http.StatusOK, gin.H{"message": localizedMessage(c, "message.logged_out")})
 }
 
 func (h *UserHandler) GetProfile(c *gin.Context) {
-	userIDInterface, exists := c.Get("user_id")
+	userID, exists := currentUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		abortWithError(c, domain.ErrUnauthorized)
 		return
 	}
-	userID := uint(userIDInterface.(float64))
 
 	user, err := h.useCase.GetProfile(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		abortWithError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"data": user})
+}
+
+func (h *UserHandler) SendPhoneOTP(c *gin.Context) {
+	userID, exists := currentUserID(c)
+	if !exists {
+		abortWithError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	challenge, err := h.useCase.SendPhoneOTP(c.Request.Context(), userID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": localizedMessage(c, "message.verification_code_sent"),
+		"data":    challenge,
+	})
+}
+
+func (h *UserHandler) VerifyPhoneOTP(c *gin.Context) {
+	userID, exists := currentUserID(c)
+	if !exists {
+		abortWithError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		abortWithBindError(c, err)
+		return
+	}
+
+	user, err := h.useCase.VerifyPhoneOTP(c.Request.Context(), userID, input.Code)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": localizedMessage(c, "message.phone_verified"),
+		"data":    user,
+	})
+}
+
+// RequestEmailChange mengirim link konfirmasi ke email baru; email lama baru diganti setelah dikonfirmasi
+func (h *UserHandler) RequestEmailChange(c *gin.Context) {
+	userID, exists := currentUserID(c)
+	if !exists {
+		abortWithError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	var req ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithBindError(c, err)
+		return
+	}
+
+	challenge, err := h.useCase.RequestEmailChange(c.Request.Context(), userID, req.Email, req.CurrentPassword, req.RevokeSessions)
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Warn("[Email Change Failed]", "error", err)
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": localizedMessage(c, "message.email_change_requested"),
+		"data":    challenge,
+	})
+}
+
+// ConfirmEmailChange dipanggil dari link di email baru (tanpa login)
+func (h *UserHandler) ConfirmEmailChange(c *gin.Context) {
+	var input struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		abortWithBindError(c, err)
+		return
+	}
+
+	user, err := h.useCase.ConfirmEmailChange(c.Request.Context(), input.Token)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": localizedMessage(c, "message.email_changed"),
+		"data":    user,
+	})
+}
+
+// CancelEmailChange dipanggil dari link pembatalan di email lama (tanpa login)
+func (h *UserHandler) CancelEmailChange(c *gin.Context) {
+	var input struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		abortWithBindError(c, err)
+		return
+	}
+
+	if err := h.useCase.CancelEmailChange(c.Request.Context(), input.Token); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": localizedMessage(c, "message.email_change_cancelled")})
+}
+
+// ChangePassword: sesi lain dicabut, response membawa token baru pengganti token saat ini
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	userID, exists := currentUserID(c)
+	if !exists {
+		abortWithError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithBindError(c, err)
+		return
+	}
+
+	token, user, err := h.useCase.ChangePassword(c.Request.Context(), userID, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Warn("[Password Change Failed]", "error", err)
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": localizedMessage(c, "message.password_changed"),
+		"token":   token,
+		"data":    user,
+	})
+}
+
+// RequestDataExport menjadwalkan arsip data pribadi; link unduh dikirim ke email user
+func (h *UserHandler) RequestDataExport(c *gin.Context) {
+	userID, exists := currentUserID(c)
+	if !exists {
+		abortWithError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	if err := h.useCase.RequestDataExport(c.Request.Context(), userID); err != nil {
+		logger.FromContext(c.Request.Context()).Warn("[Data Export Failed]", "error", err)
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": localizedMessage(c, "message.data_export_requested"),
+	})
+}
+
+// DownloadDataExport mengirim arsip ZIP; token dari link email sudah cukup sebagai otorisasi
+func (h *UserHandler) DownloadDataExport(c *gin.Context) {
+	export, err := h.useCase.DownloadDataExport(c.Request.Context(), c.Query("token"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	defer export.Content.Close()
+
+	c.DataFromReader(http.StatusOK, -1, "application/zip", export.Content, map[string]string{
+		"Cache-Control":       "no-store",
+		"Content-Disposition": `attachment; filename="` + export.Filename + `"`,
+	})
+}
+
+// DeleteAccount menjadwalkan hapus akun sendiri; sesi dicabut dan login ditolak selama masa tenggang
+func (h *UserHandler) DeleteAccount(c *gin.Context) {
+	userID, exists := currentUserID(c)
+	if !exists {
+		abortWithError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	var req DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithBindError(c, err)
+		return
+	}
+
+	user, err := h.useCase.RequestAccountDeletion(c.Request.Context(), userID, req.CurrentPassword)
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Warn("[Account Deletion Failed]", "error", err)
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": localizedMessage(c, "message.account_deletion_scheduled"),
+		"data":    user,
+	})
+}
+
+// CancelAccountDeletion memulihkan akun lewat link pembatalan di email (tanpa login)
+func (h *UserHandler) CancelAccountDeletion(c *gin.Context) {
+	var req CancelAccountDeletionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithBindError(c, err)
+		return
+	}
+
+	user, err := h.useCase.CancelAccountDeletion(c.Request.Context(), req.Token)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": localizedMessage(c, "message.account_deletion_cancelled"),
+		"data":    user,
+	})
+}
+
+// currentUserID mengambil UUID user yang di-set oleh AuthMiddleware (claim "user_id")
+func currentUserID(c *gin.Context) (string, bool) {
+	value, exists := c.Get("user_id")
+	if !exists {
+		return "", false
+	}
+	userID, ok := value.(string)
+	return userID, ok && userID != ""
+}
+
+const loginNonceCookie = "login_link_nonce"
+
+// GetAuditEvents: audit log terbaru lebih dulu, bisa difilter actor_id, subject_id, action, from, to
+func (h *UserHandler) GetAuditEvents(c *gin.Context) {
+	var req AuditQueryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		abortWithBindError(c, err)
+		return
+	}
+
+	events, total, err := h.useCase.GetAuditEvents(c.Request.Context(), req.filter(), req.Page, req.Limit)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": events,
+		"meta": gin.H{
+			"page":  req.Page,
+			"limit": req.Limit,
+			"total": total,
+		},
+	})
+}
+
+// ExportAuditEvents mengalirkan audit log (filter sama dengan GetAuditEvents) sebagai NDJSON
+// urut dari yang terlama, lengkap dengan prev_hash/hash untuk diverifikasi di luar sistem
+func (h *UserHandler) ExportAuditEvents(c *gin.Context) {
+	var req AuditQueryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		abortWithBindError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Disposition", `attachment; filename="audit-log.ndjson"`)
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	if err := h.useCase.ExportAuditEvents(c.Request.Context(), req.filter(), c.Writer); err != nil {
+		// Setelah sebagian isi terkirim status tidak bisa diganti; export yang terpotong hanya dicatat
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Disposition")
+			abortWithError(c, err)
+			return
+		}
+		logger.FromContext(c.Request.Context()).Error("❌ Export audit log terputus", "error", err)
+	}
+}
+
+// VerifyAuditLog menghitung ulang rantai hash; valid=false berarti ada baris yang diubah atau dihapus
+func (h *UserHandler) VerifyAuditLog(c *gin.Context) {
+	result, err := h.useCase.VerifyAuditLog(c.Request.Context())
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if !result.Valid {
+		logger.FromContext(c.Request.Context()).Error("🚨 Rantai audit log putus", "broken_at", result.BrokenAt)
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// localizedMessage menerjemahkan pesan sukses ke bahasa yang dipilih middleware Locale/UserLocale
+func localizedMessage(c *gin.Context, key string) string {
+	return i18n.T(i18n.FromContext(c.Request.Context()), key, nil)
+}
+
+// openUpload membuka foto yang sudah lolos validasi binding; nil jika tidak ada foto
+func openUpload(header *multipart.FileHeader) (multipart.File, error) {
+	if header == nil {
+		return nil, nil
+	}
+	return header.Open()
+}
+
+// abortWithError menyerahkan error ke middleware.ErrorHandler, yang memetakan
+// error domain ke status HTTP dan menulis response problem+json
+func abortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// abortWithBindError untuk input yang gagal di-parse atau tidak lolos aturan binding
+func abortWithBindError(c *gin.Context, err error) {
+	_ = c.Error(err).SetType(gin.ErrorTypeBind)
+	c.Abort()
+}
+
+// observeLogin mencatat metric login dengan outcome terbatas (bukan pesan error)
+func observeLogin(method string, user *domain.User, err error) {
+	if err == nil {
+		metrics.ObserveLogin(method, metrics.LoginSuccess, user.Role.Name)
+		return
+	}
+
+	outcome := metrics.LoginError
+	switch {
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		outcome = metrics.LoginInvalidCredentials
+	case errors.Is(err, domain.ErrAccountDisabled):
+		outcome = metrics.LoginDisabled
+	case errors.Is(err, domain.ErrAccountPendingDeletion):
+		outcome = metrics.LoginPendingDeletion
+	case errors.Is(err, domain.ErrOTPInvalid), errors.Is(err, domain.ErrOTPExpired), errors.Is(err, domain.ErrOTPTooManyAttempts):
+		outcome = metrics.LoginInvalidOTP
+	case errors.Is(err, domain.ErrLoginLinkInvalid):
+		outcome = metrics.LoginInvalidLink
+	}
+	metrics.ObserveLogin(method, outcome, "")
 }
\ No newline at end of file
@@ -1,7 +1,11 @@
 package repository
 import (
 	"context"
+	"errors"
+	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
 
 	"khalif-identify/internal/domain"
@@ -14,12 +18,19 @@ func NewUserRepository(db *gorm.DB) *UserRepo {
 	return &UserRepo{db: db}
 }
 func (r *UserRepo) Create(ctx context.Context, user *domain.User) error {
-	return r.db.WithContext(ctx).Create(user).Error
+	user.Email = domain.NormalizeEmail(user.Email)
+	return translateError(r.db.WithContext(ctx).Create(user).Error)
 }
 func (r *UserRepo) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
 	var user domain.User
-	err := r.db.WithContext(ctx).Preload("Role").Where("email = ?", email).First(&user).Error
-	return &user, err
+	// lower(email) memakai unique index fungsional idx_users_email_lower
+	err := r.db.WithContext(ctx).Preload("Role").Where("lower(email) = ?", domain.NormalizeEmail(email)).First(&user).Error
+	return &user, translateError(err)
+}
+func (r *UserRepo) FindByPhone(ctx context.Context, phone string) (*domain.User, error) {
+	var user domain.User
+	err := r.db.WithContext(ctx).Preload("Role").Where("phone_number = ?", phone).First(&user).Error
+	return &user, translateError(err)
 }
 func (r *UserRepo) FindAll(ctx context.Context, page, limit int) ([]domain.User, int64, error) {
 	var users []domain.User
@@ -38,11 +49,185 @@ func (r *UserRepo) CountByRoleID(ctx context.Context, roleID uint) (int64, error
 	err := r.db.WithContext(ctx).Model(&domain.User{}).Where("role_id = ?", roleID).Count(&count).Error
 	return count, err
 }
+func (r *UserRepo) FindRoleByID(ctx context.Context, id uint) (*domain.Role, error) {
+	var role domain.Role
+	err := r.db.WithContext(ctx).First(&role, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &role, domain.ErrRoleNotFound
+	}
+	return &role, err
+}
 func (r *UserRepo) FindByID(ctx context.Context, id uint) (*domain.User, error) {
 	var user domain.User
 	err := r.db.WithContext(ctx).Preload("Role").First(&user, id).Error
-	return &user, err
+	return &user, translateError(err)
+}
+func (r *UserRepo) FindByUUID(ctx context.Context, uuid string) (*domain.User, error) {
+	var user domain.User
+	err := r.db.WithContext(ctx).Preload("Role").Where("uuid = ?", uuid).First(&user).Error
+	return &user, translateError(err)
 }
 func (r *UserRepo) Update(ctx context.Context, user *domain.User) error {
-	return r.db.WithContext(ctx).Save(user).Error
+	user.Email = domain.NormalizeEmail(user.Email)
+	return translateError(r.db.WithContext(ctx).Save(user).Error)
+}
+
+// UpdateEmail mengganti email hanya jika email lama masih sama, jadi dua konfirmasi yang
+// bersamaan tidak saling menimpa. Tidak ada baris yang cocok -> ErrUserNotFound.
+func (r *UserRepo) UpdateEmail(ctx context.Context, userUUID, oldEmail, newEmail string) error {
+	result := r.db.WithContext(ctx).Model(&domain.User{}).
+		Where("uuid = ? AND lower(email) = ?", userUUID, domain.NormalizeEmail(oldEmail)).
+		Update("email", domain.NormalizeEmail(newEmail))
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// UpdateProfileImage dipakai worker foto profil: hanya kolom foto yang ditulis (bukan Save seluruh
+// baris yang dibaca sebelum decode) dan akun yang menunggu hapus/sudah dianonimkan dilewati.
+func (r *UserRepo) UpdateProfileImage(ctx context.Context, user *domain.User) error {
+	result := r.db.WithContext(ctx).Model(user).
+		Where("anonymized_at IS NULL AND deletion_scheduled_at IS NULL").
+		Select("profile_image", "theme", "dominant_color", "image_status").
+		Updates(user)
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// Anonymize menyimpan tombstone user (data pribadi sudah dikosongkan usecase) dan menghapus
+// riwayat login yang berisi IP & user agent. Baris user tetap ada untuk foreign key dan audit.
+func (r *UserRepo) Anonymize(ctx context.Context, user *domain.User) error {
+	return translateError(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(user).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&domain.LoginEvent{}).Error
+	}))
+}
+
+// RevokeSessions: increment di SQL, jadi dua pencabutan bersamaan tetap menghasilkan dua versi
+func (r *UserRepo) RevokeSessions(ctx context.Context, userUUID string, at time.Time) (int, error) {
+	var versions []int
+	err := r.db.WithContext(ctx).Raw(
+		"UPDATE users SET session_version = session_version + 1, sessions_revoked_at = ? WHERE uuid = ? RETURNING session_version",
+		at, userUUID,
+	).Scan(&versions).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(versions) == 0 {
+		return 0, domain.ErrUserNotFound
+	}
+	return versions[0], nil
+}
+
+// FindDeletionDue mengembalikan akun yang masa tenggang hapusnya sudah lewat tapi belum dianonimkan
+func (r *UserRepo) FindDeletionDue(ctx context.Context, before time.Time, limit int) ([]domain.User, error) {
+	var users []domain.User
+	err := r.db.WithContext(ctx).Preload("Role").
+		Where("deletion_scheduled_at <= ? AND anonymized_at IS NULL", before).
+		Order("deletion_scheduled_at").Limit(limit).Find(&users).Error
+	return users, err
+}
+
+func (r *UserRepo) RecordLogin(ctx context.Context, event *domain.LoginEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+// FindLogins mengembalikan riwayat login user sejak waktu tertentu, terbaru lebih dulu
+func (r *UserRepo) FindLogins(ctx context.Context, userID uint, since time.Time) ([]domain.LoginEvent, error) {
+	var events []domain.LoginEvent
+	err := r.db.WithContext(ctx).Where("user_id = ? AND created_at >= ?", userID, since).
+		Order("created_at DESC").Find(&events).Error
+	return events, err
+}
+
+// auditChainLock kunci advisory Postgres untuk penulis audit log (harus beda dari kunci migrasi)
+const auditChainLock = 726518341
+
+// AppendAudit: lock transaksi membuat penulis antri, jadi dua baris tidak pernah memakai
+// prev_hash yang sama (rantai tidak bercabang)
+func (r *UserRepo) AppendAudit(ctx context.Context, event *domain.AuditEvent, seal func(prevHash string)) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLock).Error; err != nil {
+			return err
+		}
+		var last []string
+		if err := tx.Model(&domain.AuditEvent{}).Order("id DESC").Limit(1).Pluck("hash", &last).Error; err != nil {
+			return err
+		}
+		prevHash := ""
+		if len(last) > 0 {
+			prevHash = last[0]
+		}
+		seal(prevHash)
+		return tx.Create(event).Error
+	})
+}
+
+// FindAudit mengembalikan audit log terbaru lebih dulu
+func (r *UserRepo) FindAudit(ctx context.Context, filter domain.AuditFilter, page, limit int) ([]domain.AuditEvent, int64, error) {
+	var events []domain.AuditEvent
+	var total int64
+	if err := auditQuery(r.db.WithContext(ctx), filter).Model(&domain.AuditEvent{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := auditQuery(r.db.WithContext(ctx), filter).Order("id DESC").Limit(limit).Offset((page - 1) * limit).Find(&events).Error
+	return events, total, err
+}
+
+func (r *UserRepo) ScanAudit(ctx context.Context, filter domain.AuditFilter, afterID uint64, limit int) ([]domain.AuditEvent, error) {
+	var events []domain.AuditEvent
+	err := auditQuery(r.db.WithContext(ctx), filter).
+		Where("id > ?", afterID).Order("id").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+func auditQuery(db *gorm.DB, filter domain.AuditFilter) *gorm.DB {
+	if filter.ActorID != "" {
+		db = db.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.SubjectID != "" {
+		db = db.Where("subject_id = ?", filter.SubjectID)
+	}
+	if filter.Action != "" {
+		db = db.Where("action = ?", filter.Action)
+	}
+	if !filter.From.IsZero() {
+		db = db.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		db = db.Where("created_at < ?", filter.To)
+	}
+	return db
+}
+
+// translateError mengubah error Postgres/GORM yang relevan untuk client menjadi error domain.
+// Unique violation tetap bisa terjadi walau sudah dicek di usecase (dua request bersamaan).
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrUserNotFound
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		switch {
+		case strings.Contains(pgErr.ConstraintName, "email"):
+			return domain.ErrEmailTaken
+		case strings.Contains(pgErr.ConstraintName, "phone"):
+			return domain.ErrPhoneTaken
+		}
+	}
+	return err
 }
\ No newline at end of file
@@ -2,10 +2,13 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 
+	"khalif-identify/internal/domain"
+
 )
 
 type RedisRepo struct {
@@ -17,7 +20,11 @@ func NewCacheRepository(client *redis.Client) *RedisRepo {
 }
 
 func (r *RedisRepo) Get(ctx context.Context, key string) (string, error) {
-	return r.client.Get(ctx, key).Result()
+	value, err := r.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", domain.ErrCacheMiss
+	}
+	return value, err
 }
 
 func (r *RedisRepo) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
@@ -26,4 +33,37 @@ func (r *RedisRepo) Set(ctx context.Context, key string, value interface{}, ttl
 
 func (r *RedisRepo) Del(ctx context.Context, key string) error {
 	return r.client.Del(ctx, key).Err()
+}
+
+// incrScript menaikkan counter dan memasang TTL dalam satu langkah atomik. TTL dipasang selama key
+// belum punya TTL (setara EXPIRE NX, tetap jalan di Redis < 7), jadi counter tidak pernah abadi
+// meskipun proses berhenti di antara dua perintah. KEYS: counter. ARGV: TTL ms.
+var incrScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if redis.call('PTTL', KEYS[1]) == -1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// Incr menaikkan counter, TTL hanya dipasang saat counter belum punya TTL (window tidak bergeser)
+func (r *RedisRepo) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return incrScript.Run(ctx, r.client, []string{key}, ttl.Milliseconds()).Int64()
+}
+
+func (r *RedisRepo) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, key, value, ttl).Result()
+}
+
+func (r *RedisRepo) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return r.client.TTL(ctx, key).Result()
+}
+
+// GetDel mengambil lalu menghapus key secara atomik (untuk token sekali pakai)
+func (r *RedisRepo) GetDel(ctx context.Context, key string) (string, error) {
+	value, err := r.client.GetDel(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", domain.ErrCacheMiss
+	}
+	return value, err
 }
\ No newline at end of file
@@ -0,0 +1,360 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/internal/handler"
+	"khalif-identify/internal/usecase"
+	"khalif-identify/pkg/queue"
+	"khalif-identify/pkg/utils"
+
+)
+
+func TestAccountDeletion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userID := "2b1c6a9e-0f4d-4c1a-9f0e-333333333333"
+	setup := func() *handlerEnv {
+		return newHandlerEnv(userID, func(r *gin.Engine, h *handler.UserHandler) {
+			r.POST("/login", h.Login)
+			r.POST("/account/delete/cancel", h.CancelAccountDeletion)
+			r.POST("/account/delete", h.DeleteAccount)
+		})
+	}
+
+	t.Run("Delete Is Scheduled", func(t *testing.T) {
+		env := setup()
+		scheduledAt := time.Now().Add(30 * 24 * time.Hour)
+		env.uc.On("RequestAccountDeletion", userID, "rahasia123").
+			Return(&domain.User{UUID: userID, DeletionScheduledAt: &scheduledAt}, nil)
+
+		w := env.postJSON("/account/delete", `{"current_password":"rahasia123"}`)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Contains(t, w.Body.String(), "deletion_scheduled_at")
+		env.uc.AssertExpectations(t)
+	})
+
+	t.Run("Delete Requires Current Password", func(t *testing.T) {
+		env := setup()
+		w := env.postJSON("/account/delete", `{}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, "current_password", decodeProblem(t, w).Errors[0].Field)
+		env.uc.AssertNotCalled(t, "RequestAccountDeletion")
+	})
+
+	t.Run("Delete With Wrong Password", func(t *testing.T) {
+		env := setup()
+		env.uc.On("RequestAccountDeletion", userID, "salah1234").Return(nil, domain.ErrCurrentPasswordInvalid)
+
+		w := env.postJSON("/account/delete", `{"current_password":"salah1234"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, "current_password_invalid", decodeProblem(t, w).Code)
+	})
+
+	t.Run("Login Blocked During Grace Period", func(t *testing.T) {
+		env := setup()
+		env.uc.On("Login", "khalif@example.com", "rahasia123").Return("", nil, domain.ErrAccountPendingDeletion)
+
+		w := env.postJSON("/login", `{"email":"khalif@example.com","password":"rahasia123"}`)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "account_pending_deletion", decodeProblem(t, w).Code)
+	})
+
+	t.Run("Cancel Restores Account", func(t *testing.T) {
+		env := setup()
+		env.uc.On("CancelAccountDeletion", "tok-123").Return(&domain.User{UUID: userID}, nil)
+
+		w := env.postJSON("/account/delete/cancel", `{"token":"tok-123"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "deletion_scheduled_at")
+		env.uc.AssertExpectations(t)
+	})
+
+	t.Run("Cancel With Invalid Link", func(t *testing.T) {
+		env := setup()
+		env.uc.On("CancelAccountDeletion", "kedaluwarsa").Return(nil, domain.ErrAccountDeletionInvalid)
+
+		w := env.postJSON("/account/delete/cancel", `{"token":"kedaluwarsa"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, "account_deletion_invalid", decodeProblem(t, w).Code)
+	})
+
+	t.Run("Tombstone Email Is Not A Real Address", func(t *testing.T) {
+		email := domain.TombstoneEmail(userID)
+		assert.Equal(t, domain.NormalizeEmail(email), email)
+		assert.Contains(t, email, userID)
+		assert.Contains(t, email, ".invalid")
+	})
+}
+
+func TestAccountDeletionUseCase(t *testing.T) {
+	ctx := context.Background()
+	userUUID := "2b1c6a9e-0f4d-4c1a-9f0e-131313131313"
+	email := "khalif@gmail.com"
+
+	setup := func(t *testing.T, settings usecase.Settings) (*usecaseEnv, *usecase.AccountDeletionJobHandler) {
+		env := newUsecaseEnvWith(t, settings)
+		hash, err := utils.HashPasswordWithCost("rahasia123", bcrypt.MinCost)
+		require.NoError(t, err)
+		env.repo.Seed(domain.User{UUID: userUUID, Name: "Khalif", Email: email, Password: hash})
+		return env, usecase.NewAccountDeletionJobHandler(env.repo, env.cache, env.storage, env.settings)
+	}
+
+	// cancelToken mengambil token pembatalan dari email hapus akun terakhir
+	cancelToken := func(t *testing.T, env *usecaseEnv) string {
+		return linkToken(t, env.mail.Last().Body)
+	}
+
+	t.Run("Anonymize Wipes Personal Fields", func(t *testing.T) {
+		env, deletions := setup(t, usecase.DefaultSettings())
+		stored, err := env.storage.UploadFile(ctx, bytes.NewReader([]byte("foto")), "avatar.png")
+		require.NoError(t, err)
+		due := time.Now().Add(-time.Minute)
+		user := env.repo.User(userUUID)
+		verifiedAt := time.Now()
+		user.PhoneNumber, user.PhoneRegion, user.PhoneVerified, user.PhoneVerifiedAt = "+6281234567890", "ID", true, &verifiedAt
+		user.ProfileImage, user.DominantColor, user.Locale = stored, "#112233", "en"
+		user.DeletionScheduledAt = &due
+		require.NoError(t, env.repo.Update(ctx, user))
+		require.NoError(t, env.repo.RecordLogin(ctx, &domain.LoginEvent{UserID: user.ID, Method: "email", IP: "10.0.0.1"}))
+
+		env.runJobs(t, usecase.JobAnonymizeAccount, deletions.Handle)
+		require.NoError(t, deletions.Handle(ctx, anonymizeJob(t, userUUID)))
+
+		wiped := env.repo.User(userUUID)
+		require.NotNil(t, wiped.AnonymizedAt)
+		assert.Empty(t, wiped.Name)
+		assert.Equal(t, domain.TombstoneEmail(userUUID), wiped.Email)
+		assert.Empty(t, wiped.PhoneNumber)
+		assert.Empty(t, wiped.PhoneRegion)
+		assert.False(t, wiped.PhoneVerified)
+		assert.Nil(t, wiped.PhoneVerifiedAt)
+		assert.Empty(t, wiped.Password)
+		assert.Empty(t, wiped.ProfileImage)
+		assert.Empty(t, wiped.DominantColor)
+		assert.Empty(t, wiped.Locale)
+		assert.Equal(t, []string{stored}, env.storage.Deleted)
+
+		logins, err := env.repo.FindLogins(ctx, wiped.ID, time.Time{})
+		require.NoError(t, err)
+		assert.Empty(t, logins)
+
+		events := env.repo.AuditEvents()
+		require.NotEmpty(t, events)
+		anonymized := events[len(events)-1]
+		assert.Equal(t, domain.AuditUserAnonymized, anonymized.Action)
+		assert.Equal(t, domain.AuditActorSystem, anonymized.ActorID)
+		for field, change := range anonymized.Changes {
+			assert.NotContains(t, change.Before, "Khalif", field)
+			assert.NotContains(t, change.Before, "6281234567890", field)
+		}
+
+		// Job yang jalan ulang tidak mengubah apa pun
+		require.NoError(t, deletions.Handle(ctx, anonymizeJob(t, userUUID)))
+		assert.Len(t, env.repo.AuditEvents(), len(events))
+	})
+
+	t.Run("Grace Window Keeps Account Until Due", func(t *testing.T) {
+		env, deletions := setup(t, usecase.DefaultSettings())
+		requested := time.Now()
+		user, err := env.uc.RequestAccountDeletion(ctx, userUUID, "rahasia123")
+		require.NoError(t, err)
+
+		require.NotNil(t, user.DeletionScheduledAt)
+		assert.WithinDuration(t, requested.Add(env.settings.DeletionGracePeriod), *user.DeletionScheduledAt, 2*time.Second)
+		jobs := env.jobs.Of(usecase.JobAnonymizeAccount)
+		require.Len(t, jobs, 1)
+		assert.True(t, jobs[0].At.Equal(*user.DeletionScheduledAt), "job dijadwalkan di akhir masa tenggang")
+
+		// Job yang terlalu cepat jalan (misal jam worker melenceng) tidak menganonimkan akun
+		env.runJobs(t, usecase.JobAnonymizeAccount, deletions.Handle)
+		assert.Nil(t, env.repo.User(userUUID).AnonymizedAt)
+
+		_, _, err = env.uc.Login(ctx, email, "rahasia123")
+		assert.ErrorIs(t, err, domain.ErrAccountPendingDeletion)
+
+		// Permintaan ulang tidak menggeser jadwal
+		again, err := env.uc.RequestAccountDeletion(ctx, userUUID, "rahasia123")
+		require.NoError(t, err)
+		assert.True(t, again.DeletionScheduledAt.Equal(*user.DeletionScheduledAt))
+		assert.Len(t, env.jobs.Of(usecase.JobAnonymizeAccount), 1)
+	})
+
+	t.Run("Cancel Then Old Job Skips Account", func(t *testing.T) {
+		env, deletions := setup(t, usecase.DefaultSettings())
+		_, err := env.uc.RequestAccountDeletion(ctx, userUUID, "rahasia123")
+		require.NoError(t, err)
+
+		restored, err := env.uc.CancelAccountDeletion(ctx, cancelToken(t, env))
+		require.NoError(t, err)
+		assert.Nil(t, restored.DeletionScheduledAt)
+
+		require.NoError(t, deletions.Handle(ctx, anonymizeJob(t, userUUID)))
+		assert.Nil(t, env.repo.User(userUUID).AnonymizedAt)
+		_, _, err = env.uc.Login(ctx, email, "rahasia123")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Rescheduled Deletion Skips Old Job And Old Link", func(t *testing.T) {
+		env, deletions := setup(t, usecase.DefaultSettings())
+		_, err := env.uc.RequestAccountDeletion(ctx, userUUID, "rahasia123")
+		require.NoError(t, err)
+		oldToken := cancelToken(t, env)
+
+		// Jadwal lama dipulihkan lalu dijadwalkan ulang ke waktu lain; job lama jatuh tempo duluan
+		user := env.repo.User(userUUID)
+		rescheduled := user.DeletionScheduledAt.Add(time.Hour)
+		user.DeletionScheduledAt = &rescheduled
+		require.NoError(t, env.repo.Update(ctx, user))
+
+		require.NoError(t, deletions.Handle(ctx, anonymizeJob(t, userUUID)))
+		assert.Nil(t, env.repo.User(userUUID).AnonymizedAt)
+
+		_, err = env.uc.CancelAccountDeletion(ctx, oldToken)
+		assert.ErrorIs(t, err, domain.ErrAccountDeletionInvalid)
+	})
+
+	t.Run("Cancel Token Rejects Tampering", func(t *testing.T) {
+		env, _ := setup(t, usecase.DefaultSettings())
+		_, err := env.uc.RequestAccountDeletion(ctx, userUUID, "rahasia123")
+		require.NoError(t, err)
+		token := cancelToken(t, env)
+		uuid, rest, _ := strings.Cut(token, ".")
+		unix, signature, _ := strings.Cut(rest, ".")
+
+		for name, tampered := range map[string]string{
+			"empty":             "",
+			"no signature":      uuid + "." + unix,
+			"flipped signature": uuid + "." + unix + "." + tamper(signature),
+			"other user":        "2b1c6a9e-0f4d-4c1a-9f0e-000000000000." + unix + "." + signature,
+			"other schedule":    uuid + "." + fmt.Sprint(time.Now().Unix()) + "." + signature,
+		} {
+			_, err := env.uc.CancelAccountDeletion(ctx, tampered)
+			assert.ErrorIs(t, err, domain.ErrAccountDeletionInvalid, name)
+		}
+		assert.NotNil(t, env.repo.User(userUUID).DeletionScheduledAt, "akun tetap terjadwal dihapus")
+	})
+
+	t.Run("Cancel Token Expires With Grace Period", func(t *testing.T) {
+		settings := usecase.DefaultSettings()
+		settings.DeletionGracePeriod = time.Nanosecond
+		env, _ := setup(t, settings)
+		_, err := env.uc.RequestAccountDeletion(ctx, userUUID, "rahasia123")
+		require.NoError(t, err)
+
+		_, err = env.uc.CancelAccountDeletion(ctx, cancelToken(t, env))
+		assert.ErrorIs(t, err, domain.ErrAccountDeletionInvalid)
+	})
+
+	t.Run("Scheduling Discards Pending Export And Email Change", func(t *testing.T) {
+		env, _ := setup(t, usecase.DefaultSettings())
+		exports := usecase.NewDataExportJobHandler(env.repo, env.cache, env.jobs, env.storage, env.mail, env.settings)
+		require.NoError(t, env.uc.RequestDataExport(ctx, userUUID))
+		env.runJobs(t, usecase.JobBuildDataExport, exports.Handle)
+		exportToken := linkToken(t, env.mail.Last().Body)
+		_, err := env.uc.DownloadDataExport(ctx, exportToken)
+		require.NoError(t, err)
+
+		_, err = env.uc.RequestEmailChange(ctx, userUUID, "khalif.baru@gmail.com", "rahasia123", false)
+		require.NoError(t, err)
+		confirmToken := linkToken(t, env.mail.Sent()[len(env.mail.Sent())-2].Body)
+
+		_, err = env.uc.RequestAccountDeletion(ctx, userUUID, "rahasia123")
+		require.NoError(t, err)
+
+		_, err = env.uc.DownloadDataExport(ctx, exportToken)
+		assert.ErrorIs(t, err, domain.ErrDataExportInvalid)
+		_, err = env.uc.ConfirmEmailChange(ctx, confirmToken)
+		assert.ErrorIs(t, err, domain.ErrEmailChangeInvalid)
+		assert.Equal(t, email, env.repo.User(userUUID).Email)
+		assert.Empty(t, env.storage.Files, "arsip export ikut dihapus dari storage")
+		for _, key := range env.redis.Keys() {
+			assert.False(t, strings.HasPrefix(key, "data_export") || strings.HasPrefix(key, "email_change"), "key %s masih tersisa", key)
+		}
+	})
+
+	t.Run("Anonymize Discards Pending Export And Email Change", func(t *testing.T) {
+		env, deletions := setup(t, usecase.DefaultSettings())
+		exports := usecase.NewDataExportJobHandler(env.repo, env.cache, env.jobs, env.storage, env.mail, env.settings)
+		require.NoError(t, env.uc.RequestDataExport(ctx, userUUID))
+		env.runJobs(t, usecase.JobBuildDataExport, exports.Handle)
+		exportToken := linkToken(t, env.mail.Last().Body)
+
+		// Jadwal hapus yang ditulis langsung (misal lewat restore database) tanpa pembersihan di scheduleDeletion
+		due := time.Now().Add(-time.Minute)
+		user := env.repo.User(userUUID)
+		user.DeletionScheduledAt = &due
+		require.NoError(t, env.repo.Update(ctx, user))
+
+		require.NoError(t, deletions.Handle(ctx, anonymizeJob(t, userUUID)))
+
+		_, err := env.uc.DownloadDataExport(ctx, exportToken)
+		assert.ErrorIs(t, err, domain.ErrDataExportInvalid)
+		assert.Empty(t, env.storage.Files)
+	})
+
+	t.Run("Export Job Skips Account Pending Deletion", func(t *testing.T) {
+		env, _ := setup(t, usecase.DefaultSettings())
+		exports := usecase.NewDataExportJobHandler(env.repo, env.cache, env.jobs, env.storage, env.mail, env.settings)
+		require.NoError(t, env.uc.RequestDataExport(ctx, userUUID))
+		_, err := env.uc.RequestAccountDeletion(ctx, userUUID, "rahasia123")
+		require.NoError(t, err)
+		sent := len(env.mail.Sent())
+
+		env.runJobs(t, usecase.JobBuildDataExport, exports.Handle)
+		assert.Len(t, env.mail.Sent(), sent, "arsip tidak dibuat untuk akun yang akan dihapus")
+	})
+
+	t.Run("PurgeDue Works Through Every Batch", func(t *testing.T) {
+		env, deletions := setup(t, usecase.DefaultSettings())
+		past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+		const dueCount = 250 // Lebih dari dua batch
+		for i := 0; i < dueCount; i++ {
+			env.repo.Seed(domain.User{
+				UUID:                fmt.Sprintf("00000000-0000-4000-8000-%012d", i),
+				Email:               fmt.Sprintf("user%d@gmail.com", i),
+				DeletionScheduledAt: &past,
+			})
+		}
+		env.repo.Seed(domain.User{UUID: "00000000-0000-4000-9000-000000000001", Email: "nanti@gmail.com", DeletionScheduledAt: &future})
+
+		count, err := deletions.PurgeDue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, dueCount, count)
+		for i := 0; i < dueCount; i++ {
+			assert.NotNil(t, env.repo.User(fmt.Sprintf("00000000-0000-4000-8000-%012d", i)).AnonymizedAt)
+		}
+		assert.Nil(t, env.repo.User("00000000-0000-4000-9000-000000000001").AnonymizedAt)
+		assert.Nil(t, env.repo.User(userUUID).AnonymizedAt)
+
+		count, err = deletions.PurgeDue(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, count, "akun yang sudah dianonimkan tidak disapu ulang")
+	})
+}
+
+// anonymizeJob job anonimisasi seperti yang dijadwalkan scheduleDeletion
+func anonymizeJob(t *testing.T, userUUID string) *queue.Job {
+	payload, err := json.Marshal(usecase.AccountDeletionPayload{UserUUID: userUUID})
+	require.NoError(t, err)
+	return &queue.Job{Type: usecase.JobAnonymizeAccount, Payload: payload}
+}
\ No newline at end of file
@@ -0,0 +1,247 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/internal/handler"
+	"khalif-identify/internal/usecase"
+	"khalif-identify/pkg/middleware"
+	"khalif-identify/pkg/utils"
+
+)
+
+func TestAuditLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	subjectID := "2b1c6a9e-0f4d-4c1a-9f0e-444444444444"
+	setup := func() *handlerEnv {
+		return newHandlerEnv("", func(r *gin.Engine, h *handler.UserHandler) {
+			r.GET("/audit", h.GetAuditEvents)
+			r.GET("/audit/export", h.ExportAuditEvents)
+			r.GET("/audit/verify", h.VerifyAuditLog)
+		})
+	}
+
+	t.Run("List Applies Filter", func(t *testing.T) {
+		env := setup()
+		filter := domain.AuditFilter{
+			SubjectID: subjectID,
+			Action:    domain.AuditPasswordChanged,
+			From:      time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+		events := []domain.AuditEvent{{ID: 7, SubjectID: subjectID, Action: domain.AuditPasswordChanged}}
+		env.uc.On("GetAuditEvents", filter, 1, 50).Return(events, int64(1), nil)
+
+		w := env.get("/audit?subject_id="+subjectID+"&action=user.password_changed&from=2026-01-01T00:00:00Z")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"total":1`)
+		assert.Contains(t, w.Body.String(), domain.AuditPasswordChanged)
+		env.uc.AssertExpectations(t)
+	})
+
+	t.Run("List Rejects Oversized Page", func(t *testing.T) {
+		env := setup()
+		w := env.get("/audit?limit=1000")
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, "limit", decodeProblem(t, w).Errors[0].Field)
+		env.uc.AssertNotCalled(t, "GetAuditEvents")
+	})
+
+	t.Run("List Rejects Malformed Time", func(t *testing.T) {
+		env := setup()
+		w := env.get("/audit?from=kemarin")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		env.uc.AssertNotCalled(t, "GetAuditEvents")
+	})
+
+	t.Run("Export Streams NDJSON", func(t *testing.T) {
+		env := setup()
+		env.uc.On("ExportAuditEvents", domain.AuditFilter{ActorID: "cli:root"}, mock.Anything).
+			Run(func(args mock.Arguments) {
+				io.WriteString(args.Get(1).(io.Writer), "{\"id\":1}\n{\"id\":2}\n")
+			}).
+			Return(nil)
+
+		w := env.get("/audit/export?actor_id=cli:root")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Get("Content-Disposition"), "audit-log.ndjson")
+		assert.Equal(t, "{\"id\":1}\n{\"id\":2}\n", w.Body.String())
+	})
+
+	t.Run("Export Failure Before Output Is A Problem", func(t *testing.T) {
+		env := setup()
+		env.uc.On("ExportAuditEvents", domain.AuditFilter{}, mock.Anything).Return(assert.AnError)
+
+		w := env.get("/audit/export")
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, middleware.ProblemContentType, w.Header().Get("Content-Type"))
+		assert.Empty(t, w.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("Verify Reports Broken Chain", func(t *testing.T) {
+		env := setup()
+		env.uc.On("VerifyAuditLog").Return(&domain.AuditVerification{Valid: false, Checked: 41, BrokenAt: 42}, nil)
+
+		w := env.get("/audit/verify")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"valid":false`)
+		assert.Contains(t, w.Body.String(), `"broken_at":42`)
+	})
+}
+
+func TestAuditHashChain(t *testing.T) {
+	key := []byte("kunci-audit-untuk-test-minimal-32-karakter")
+	createdAt := time.Date(2026, 3, 1, 8, 30, 0, 123456000, time.UTC)
+
+	newEvent := func(prevHash string) *domain.AuditEvent {
+		event := &domain.AuditEvent{
+			ActorID:   "cli:root",
+			SubjectID: "2b1c6a9e-0f4d-4c1a-9f0e-444444444444",
+			Action:    domain.AuditRoleChanged,
+			IP:        "203.0.113.7",
+			RequestID: "req-1",
+			Changes:   domain.AuditChanges{"role_id": {Before: "3", After: "1"}},
+			Details:   map[string]string{"role": "Admin"},
+			CreatedAt: createdAt,
+			PrevHash:  prevHash,
+		}
+		event.Hash = event.ComputeHash(key)
+		return event
+	}
+
+	t.Run("Hash Is Deterministic", func(t *testing.T) {
+		first := newEvent("")
+		assert.Len(t, first.Hash, 64)
+		assert.Equal(t, first.Hash, newEvent("").Hash)
+	})
+
+	t.Run("Hash Depends On Previous Event", func(t *testing.T) {
+		first := newEvent("")
+		second := newEvent(first.Hash)
+		assert.NotEqual(t, first.Hash, second.Hash)
+	})
+
+	t.Run("Edited Field Breaks Hash", func(t *testing.T) {
+		event := newEvent("")
+		event.Changes["role_id"] = domain.AuditChange{Before: "3", After: "2"}
+		assert.NotEqual(t, event.Hash, event.ComputeHash(key))
+
+		event = newEvent("")
+		event.ActorID = "anonymous"
+		assert.NotEqual(t, event.Hash, event.ComputeHash(key))
+	})
+
+	t.Run("Hash Requires Key", func(t *testing.T) {
+		event := newEvent("")
+		assert.NotEqual(t, event.Hash, event.ComputeHash([]byte("kunci-lain")))
+	})
+
+	t.Run("Hash Survives Database Round Trip", func(t *testing.T) {
+		event := newEvent("")
+		event.Changes, event.Details = nil, nil
+		event.Hash = event.ComputeHash(key)
+
+		// jsonb bisa mengembalikan map kosong dan zona waktu lokal
+		data, err := json.Marshal(event)
+		assert.NoError(t, err)
+		var loaded domain.AuditEvent
+		assert.NoError(t, json.Unmarshal(data, &loaded))
+		loaded.Changes = domain.AuditChanges{}
+		loaded.Details = map[string]string{}
+		loaded.CreatedAt = loaded.CreatedAt.In(time.FixedZone("WIB", 7*3600))
+
+		assert.Equal(t, event.Hash, loaded.ComputeHash(key))
+	})
+}
+
+func TestAuditPersonalData(t *testing.T) {
+	ctx := context.Background()
+	userUUID := "2b1c6a9e-0f4d-4c1a-9f0e-141414141414"
+	oldEmail, newEmail := "khalif@gmail.com", "khalif.baru@gmail.com"
+
+	// setup menjalankan ganti profil dan ganti email, lalu mengembalikan semua baris audit
+	setup := func(t *testing.T, settings usecase.Settings) []domain.AuditEvent {
+		env := newUsecaseEnvWith(t, settings)
+		hash, err := utils.HashPasswordWithCost("rahasia123", bcrypt.MinCost)
+		require.NoError(t, err)
+		env.repo.Seed(domain.User{UUID: userUUID, Name: "Khalif", Email: oldEmail, Password: hash})
+
+		_, err = env.uc.UpdateProfile(ctx, userUUID, "Khalif Baru", "081234567890", "ID", "", nil, nil)
+		require.NoError(t, err)
+		_, err = env.uc.RequestEmailChange(ctx, userUUID, newEmail, "rahasia123", false)
+		require.NoError(t, err)
+		_, err = env.uc.ConfirmEmailChange(ctx, linkToken(t, env.mail.Sent()[0].Body))
+		require.NoError(t, err)
+		return env.repo.AuditEvents()
+	}
+
+	byAction := func(t *testing.T, events []domain.AuditEvent, action string) domain.AuditEvent {
+		for _, event := range events {
+			if event.Action == action {
+				return event
+			}
+		}
+		t.Fatalf("tidak ada audit %s", action)
+		return domain.AuditEvent{}
+	}
+
+	t.Run("Personal Values Are Never Stored", func(t *testing.T) {
+		events := setup(t, usecase.DefaultSettings())
+		raw, err := json.Marshal(events)
+		require.NoError(t, err)
+		for _, value := range []string{"Khalif", "khalif", "6281234567890", "gmail.com"} {
+			assert.NotContains(t, string(raw), value)
+		}
+	})
+
+	t.Run("Fingerprints Still Show Changes", func(t *testing.T) {
+		events := setup(t, usecase.DefaultSettings())
+
+		profile := byAction(t, events, domain.AuditProfileUpdated)
+		for _, field := range []string{"name", "phone_number"} {
+			change, ok := profile.Changes[field]
+			require.True(t, ok, field)
+			assert.True(t, strings.HasPrefix(change.After, "hmac:"), field)
+			assert.NotEqual(t, change.Before, change.After, field)
+		}
+		assert.Empty(t, profile.Changes["phone_number"].Before, "nilai kosong tetap kosong")
+		assert.Equal(t, "ID", profile.Changes["phone_region"].After, "field bukan data pribadi tidak diubah")
+
+		// Email yang sama menghasilkan sidik jari yang sama di event yang berbeda
+		requested := byAction(t, events, domain.AuditEmailChangeRequested)
+		changed := byAction(t, events, domain.AuditEmailChanged)
+		assert.Equal(t, requested.Details["new_email"], changed.Changes["email"].After)
+		assert.Equal(t, "false", requested.Details["revoke_sessions"])
+	})
+
+	t.Run("Fingerprint Depends On Audit Key", func(t *testing.T) {
+		first := setup(t, usecase.DefaultSettings())
+		settings := usecase.DefaultSettings()
+		settings.AuditKey = "kunci-audit-lain-minimal-32-karakter"
+		second := setup(t, settings)
+
+		assert.NotEqual(t,
+			byAction(t, first, domain.AuditEmailChanged).Changes["email"].After,
+			byAction(t, second, domain.AuditEmailChanged).Changes["email"].After)
+	})
+}
\ No newline at end of file
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"khalif-identify/internal/handler"
+	"khalif-identify/internal/tests/mocks"
+	"khalif-identify/pkg/utils"
+
+)
+
+func TestCountryList(t *testing.T) {
+	t.Run("Localized Names", func(t *testing.T) {
+		// Nama bisa dicari dalam bahasa apa pun, response mengikuti Accept-Language
+		id := utils.GetCountryList("id-ID,id;q=0.9", "germany", "")
+		assert.Equal(t, "id", id.Language)
+		if assert.Len(t, id.Countries, 1) {
+			assert.Equal(t, "Jerman", id.Countries[0].Name)
+		}
+
+		en := utils.GetCountryList("en-US", "jerman", "")
+		assert.Equal(t, "en", en.Language)
+		if assert.NotEmpty(t, en.Countries) {
+			assert.Equal(t, "Germany", en.Countries[0].Name)
+		}
+	})
+
+	t.Run("Phone Metadata", func(t *testing.T) {
+		list := utils.GetCountryList("en", "", "")
+		byISO := map[string]utils.Country{}
+		for _, c := range list.Countries {
+			byISO[c.ISOCode] = c
+		}
+
+		indonesia := byISO["ID"]
+		assert.Equal(t, "+62", indonesia.DialCode)
+		assert.Equal(t, "🇮🇩", indonesia.Flag)
+		assert.NotEmpty(t, indonesia.ExampleNumber)
+		assert.Greater(t, indonesia.MaxLength, 9)
+
+		assert.Greater(t, len(byISO["DO"].DialCodes), 1)
+	})
+
+	t.Run("Search By Dial Code", func(t *testing.T) {
+		list := utils.GetCountryList("en", "+62", "")
+		if assert.Len(t, list.Countries, 1) {
+			assert.Equal(t, "ID", list.Countries[0].ISOCode)
+		}
+		assert.NotEqual(t, utils.GetCountryList("en", "", "").ETag, list.ETag)
+	})
+}
+
+func TestCountryHandlerCaching(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	list := utils.CountryList{
+		Language:  "id",
+		Countries: []utils.Country{{Name: "Indonesia", ISOCode: "ID", DialCode: "+62"}},
+		ETag:      `"abc123"`,
+	}
+	setup := func(mockUC *mocks.MockUserUseCase) *gin.Engine {
+		h := handler.NewUserHandler(mockUC)
+		r := gin.Default()
+		r.GET("/meta/countries", h.GetCountryCodes)
+		return r
+	}
+
+	t.Run("Fresh Request", func(t *testing.T) {
+		mockUC := new(mocks.MockUserUseCase)
+		mockUC.On("GetCountryCodes", "id", "indo", "").Return(list)
+
+		req, _ := http.NewRequest(http.MethodGet, "/meta/countries?q=indo", nil)
+		req.Header.Set("Accept-Language", "id")
+		w := httptest.NewRecorder()
+		setup(mockUC).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"abc123"`, w.Header().Get("ETag"))
+		assert.Contains(t, w.Header().Get("Cache-Control"), "max-age")
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Len(t, response["data"], 1)
+	})
+
+	t.Run("Not Modified", func(t *testing.T) {
+		mockUC := new(mocks.MockUserUseCase)
+		mockUC.On("GetCountryCodes", "id", "", "").Return(list)
+
+		req, _ := http.NewRequest(http.MethodGet, "/meta/countries", nil)
+		req.Header.Set("Accept-Language", "id")
+		req.Header.Set("If-None-Match", `"abc123"`)
+		w := httptest.NewRecorder()
+		setup(mockUC).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+}
\ No newline at end of file
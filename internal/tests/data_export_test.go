@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/internal/handler"
+	"khalif-identify/internal/usecase"
+	"khalif-identify/pkg/queue"
+	"khalif-identify/pkg/utils"
+
+)
+
+func TestDataExport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userID := "2b1c6a9e-0f4d-4c1a-9f0e-222222222222"
+	setup := func() *handlerEnv {
+		return newHandlerEnv(userID, func(r *gin.Engine, h *handler.UserHandler) {
+			r.GET("/export/download", h.DownloadDataExport)
+			r.POST("/export", h.RequestDataExport)
+		})
+	}
+
+	t.Run("Request Is Accepted", func(t *testing.T) {
+		env := setup()
+		env.uc.On("RequestDataExport", userID).Return(nil)
+
+		w := env.call(http.MethodPost, "/export")
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		env.uc.AssertExpectations(t)
+	})
+
+	t.Run("Second Request While Pending", func(t *testing.T) {
+		env := setup()
+		env.uc.On("RequestDataExport", userID).Return(domain.ErrDataExportPending)
+
+		w := env.call(http.MethodPost, "/export")
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "data_export_pending", decodeProblem(t, w).Code)
+	})
+
+	t.Run("Download Serves Zip Attachment", func(t *testing.T) {
+		env := setup()
+		env.uc.On("DownloadDataExport", "tok-123").
+			Return(&domain.DataExport{Filename: "khalif-data-export.zip", Content: io.NopCloser(strings.NewReader("PK\x03\x04"))}, nil)
+
+		w := env.get("/export/download?token=tok-123")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="khalif-data-export.zip"`, w.Header().Get("Content-Disposition"))
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		assert.Equal(t, "PK\x03\x04", w.Body.String())
+	})
+
+	t.Run("Expired Link", func(t *testing.T) {
+		env := setup()
+		env.uc.On("DownloadDataExport", "kedaluwarsa").Return(nil, domain.ErrDataExportInvalid)
+
+		w := env.get("/export/download?token=kedaluwarsa")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "data_export_invalid", decodeProblem(t, w).Code)
+	})
+}
+
+func TestDataExportUseCase(t *testing.T) {
+	ctx := context.Background()
+	userUUID := "2b1c6a9e-0f4d-4c1a-9f0e-232323232323"
+
+	setup := func(t *testing.T) (*usecaseEnv, *usecase.DataExportJobHandler) {
+		env := newUsecaseEnv(t)
+		hash, err := utils.HashPasswordWithCost("rahasia123", bcrypt.MinCost)
+		require.NoError(t, err)
+		env.repo.Seed(domain.User{UUID: userUUID, Name: "Khalif", Email: "khalif@gmail.com", Password: hash})
+		return env, usecase.NewDataExportJobHandler(env.repo, env.cache, env.jobs, env.storage, env.mail, env.settings)
+	}
+	// exportKeys key Redis milik export data yang masih tersisa
+	exportKeys := func(env *usecaseEnv) []string {
+		var keys []string
+		for _, key := range env.redis.Keys() {
+			if strings.HasPrefix(key, "data_export") {
+				keys = append(keys, key)
+			}
+		}
+		return keys
+	}
+
+	t.Run("Archive Lives In Storage Until Expiry", func(t *testing.T) {
+		env, exports := setup(t)
+		require.NoError(t, env.uc.RequestDataExport(ctx, userUUID))
+		env.runJobs(t, usecase.JobBuildDataExport, exports.Handle)
+		token := linkToken(t, env.mail.Last().Body)
+
+		require.Len(t, env.storage.Files, 1)
+		stored, err := env.redis.Get("data_export:" + token)
+		require.NoError(t, err)
+		assert.Contains(t, env.storage.Files, stored, "Redis hanya menyimpan lokasi arsip")
+
+		export, err := env.uc.DownloadDataExport(ctx, token)
+		require.NoError(t, err)
+		archive, err := io.ReadAll(export.Content)
+		require.NoError(t, export.Content.Close())
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(archive), "PK"))
+
+		expiries := env.jobs.Of(usecase.JobExpireDataExport)
+		require.Len(t, expiries, 1)
+		assert.WithinDuration(t, time.Now().Add(env.settings.DataExportTTL), expiries[0].At, time.Minute)
+		env.runJobs(t, usecase.JobExpireDataExport, exports.Expire)
+
+		assert.Equal(t, []string{stored}, env.storage.Deleted)
+		_, err = env.uc.DownloadDataExport(ctx, token)
+		assert.ErrorIs(t, err, domain.ErrDataExportInvalid)
+		assert.Empty(t, exportKeys(env))
+	})
+
+	t.Run("New Archive Replaces Previous", func(t *testing.T) {
+		env, exports := setup(t)
+		require.NoError(t, env.uc.RequestDataExport(ctx, userUUID))
+		env.runJobs(t, usecase.JobBuildDataExport, exports.Handle)
+		first := linkToken(t, env.mail.Last().Body)
+
+		require.NoError(t, env.uc.RequestDataExport(ctx, userUUID))
+		jobs := env.jobs.Of(usecase.JobBuildDataExport)
+		require.NoError(t, exports.Handle(ctx, &queue.Job{Type: usecase.JobBuildDataExport, Payload: jobs[len(jobs)-1].Payload}))
+		second := linkToken(t, env.mail.Last().Body)
+
+		_, err := env.uc.DownloadDataExport(ctx, first)
+		assert.ErrorIs(t, err, domain.ErrDataExportInvalid)
+		assert.Len(t, env.storage.Files, 1)
+
+		// Job kedaluwarsa arsip pertama tidak boleh menyentuh arsip kedua
+		require.NoError(t, exports.Expire(ctx, &queue.Job{Type: usecase.JobExpireDataExport, Payload: env.jobs.Of(usecase.JobExpireDataExport)[0].Payload}))
+		export, err := env.uc.DownloadDataExport(ctx, second)
+		require.NoError(t, err)
+		export.Content.Close()
+	})
+
+	t.Run("Mail Failure Discards Archive", func(t *testing.T) {
+		env, exports := setup(t)
+		require.NoError(t, env.uc.RequestDataExport(ctx, userUUID))
+		env.mail.Err = errors.New("smtp mati")
+
+		job := env.jobs.Of(usecase.JobBuildDataExport)[0]
+		err := exports.Handle(ctx, &queue.Job{Type: usecase.JobBuildDataExport, Payload: job.Payload})
+
+		assert.Error(t, err)
+		assert.Empty(t, env.storage.Files)
+		assert.Equal(t, []string{"data_export_pending:" + userUUID}, exportKeys(env), "hanya kunci pending yang tersisa sampai retry")
+	})
+}
\ No newline at end of file
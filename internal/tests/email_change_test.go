@@ -0,0 +1,239 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/internal/handler"
+	"khalif-identify/pkg/utils"
+
+)
+
+func TestEmailChange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userID := "2b1c6a9e-0f4d-4c1a-9f0e-111111111111"
+	setup := func() *handlerEnv {
+		return newHandlerEnv(userID, func(r *gin.Engine, h *handler.UserHandler) {
+			r.POST("/email/change/confirm", h.ConfirmEmailChange)
+			r.POST("/email/change/cancel", h.CancelEmailChange)
+			r.POST("/email/change", h.RequestEmailChange)
+		})
+	}
+
+	t.Run("Request Sends Confirmation", func(t *testing.T) {
+		env := setup()
+		env.uc.On("RequestEmailChange", userID, "baru@khalif.id", "rahasia123", true).
+			Return(&domain.EmailChangeChallenge{Email: "baru@khalif.id", ExpiresIn: 86400}, nil)
+
+		w := env.postJSON("/email/change", `{"email":" Baru@Khalif.ID ","current_password":"rahasia123","revoke_sessions":true}`)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		var response struct {
+			Data domain.EmailChangeChallenge `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "baru@khalif.id", response.Data.Email)
+		env.uc.AssertExpectations(t)
+	})
+
+	t.Run("Current Password Required", func(t *testing.T) {
+		env := setup()
+		w := env.postJSON("/email/change", `{"email":"baru@khalif.id"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		problem := decodeProblem(t, w)
+		if assert.Len(t, problem.Errors, 1) {
+			assert.Equal(t, "current_password", problem.Errors[0].Field)
+		}
+		env.uc.AssertNotCalled(t, "RequestEmailChange")
+	})
+
+	t.Run("Wrong Current Password", func(t *testing.T) {
+		env := setup()
+		env.uc.On("RequestEmailChange", userID, "baru@khalif.id", "salah123", false).
+			Return(nil, domain.ErrCurrentPasswordInvalid)
+
+		w := env.postJSON("/email/change", `{"email":"baru@khalif.id","current_password":"salah123"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		problem := decodeProblem(t, w)
+		assert.Equal(t, "current_password_invalid", problem.Code)
+		if assert.Len(t, problem.Errors, 1) {
+			assert.Equal(t, "current_password", problem.Errors[0].Field)
+		}
+	})
+
+	t.Run("Confirm Applies New Email", func(t *testing.T) {
+		env := setup()
+		env.uc.On("ConfirmEmailChange", "id.sig").Return(&domain.User{UUID: userID, Email: "baru@khalif.id"}, nil)
+
+		w := env.postJSON("/email/change/confirm", `{"token":"id.sig"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "baru@khalif.id")
+		env.uc.AssertExpectations(t)
+	})
+
+	t.Run("Confirm After Email Was Taken", func(t *testing.T) {
+		env := setup()
+		env.uc.On("ConfirmEmailChange", "id.sig").Return(nil, domain.ErrEmailTaken)
+
+		w := env.postJSON("/email/change/confirm", `{"token":"id.sig"}`)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "email_taken", decodeProblem(t, w).Code)
+	})
+
+	t.Run("Cancel With Used Link", func(t *testing.T) {
+		env := setup()
+		env.uc.On("CancelEmailChange", "id.sig").Return(domain.ErrEmailChangeInvalid)
+
+		w := env.postJSON("/email/change/cancel", `{"token":"id.sig"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, "email_change_invalid", decodeProblem(t, w).Code)
+	})
+}
+
+func TestEmailChangeUseCase(t *testing.T) {
+	ctx := context.Background()
+	userUUID := "2b1c6a9e-0f4d-4c1a-9f0e-888888888888"
+	oldEmail, newEmail := "khalif@gmail.com", "khalif.baru@gmail.com"
+
+	setup := func(t *testing.T) *usecaseEnv {
+		env := newUsecaseEnv(t)
+		hash, err := utils.HashPasswordWithCost("rahasia123", bcrypt.MinCost)
+		require.NoError(t, err)
+		env.repo.Seed(domain.User{UUID: userUUID, Name: "Khalif", Email: oldEmail, Password: hash})
+		return env
+	}
+
+	// request meminta ganti email; mengembalikan token konfirmasi (email baru) dan pembatalan (email lama)
+	request := func(t *testing.T, env *usecaseEnv, revokeSessions bool) (string, string) {
+		_, err := env.uc.RequestEmailChange(ctx, userUUID, newEmail, "rahasia123", revokeSessions)
+		require.NoError(t, err)
+		var confirm, cancel string
+		for _, mail := range env.mail.Sent() {
+			switch mail.To {
+			case newEmail:
+				confirm = linkToken(t, mail.Body)
+			case oldEmail:
+				cancel = linkToken(t, mail.Body)
+			}
+		}
+		require.NotEmpty(t, confirm)
+		require.NotEmpty(t, cancel)
+		return confirm, cancel
+	}
+
+	t.Run("Confirm Applies Once", func(t *testing.T) {
+		env := setup(t)
+		confirm, cancel := request(t, env, false)
+
+		user, err := env.uc.ConfirmEmailChange(ctx, confirm)
+		require.NoError(t, err)
+		assert.Equal(t, newEmail, user.Email)
+		assert.Equal(t, newEmail, env.repo.User(userUUID).Email)
+
+		// GetDel: token yang sama, maupun link pembatalannya, tidak berlaku lagi
+		_, err = env.uc.ConfirmEmailChange(ctx, confirm)
+		assert.ErrorIs(t, err, domain.ErrEmailChangeInvalid)
+		assert.ErrorIs(t, env.uc.CancelEmailChange(ctx, cancel), domain.ErrEmailChangeInvalid)
+		assert.False(t, env.redis.Exists("email_change_pending:"+userUUID))
+	})
+
+	t.Run("Confirm And Cancel Tokens Are Not Interchangeable", func(t *testing.T) {
+		env := setup(t)
+		confirm, cancel := request(t, env, false)
+
+		_, err := env.uc.ConfirmEmailChange(ctx, cancel)
+		assert.ErrorIs(t, err, domain.ErrEmailChangeInvalid)
+		assert.ErrorIs(t, env.uc.CancelEmailChange(ctx, confirm), domain.ErrEmailChangeInvalid)
+
+		// Token yang salah tujuan tidak ikut menghabiskan permintaan
+		_, err = env.uc.ConfirmEmailChange(ctx, confirm)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Cancel Stops Confirmation", func(t *testing.T) {
+		env := setup(t)
+		confirm, cancel := request(t, env, false)
+
+		require.NoError(t, env.uc.CancelEmailChange(ctx, cancel))
+		_, err := env.uc.ConfirmEmailChange(ctx, confirm)
+		assert.ErrorIs(t, err, domain.ErrEmailChangeInvalid)
+		assert.Equal(t, oldEmail, env.repo.User(userUUID).Email)
+	})
+
+	t.Run("Newer Request Replaces Older", func(t *testing.T) {
+		env := setup(t)
+		first, _ := request(t, env, false)
+		second, _ := request(t, env, false)
+
+		_, err := env.uc.ConfirmEmailChange(ctx, first)
+		assert.ErrorIs(t, err, domain.ErrEmailChangeInvalid)
+		_, err = env.uc.ConfirmEmailChange(ctx, second)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Email Changed Since Request Is Not Overwritten", func(t *testing.T) {
+		env := setup(t)
+		confirm, _ := request(t, env, false)
+
+		// Admin mengganti email di antara permintaan dan konfirmasi
+		require.NoError(t, env.repo.UpdateEmail(ctx, userUUID, oldEmail, "admin.ganti@gmail.com"))
+
+		_, err := env.uc.ConfirmEmailChange(ctx, confirm)
+		assert.ErrorIs(t, err, domain.ErrEmailChangeInvalid)
+		assert.Equal(t, "admin.ganti@gmail.com", env.repo.User(userUUID).Email)
+	})
+
+	t.Run("Email Taken Before Confirmation", func(t *testing.T) {
+		env := setup(t)
+		confirm, _ := request(t, env, false)
+		env.repo.Seed(domain.User{UUID: "2b1c6a9e-0f4d-4c1a-9f0e-999999999999", Email: newEmail})
+
+		_, err := env.uc.ConfirmEmailChange(ctx, confirm)
+		assert.ErrorIs(t, err, domain.ErrEmailTaken)
+		assert.Equal(t, oldEmail, env.repo.User(userUUID).Email)
+	})
+
+	t.Run("Same Email Is Unchanged", func(t *testing.T) {
+		env := setup(t)
+		_, err := env.uc.RequestEmailChange(ctx, userUUID, " KHALIF@gmail.com ", "rahasia123", false)
+		assert.ErrorIs(t, err, domain.ErrEmailUnchanged)
+		assert.Empty(t, env.mail.Sent())
+		assert.Empty(t, env.redis.Keys())
+	})
+
+	t.Run("Wrong Password Is Rejected", func(t *testing.T) {
+		env := setup(t)
+		_, err := env.uc.RequestEmailChange(ctx, userUUID, newEmail, "salah", false)
+		assert.ErrorIs(t, err, domain.ErrCurrentPasswordInvalid)
+		assert.Empty(t, env.mail.Sent())
+	})
+
+	t.Run("Confirm Can Revoke Sessions", func(t *testing.T) {
+		env := setup(t)
+		confirm, _ := request(t, env, true)
+
+		_, err := env.uc.ConfirmEmailChange(ctx, confirm)
+		require.NoError(t, err)
+
+		var actions []string
+		for _, event := range env.repo.AuditEvents() {
+			actions = append(actions, event.Action)
+		}
+		assert.Contains(t, actions, domain.AuditEmailChanged)
+		assert.Contains(t, actions, domain.AuditSessionsRevoked)
+	})
+}
\ No newline at end of file
@@ -0,0 +1,41 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/pkg/database"
+
+)
+
+func TestEmailIdentity(t *testing.T) {
+	t.Run("Normalize", func(t *testing.T) {
+		assert.Equal(t, "khalif@gmail.com", domain.NormalizeEmail("  Khalif@Gmail.COM \t"))
+		assert.Equal(t, domain.NormalizeEmail("KHALIF@gmail.com"), domain.NormalizeEmail("khalif@GMAIL.com"))
+	})
+
+	t.Run("Migration Reports Collisions Before Indexing", func(t *testing.T) {
+		migrations, err := database.Migrations()
+		assert.NoError(t, err)
+
+		var found *database.Migration
+		for i := range migrations {
+			if migrations[i].Name == "users_email_case_insensitive" {
+				found = &migrations[i]
+			}
+		}
+		if !assert.NotNil(t, found) {
+			return
+		}
+
+		// Bentrokan harus dicek dulu, baru data dinormalisasi dan index fungsional dibuat
+		check := strings.Index(found.Up, "RAISE EXCEPTION")
+		update := strings.Index(found.Up, "UPDATE users SET email = lower(btrim(email))")
+		index := strings.Index(found.Up, "CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users (lower(email))")
+		assert.True(t, check >= 0 && check < update && update < index, "urutan migrasi salah")
+		assert.Contains(t, found.Down, "idx_users_email ON users (email)")
+	})
+}
\ No newline at end of file
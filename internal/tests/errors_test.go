@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/internal/handler"
+	"khalif-identify/internal/tests/mocks"
+	"khalif-identify/pkg/middleware"
+
+)
+
+type problemBody struct {
+	Type     string              `json:"type"`
+	Title    string              `json:"title"`
+	Status   int                 `json:"status"`
+	Detail   string              `json:"detail"`
+	Instance string              `json:"instance"`
+	Code     string              `json:"code"`
+	Errors   []domain.FieldError `json:"errors"`
+}
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) problemBody {
+	assert.Equal(t, middleware.ProblemContentType, w.Header().Get("Content-Type"))
+	var problem problemBody
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	return problem
+}
+
+func TestDomainErrors(t *testing.T) {
+	t.Run("Detail Keeps Identity", func(t *testing.T) {
+		err := domain.ErrAdminQuotaFull.WithParams(map[string]interface{}{"max": 3})
+		assert.ErrorIs(t, err, domain.ErrAdminQuotaFull)
+		assert.NotErrorIs(t, err, domain.ErrUserNotFound)
+		assert.Equal(t, "admin quota is full (max=3)", err.Error())
+		assert.Empty(t, domain.ErrAdminQuotaFull.Params, "sentinel tidak boleh ikut berubah")
+	})
+
+	t.Run("Cooldown Is Rate Limited", func(t *testing.T) {
+		var err error = &domain.OTPCooldownError{RetryAfter: 30 * time.Second}
+		var domainErr *domain.Error
+		assert.ErrorIs(t, err, domain.ErrOTPCooldown)
+		if assert.ErrorAs(t, err, &domainErr) {
+			assert.Equal(t, domain.KindRateLimited, domainErr.Kind)
+		}
+	})
+}
+
+func TestErrorHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func(mockUC *mocks.MockUserUseCase) *gin.Engine {
+		h := handler.NewUserHandler(mockUC)
+		r := gin.New()
+		r.Use(middleware.ErrorHandler())
+		r.POST("/register", h.RegisterCustomer)
+		r.POST("/login/link", h.SendLoginLink)
+		r.POST("/login/otp", h.SendLoginOTP)
+		r.GET("/list", h.GetAll)
+		return r
+	}
+	postJSON := func(r *gin.Engine, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Duplicate Email Is Conflict", func(t *testing.T) {
+		mockUC := new(mocks.MockUserUseCase)
+		mockUC.On("RegisterCustomer", "Khalif", "khalif@gmail.com", "081234567890", "ID", "rahasia123", mock.Anything, mock.Anything).
+			Return(nil, domain.ErrEmailTaken)
+
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		writer.WriteField("name", "Khalif")
+		writer.WriteField("email", "khalif@gmail.com")
+		writer.WriteField("phone", "081234567890")
+		writer.WriteField("country", "ID")
+		writer.WriteField("password", "rahasia123")
+		writer.Close()
+
+		req, _ := http.NewRequest(http.MethodPost, "/register", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Header.Set("Accept-Language", "en")
+		w := httptest.NewRecorder()
+		setup(mockUC).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		problem := decodeProblem(t, w)
+		assert.Equal(t, "email_taken", problem.Code)
+		assert.Equal(t, "/register", problem.Instance)
+		assert.Equal(t, []domain.FieldError{{Field: "email", Code: "email_taken", Message: "This email is already registered"}}, problem.Errors)
+	})
+
+	t.Run("Binding Errors Are Validation", func(t *testing.T) {
+		w := postJSON(setup(new(mocks.MockUserUseCase)), "/login/link", `{"email":"bukan-email"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		problem := decodeProblem(t, w)
+		assert.Equal(t, "validation_failed", problem.Code)
+		if assert.Len(t, problem.Errors, 1) {
+			assert.Equal(t, "email", problem.Errors[0].Field)
+			assert.Equal(t, "email", problem.Errors[0].Code)
+		}
+	})
+
+	t.Run("Malformed Body", func(t *testing.T) {
+		w := postJSON(setup(new(mocks.MockUserUseCase)), "/login/link", `{"email":`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "malformed_request", decodeProblem(t, w).Code)
+	})
+
+	t.Run("Cooldown Sets Retry-After", func(t *testing.T) {
+		mockUC := new(mocks.MockUserUseCase)
+		mockUC.On("SendLoginOTP", "081234567890", "").
+			Return(nil, &domain.OTPCooldownError{RetryAfter: 42 * time.Second})
+
+		w := postJSON(setup(mockUC), "/login/otp", `{"phone":"081234567890"}`)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "42", w.Header().Get("Retry-After"))
+		assert.Equal(t, "otp_cooldown", decodeProblem(t, w).Code)
+	})
+
+	t.Run("Unknown Error Is Hidden", func(t *testing.T) {
+		mockUC := new(mocks.MockUserUseCase)
+		mockUC.On("GetAllAdmins", 1, 10).Return(nil, int64(0), errors.New("pq: connection refused to 10.0.0.5"))
+
+		req, _ := http.NewRequest(http.MethodGet, "/list", nil)
+		w := httptest.NewRecorder()
+		setup(mockUC).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		problem := decodeProblem(t, w)
+		assert.Equal(t, "internal_error", problem.Code)
+		assert.NotContains(t, w.Body.String(), "10.0.0.5")
+	})
+}
\ No newline at end of file
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+
+	"khalif-identify/internal/handler"
+	"khalif-identify/internal/tests/mocks"
+	"khalif-identify/pkg/middleware"
+
+)
+
+// handlerEnv handler asli di atas MockUserUseCase, dipakai test yang hanya memeriksa lapisan HTTP
+// (binding, validasi, status & bentuk response)
+type handlerEnv struct {
+	uc     *mocks.MockUserUseCase
+	router *gin.Engine
+}
+
+// newHandlerEnv menyiapkan router dengan ErrorHandler lalu mendaftarkan endpoint lewat routes.
+// userID tidak kosong = request dianggap sudah lolos AuthMiddleware (user_id di-set ke context).
+func newHandlerEnv(userID string, routes func(r *gin.Engine, h *handler.UserHandler)) *handlerEnv {
+	gin.SetMode(gin.TestMode)
+	mockUC := new(mocks.MockUserUseCase)
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+	if userID != "" {
+		r.Use(func(c *gin.Context) {
+			c.Set("user_id", userID)
+			c.Next()
+		})
+	}
+	routes(r, handler.NewUserHandler(mockUC))
+	return &handlerEnv{uc: mockUC, router: r}
+}
+
+// serve menjalankan request apa adanya (untuk multipart atau header tambahan)
+func (e *handlerEnv) serve(req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	e.router.ServeHTTP(w, req)
+	return w
+}
+
+// call mengirim request tanpa body, misal GET atau DELETE
+func (e *handlerEnv) call(method, target string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, target, nil)
+	return e.serve(req)
+}
+
+func (e *handlerEnv) get(target string) *httptest.ResponseRecorder {
+	return e.call(http.MethodGet, target)
+}
+
+func (e *handlerEnv) postJSON(target, body string) *httptest.ResponseRecorder {
+	return e.serve(jsonRequest(http.MethodPost, target, body))
+}
+
+// jsonRequest request dengan body JSON; header lain bisa ditambahkan sebelum serve
+func jsonRequest(method, target, body string) *http.Request {
+	req, _ := http.NewRequest(method, target, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
\ No newline at end of file
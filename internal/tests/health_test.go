@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"khalif-identify/internal/handler"
+
+)
+
+func TestHealthEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ok := func(ctx context.Context) error { return nil }
+	down := func(ctx context.Context) error { return errors.New("dial tcp 10.0.0.5:6379: connection refused") }
+
+	serve := func(h *handler.HealthHandler, path string) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.GET("/healthz", h.Liveness)
+		r.GET("/readyz", h.Readiness)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	t.Run("Ready", func(t *testing.T) {
+		h := handler.NewHealthHandler(
+			handler.HealthCheck{Name: "postgres", Check: ok},
+			handler.HealthCheck{Name: "redis", Check: ok},
+		)
+		w := serve(h, "/readyz")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Dependency Down", func(t *testing.T) {
+		h := handler.NewHealthHandler(
+			handler.HealthCheck{Name: "postgres", Check: ok},
+			handler.HealthCheck{Name: "redis", Check: down},
+		)
+		w := serve(h, "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+		var body struct {
+			Checks map[string]string `json:"checks"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		assert.Equal(t, "ok", body.Checks["postgres"])
+		assert.Equal(t, "unavailable", body.Checks["redis"])
+		// Detail error internal tidak bocor ke response
+		assert.NotContains(t, w.Body.String(), "10.0.0.5")
+
+		// Liveness tidak ikut gagal karena dependency
+		assert.Equal(t, http.StatusOK, serve(h, "/healthz").Code)
+	})
+
+	t.Run("Draining", func(t *testing.T) {
+		h := handler.NewHealthHandler(handler.HealthCheck{Name: "postgres", Check: ok})
+		h.SetDraining()
+		assert.Equal(t, http.StatusServiceUnavailable, serve(h, "/readyz").Code)
+		assert.Equal(t, http.StatusOK, serve(h, "/healthz").Code)
+	})
+}
\ No newline at end of file
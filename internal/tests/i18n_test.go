@@ -0,0 +1,203 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/internal/handler"
+	"khalif-identify/internal/tests/mocks"
+	"khalif-identify/pkg/i18n"
+	"khalif-identify/pkg/middleware"
+
+)
+
+func TestI18nCatalogs(t *testing.T) {
+	t.Run("Same Keys In Every Locale", func(t *testing.T) {
+		base, err := i18n.Keys(i18n.DefaultLocale)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, base)
+		for _, locale := range i18n.Supported {
+			keys, err := i18n.Keys(locale)
+			assert.NoError(t, err)
+			assert.Equal(t, base, keys, "katalog %s tidak lengkap", locale)
+		}
+	})
+
+	t.Run("Every Domain Error Is Translated", func(t *testing.T) {
+		for _, err := range []*domain.Error{
+			domain.ErrInvalidCredentials, domain.ErrLoginLinkInvalid, domain.ErrUnauthorized,
+			domain.ErrTokenInvalid, domain.ErrTokenRevoked, domain.ErrAccountDisabled, domain.ErrAdminOnly,
+			domain.ErrUserNotFound, domain.ErrRoleNotFound, domain.ErrEmailTaken, domain.ErrPhoneTaken,
+			domain.ErrPhoneMissing, domain.ErrPhoneAlreadyVerified, domain.ErrLocaleUnsupported,
+			domain.ErrCurrentPasswordInvalid, domain.ErrPasswordUnchanged, domain.ErrEmailUnchanged, domain.ErrEmailChangeInvalid,
+			domain.ErrDataExportPending, domain.ErrDataExportInvalid,
+			domain.ErrAccountPendingDeletion, domain.ErrAccountDeletionInvalid, domain.ErrAccountDeleted,
+			domain.ErrAdminQuotaFull, domain.ErrImageTooLarge, domain.ErrImageInvalid, domain.ErrOTPInvalid,
+			domain.ErrOTPExpired, domain.ErrOTPTooManyAttempts, domain.ErrOTPCooldown, domain.ErrTooManyRequests,
+		} {
+			_, ok := i18n.Lookup(i18n.English, "error."+err.Code, nil)
+			assert.True(t, ok, "kode %s belum ada di katalog", err.Code)
+		}
+	})
+
+	t.Run("Locale Selection", func(t *testing.T) {
+		assert.Equal(t, i18n.English, i18n.Match("en-GB,en;q=0.9"))
+		assert.Equal(t, i18n.Indonesian, i18n.Match("id-ID"))
+		assert.Equal(t, i18n.DefaultLocale, i18n.Match(""))
+		assert.Equal(t, i18n.DefaultLocale, i18n.Match("fr-FR"))
+
+		locale, ok := i18n.Normalize("en-US")
+		assert.True(t, ok)
+		assert.Equal(t, i18n.English, locale)
+		_, ok = i18n.Normalize("fr")
+		assert.False(t, ok)
+
+		// Bahasa pilihan user menang atas bahasa request
+		ctx := i18n.WithLocale(context.Background(), i18n.Indonesian)
+		assert.Equal(t, i18n.English, i18n.ForUser(ctx, "en"))
+		assert.Equal(t, i18n.Indonesian, i18n.ForUser(ctx, ""))
+	})
+
+	t.Run("Params", func(t *testing.T) {
+		body := i18n.T(i18n.English, "sms.login_otp", i18n.Params{"code": "123456", "minutes": 5})
+		assert.Contains(t, body, "123456")
+		assert.Contains(t, body, "5 minutes")
+		assert.Equal(t, "missing.key", i18n.T(i18n.English, "missing.key", nil))
+	})
+}
+
+func TestLocalizedProblems(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockUC := new(mocks.MockUserUseCase)
+	mockUC.On("SendLoginOTP", "081234567890", "").
+		Return(nil, &domain.OTPCooldownError{RetryAfter: 42 * time.Second})
+
+	h := handler.NewUserHandler(mockUC)
+	r := gin.New()
+	r.Use(middleware.ErrorHandler(), middleware.Locale())
+	r.POST("/login/otp", h.SendLoginOTP)
+
+	send := func(acceptLanguage, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/login/otp", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguage)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Indonesian", func(t *testing.T) {
+		w := send("id-ID", `{"phone":"081234567890"}`)
+		assert.Equal(t, "id", w.Header().Get("Content-Language"))
+		problem := decodeProblem(t, w)
+		assert.Equal(t, "otp_cooldown", problem.Code)
+		assert.Equal(t, "Tunggu 42 detik sebelum meminta kode baru", problem.Detail)
+	})
+
+	t.Run("English", func(t *testing.T) {
+		problem := decodeProblem(t, send("en-US", `{"phone":"081234567890"}`))
+		assert.Equal(t, "Please wait 42 seconds before requesting a new code", problem.Detail)
+	})
+
+	t.Run("Field Validation", func(t *testing.T) {
+		problem := decodeProblem(t, send("en", `{}`))
+		if assert.Len(t, problem.Errors, 1) {
+			assert.Equal(t, "phone is required", problem.Errors[0].Message)
+		}
+		problem = decodeProblem(t, send("id", `{}`))
+		if assert.Len(t, problem.Errors, 1) {
+			assert.Equal(t, "phone wajib diisi", problem.Errors[0].Message)
+		}
+	})
+}
+
+func TestUserLocale(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	saved := map[string]string{"user-en": "en", "user-kosong": ""}
+	lookup := func(ctx context.Context, userUUID string) (string, error) {
+		locale, ok := saved[userUUID]
+		if !ok {
+			return "", assert.AnError
+		}
+		return locale, nil
+	}
+
+	r := gin.New()
+	r.Use(middleware.ErrorHandler(), middleware.Locale())
+	// Pengganti AuthMiddleware: user_id diambil dari header
+	r.Use(func(c *gin.Context) {
+		if userID := c.GetHeader("X-Test-User"); userID != "" {
+			c.Set("user_id", userID)
+		}
+	}, middleware.UserLocale(lookup))
+	r.GET("/message", func(c *gin.Context) {
+		c.String(http.StatusOK, i18n.T(i18n.FromContext(c.Request.Context()), "message.logged_out", nil))
+	})
+	r.GET("/error", func(c *gin.Context) {
+		c.Error(domain.ErrUserNotFound)
+	})
+
+	send := func(path, userID string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Language", "id-ID")
+		if userID != "" {
+			req.Header.Set("X-Test-User", userID)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Saved Locale Wins Over Header", func(t *testing.T) {
+		w := send("/message", "user-en")
+		assert.Equal(t, "en", w.Header().Get("Content-Language"))
+		assert.Equal(t, "Successfully logged out", w.Body.String())
+
+		problem := decodeProblem(t, send("/error", "user-en"))
+		assert.Equal(t, i18n.T(i18n.English, "error.user_not_found", nil), problem.Detail)
+	})
+
+	t.Run("Header Without Saved Locale", func(t *testing.T) {
+		for _, userID := range []string{"", "user-kosong", "user-gagal-dibaca"} {
+			w := send("/message", userID)
+			assert.Equal(t, "id", w.Header().Get("Content-Language"), userID)
+			assert.Equal(t, "Berhasil logout", w.Body.String(), userID)
+		}
+	})
+}
+
+func TestPreferredLocale(t *testing.T) {
+	ctx := context.Background()
+	env := newUsecaseEnv(t)
+	user := env.repo.Seed(domain.User{UUID: "2b1c6a9e-0f4d-4c1a-9f0e-777777777777", Name: "Khalif", Email: "khalif@gmail.com", Locale: "en"})
+
+	locale, err := env.uc.PreferredLocale(ctx, user.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, "en", locale)
+
+	// Nilai di-cache: perubahan langsung di database belum terlihat
+	stored := env.repo.User(user.UUID)
+	stored.Locale = "id"
+	assert.NoError(t, env.repo.Update(ctx, stored))
+	locale, _ = env.uc.PreferredLocale(ctx, user.UUID)
+	assert.Equal(t, "en", locale)
+
+	// Mengubah bahasa lewat profil langsung berlaku
+	_, err = env.uc.UpdateProfile(ctx, user.UUID, "", "", "", "id", nil, nil)
+	assert.NoError(t, err)
+	locale, _ = env.uc.PreferredLocale(ctx, user.UUID)
+	assert.Equal(t, "id", locale)
+
+	_, err = env.uc.PreferredLocale(ctx, "tidak-ada")
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+}
\ No newline at end of file
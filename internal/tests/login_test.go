@@ -3,7 +3,6 @@ package tests
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -14,6 +13,7 @@ import (
 	"khalif-identify/internal/domain"
 	"khalif-identify/internal/handler"
 	"khalif-identify/internal/tests/mocks" // Import Mock yang kita buat
+	"khalif-identify/pkg/middleware"
 
 )
 
@@ -42,6 +42,7 @@ func TestLogin(t *testing.T) {
 
 		// 3. Setup Router
 		r := gin.Default()
+		r.Use(middleware.ErrorHandler())
 		r.POST("/login", h.Login)
 
 		// 4. Lakukan Request Pura-pura
@@ -72,10 +73,11 @@ func TestLogin(t *testing.T) {
 		
 		// Ekspektasi: Jika password salah -> Return Error
 		mockUC.On("Login", "wrong@gmail.com", "wrongpass").
-			Return("", nil, errors.New("invalid credentials"))
+			Return("", nil, domain.ErrInvalidCredentials)
 
 		h := handler.NewUserHandler(mockUC)
 		r := gin.Default()
+		r.Use(middleware.ErrorHandler())
 		r.POST("/login", h.Login)
 
 		// 2. Request
@@ -86,6 +88,32 @@ func TestLogin(t *testing.T) {
 
 		// 3. Assert
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+
+		var problem map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &problem)
+		assert.Equal(t, "invalid_credentials", problem["code"])
+		assert.Equal(t, float64(http.StatusUnauthorized), problem["status"])
+		mockUC.AssertExpectations(t)
+	})
+
+	t.Run("Disabled Account", func(t *testing.T) {
+		mockUC := new(mocks.MockUserUseCase)
+		mockUC.On("Login", "disabled@gmail.com", "password123").
+			Return("", nil, domain.ErrAccountDisabled)
+
+		h := handler.NewUserHandler(mockUC)
+		r := gin.Default()
+		r.Use(middleware.ErrorHandler())
+		r.POST("/login", h.Login)
+
+		reqBody := []byte(`{"email":"disabled@gmail.com", "password":"password123"}`)
+		req, _ := http.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		// Akun nonaktif dibedakan dari password salah
+		assert.Equal(t, http.StatusForbidden, w.Code)
 		mockUC.AssertExpectations(t)
 	})
 }
\ No newline at end of file
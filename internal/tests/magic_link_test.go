@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/internal/handler"
+	"khalif-identify/internal/usecase"
+	"khalif-identify/pkg/queue"
+
+)
+
+func TestMagicLinkLogin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func() *handlerEnv {
+		return newHandlerEnv("", func(r *gin.Engine, h *handler.UserHandler) {
+			r.POST("/login/link", h.SendLoginLink)
+			r.POST("/login/link/verify", h.VerifyLoginLink)
+		})
+	}
+
+	t.Run("Send Link Sets Nonce Cookie", func(t *testing.T) {
+		env := setup()
+		env.uc.On("SendLoginLink", "khalif@gmail.com").
+			Return(&domain.LoginLinkChallenge{Nonce: "nonce-abc", ExpiresIn: 900}, nil)
+
+		w := env.postJSON("/login/link", `{"email":"khalif@gmail.com"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Set-Cookie"), "login_link_nonce=nonce-abc")
+		env.uc.AssertExpectations(t)
+	})
+
+	t.Run("Verify With Cookie Nonce", func(t *testing.T) {
+		env := setup()
+		env.uc.On("LoginWithLink", "link-token", "nonce-abc").
+			Return("token-rahasia", &domain.User{Email: "khalif@gmail.com"}, nil)
+
+		req := jsonRequest(http.MethodPost, "/login/link/verify", `{"token":"link-token"}`)
+		req.AddCookie(&http.Cookie{Name: "login_link_nonce", Value: "nonce-abc"})
+		w := env.serve(req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, "token-rahasia", response["token"])
+		env.uc.AssertExpectations(t)
+	})
+
+	t.Run("Verify From Other Device", func(t *testing.T) {
+		env := setup()
+		env.uc.On("LoginWithLink", "link-token", "").
+			Return("", nil, domain.ErrLoginLinkInvalid)
+
+		w := env.postJSON("/login/link/verify", `{"token":"link-token"}`)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		env.uc.AssertExpectations(t)
+	})
+}
+
+func TestMagicLinkUseCase(t *testing.T) {
+	ctx := context.Background()
+	email := "khalif@gmail.com"
+
+	setup := func(t *testing.T) (*usecaseEnv, *usecase.LoginLinkJobHandler) {
+		env := newUsecaseEnv(t)
+		env.repo.Seed(domain.User{UUID: "2b1c6a9e-0f4d-4c1a-9f0e-666666666666", Name: "Khalif", Email: email})
+		return env, usecase.NewLoginLinkJobHandler(env.repo, env.cache, env.mail, env.settings)
+	}
+
+	// sendLink meminta link lalu menjalankan worker; mengembalikan token dari email dan nonce device
+	sendLink := func(t *testing.T, env *usecaseEnv, handler *usecase.LoginLinkJobHandler) (string, string) {
+		challenge, err := env.uc.SendLoginLink(ctx, email)
+		require.NoError(t, err)
+		env.runJobs(t, usecase.JobSendLoginLink, handler.Handle)
+		return linkToken(t, env.mail.Last().Body), challenge.Nonce
+	}
+
+	t.Run("Request Does Not Touch Users Or Mail", func(t *testing.T) {
+		env, _ := setup(t)
+		for _, target := range []string{email, "tidak-terdaftar@gmail.com"} {
+			challenge, err := env.uc.SendLoginLink(ctx, target)
+			require.NoError(t, err)
+			assert.NotEmpty(t, challenge.Nonce)
+			assert.Equal(t, int(usecase.MagicLinkTTL.Seconds()), challenge.ExpiresIn)
+		}
+
+		// Email terdaftar maupun tidak: sama-sama satu job, belum ada email atau token tersimpan
+		assert.Len(t, env.jobs.Of(usecase.JobSendLoginLink), 2)
+		assert.Empty(t, env.mail.Sent())
+		assert.Empty(t, env.redis.Keys())
+	})
+
+	t.Run("Unknown Email Gets No Mail", func(t *testing.T) {
+		env, handler := setup(t)
+		_, err := env.uc.SendLoginLink(ctx, "tidak-terdaftar@gmail.com")
+		require.NoError(t, err)
+		env.runJobs(t, usecase.JobSendLoginLink, handler.Handle)
+
+		assert.Empty(t, env.mail.Sent())
+		assert.Empty(t, env.redis.Keys())
+	})
+
+	t.Run("Link Works Once On The Requesting Device", func(t *testing.T) {
+		env, handler := setup(t)
+		token, nonce := sendLink(t, env, handler)
+		assert.Equal(t, email, env.mail.Last().To)
+
+		jwtToken, user, err := env.uc.LoginWithLink(ctx, token, nonce)
+		require.NoError(t, err)
+		assert.NotEmpty(t, jwtToken)
+		assert.Equal(t, email, user.Email)
+
+		_, _, err = env.uc.LoginWithLink(ctx, token, nonce)
+		assert.ErrorIs(t, err, domain.ErrLoginLinkInvalid, "link hanya berlaku sekali")
+	})
+
+	t.Run("Other Device Cannot Use Or Burn The Link", func(t *testing.T) {
+		env, handler := setup(t)
+		token, nonce := sendLink(t, env, handler)
+
+		_, _, err := env.uc.LoginWithLink(ctx, token, "")
+		assert.ErrorIs(t, err, domain.ErrLoginLinkInvalid)
+		otherNonce := "nonce-device-lain"
+		_, _, err = env.uc.LoginWithLink(ctx, token, otherNonce)
+		assert.ErrorIs(t, err, domain.ErrLoginLinkInvalid)
+
+		// Percobaan dari device lain tidak menghapus token, pemilik nonce tetap bisa login
+		_, _, err = env.uc.LoginWithLink(ctx, token, nonce)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Tampered Token Is Rejected", func(t *testing.T) {
+		env, handler := setup(t)
+		token, nonce := sendLink(t, env, handler)
+		id, signature, _ := strings.Cut(token, ".")
+
+		_, _, err := env.uc.LoginWithLink(ctx, id+"."+tamper(signature), nonce)
+		assert.ErrorIs(t, err, domain.ErrLoginLinkInvalid)
+		_, _, err = env.uc.LoginWithLink(ctx, tamper(id)+"."+signature, nonce)
+		assert.ErrorIs(t, err, domain.ErrLoginLinkInvalid)
+	})
+
+	t.Run("Link Expires", func(t *testing.T) {
+		env, handler := setup(t)
+		token, nonce := sendLink(t, env, handler)
+
+		env.redis.FastForward(usecase.MagicLinkTTL + time.Second)
+		_, _, err := env.uc.LoginWithLink(ctx, token, nonce)
+		assert.ErrorIs(t, err, domain.ErrLoginLinkInvalid)
+	})
+
+	t.Run("Job Past Expiry Sends Nothing", func(t *testing.T) {
+		env, handler := setup(t)
+		payload, _ := json.Marshal(usecase.LoginLinkPayload{
+			Email:     email,
+			Token:     "id-lama.signature",
+			ExpiresAt: time.Now().Add(-time.Minute),
+		})
+
+		require.NoError(t, handler.Handle(ctx, &queue.Job{Type: usecase.JobSendLoginLink, Payload: payload}))
+		assert.Empty(t, env.mail.Sent())
+		assert.Empty(t, env.redis.Keys())
+	})
+}
\ No newline at end of file
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/internal/handler"
+	"khalif-identify/internal/tests/mocks"
+	"khalif-identify/pkg/metrics"
+	"khalif-identify/pkg/middleware"
+
+)
+
+func TestLoginMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockUC := new(mocks.MockUserUseCase)
+	mockUC.On("Login", "khalif@gmail.com", "password123").
+		Return("token", &domain.User{UUID: "u-1", Role: domain.Role{Name: "Customer"}}, nil)
+	mockUC.On("Login", "khalif@gmail.com", "salah").
+		Return("", nil, domain.ErrInvalidCredentials)
+
+	h := handler.NewUserHandler(mockUC)
+	r := gin.New()
+	r.Use(metrics.Middleware(), middleware.ErrorHandler())
+	r.POST("/api/user/login", h.Login)
+
+	login := func(password string) {
+		body := []byte(`{"email":"khalif@gmail.com","password":"` + password + `"}`)
+		req := httptest.NewRequest(http.MethodPost, "/api/user/login", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	success := metrics.Logins.WithLabelValues("email", metrics.LoginSuccess, "Customer")
+	failed := metrics.Logins.WithLabelValues("email", metrics.LoginInvalidCredentials, "unknown")
+	beforeSuccess, beforeFailed := testutil.ToFloat64(success), testutil.ToFloat64(failed)
+
+	login("password123")
+	login("salah")
+	login("salah")
+
+	assert.Equal(t, beforeSuccess+1, testutil.ToFloat64(success))
+	assert.Equal(t, beforeFailed+2, testutil.ToFloat64(failed))
+
+	// Histogram memakai route template, bukan path mentah
+	assert.GreaterOrEqual(t, testutil.CollectAndCount(metrics.HTTPRequestDuration, "khalif_http_request_duration_seconds"), 2)
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(token, auth string) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.GET("/metrics", metrics.Handler(token))
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Open", func(t *testing.T) {
+		metrics.Logouts.Inc()
+		w := serve("", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "khalif_logouts_total")
+		assert.Contains(t, w.Body.String(), "go_goroutines")
+	})
+
+	t.Run("Token Required", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, serve("scrape-secret", "").Code)
+		assert.Equal(t, http.StatusUnauthorized, serve("scrape-secret", "Bearer salah").Code)
+		assert.Equal(t, http.StatusOK, serve("scrape-secret", "Bearer scrape-secret").Code)
+	})
+}
\ No newline at end of file
@@ -0,0 +1,174 @@
+package mocks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"khalif-identify/pkg/utils"
+
+)
+
+// Outbox menampung SMS/email yang "terkirim" agar test bisa membaca kode OTP atau link di dalamnya
+type Outbox struct {
+	mu       sync.Mutex
+	Messages []OutboxMessage
+}
+
+type OutboxMessage struct {
+	To      string
+	Subject string // Kosong untuk SMS
+	Body    string
+}
+
+// FakeSMS sms.Sender yang hanya mencatat pesan
+type FakeSMS struct{ Outbox }
+
+func (f *FakeSMS) Send(ctx context.Context, to, message string) error {
+	f.add(OutboxMessage{To: to, Body: message})
+	return nil
+}
+
+// FakeMailer mailer.Mailer yang hanya mencatat email; Err diisi untuk mensimulasikan gagal kirim
+type FakeMailer struct {
+	Outbox
+	Err error
+}
+
+func (f *FakeMailer) Send(ctx context.Context, to, subject, body string) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.add(OutboxMessage{To: to, Subject: subject, Body: body})
+	return nil
+}
+
+func (o *Outbox) add(message OutboxMessage) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.Messages = append(o.Messages, message)
+}
+
+// Sent mengembalikan salinan pesan yang sudah terkirim
+func (o *Outbox) Sent() []OutboxMessage {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]OutboxMessage(nil), o.Messages...)
+}
+
+// Last mengembalikan pesan terakhir (zero value jika belum ada)
+func (o *Outbox) Last() OutboxMessage {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.Messages) == 0 {
+		return OutboxMessage{}
+	}
+	return o.Messages[len(o.Messages)-1]
+}
+
+// FakeJobQueue domain.JobQueue yang mencatat job tanpa menjalankannya
+type FakeJobQueue struct {
+	mu   sync.Mutex
+	Jobs []QueuedJob
+}
+
+type QueuedJob struct {
+	Type    string
+	Payload json.RawMessage
+	At      time.Time // Zero untuk Enqueue biasa
+}
+
+func (q *FakeJobQueue) Enqueue(ctx context.Context, jobType string, payload interface{}) error {
+	return q.EnqueueAt(ctx, jobType, payload, time.Time{})
+}
+
+func (q *FakeJobQueue) EnqueueAt(ctx context.Context, jobType string, payload interface{}, at time.Time) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.Jobs = append(q.Jobs, QueuedJob{Type: jobType, Payload: data, At: at})
+	return nil
+}
+
+func (q *FakeJobQueue) Discard(ctx context.Context, jobType string, match func(payload json.RawMessage) bool) ([]json.RawMessage, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var discarded []json.RawMessage
+	kept := q.Jobs[:0]
+	for _, job := range q.Jobs {
+		if job.Type == jobType && match(job.Payload) {
+			discarded = append(discarded, job.Payload)
+			continue
+		}
+		kept = append(kept, job)
+	}
+	q.Jobs = kept
+	return discarded, nil
+}
+
+// Of mengembalikan job dengan tipe tertentu sesuai urutan masuk
+func (q *FakeJobQueue) Of(jobType string) []QueuedJob {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var jobs []QueuedJob
+	for _, job := range q.Jobs {
+		if job.Type == jobType {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs
+}
+
+// FakeStorage utils.Storage in-memory; file disimpan dengan URL "mem://<nama>"
+type FakeStorage struct {
+	mu      sync.Mutex
+	Files   map[string][]byte
+	Deleted []string
+}
+
+func (s *FakeStorage) UploadFile(ctx context.Context, file io.Reader, filename string) (string, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Files == nil {
+		s.Files = map[string][]byte{}
+	}
+	stored := "mem://" + filename
+	s.Files[stored] = data
+	return stored, nil
+}
+
+func (s *FakeStorage) ResolveURL(stored string) (string, *time.Time) {
+	return stored, nil
+}
+
+func (s *FakeStorage) Open(ctx context.Context, stored string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.Files[stored]
+	if !ok {
+		return nil, utils.ErrNotInStorage
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *FakeStorage) Delete(ctx context.Context, stored string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.Files, stored)
+	s.Deleted = append(s.Deleted, stored)
+	return nil
+}
+
+func (s *FakeStorage) Ping(ctx context.Context) error {
+	return nil
+}
\ No newline at end of file
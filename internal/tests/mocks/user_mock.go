@@ -1,6 +1,8 @@
 package mocks
 
 import (
+	"context"
+	"io"
 	"mime/multipart"
 
 	"github.com/stretchr/testify/mock"
@@ -14,7 +16,7 @@ type MockUserUseCase struct {
 	mock.Mock
 }
 
-func (m *MockUserUseCase) Login(email, password string) (string, *domain.User, error) {
+func (m *MockUserUseCase) Login(ctx context.Context, email, password string) (string, *domain.User, error) {
 	args := m.Called(email, password)
 	if args.Get(1) == nil {
 		return args.String(0), nil, args.Error(2)
@@ -22,29 +24,233 @@ func (m *MockUserUseCase) Login(email, password string) (string, *domain.User, e
 	return args.String(0), args.Get(1).(*domain.User), args.Error(2)
 }
 
-func (m *MockUserUseCase) Register(name, email, phone, password string, file multipart.File, fh *multipart.FileHeader) (*domain.User, error) {
-	args := m.Called(name, email, phone, password, file, fh)
+func (m *MockUserUseCase) Register(ctx context.Context, name, email, phone, phoneRegion, password string, file multipart.File, fh *multipart.FileHeader) (*domain.User, error) {
+	args := m.Called(name, email, phone, phoneRegion, password, file, fh)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*domain.User), args.Error(1)
 }
 
+func (m *MockUserUseCase) RegisterCustomer(ctx context.Context, name, email, phone, phoneRegion, password string, file multipart.File, fh *multipart.FileHeader) (*domain.User, error) {
+	args := m.Called(name, email, phone, phoneRegion, password, file, fh)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserUseCase) Logout(ctx context.Context, tokenString string) error {
+	args := m.Called(tokenString)
+	return args.Error(0)
+}
+
 // --- UPDATE DISINI ---
-func (m *MockUserUseCase) UpdateProfile(userID uint, name, phone, password string, file multipart.File, fh *multipart.FileHeader) (*domain.User, error) {
-	// Kita gunakan mock.Called untuk merekam panggilan
-	args := m.Called(userID, name, phone, password, file, fh)
-	
+func (m *MockUserUseCase) UpdateProfile(ctx context.Context, userUUID string, name, phone, phoneRegion, locale string, file multipart.File, fh *multipart.FileHeader) (*domain.User, error) {
+	// Kita gunakan mock.Called untuk merekam panggilan (context tidak ikut direkam)
+	args := m.Called(userUUID, name, phone, phoneRegion, locale, file, fh)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserUseCase) GetAllAdmins(ctx context.Context, page, limit int) ([]domain.User, int64, error) {
+	args := m.Called(page, limit)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]domain.User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserUseCase) GetCountryCodes(acceptLanguage, query, sortBy string) utils.CountryList {
+	args := m.Called(acceptLanguage, query, sortBy)
+	return args.Get(0).(utils.CountryList)
+}
+
+func (m *MockUserUseCase) GetProfile(ctx context.Context, userUUID string) (*domain.User, error) {
+	args := m.Called(userUUID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserUseCase) PreferredLocale(ctx context.Context, userUUID string) (string, error) {
+	args := m.Called(userUUID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUserUseCase) SessionVersion(ctx context.Context, userUUID string) (int, error) {
+	args := m.Called(userUUID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockUserUseCase) SendPhoneOTP(ctx context.Context, userUUID string) (*domain.OTPChallenge, error) {
+	args := m.Called(userUUID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.OTPChallenge), args.Error(1)
+}
+
+func (m *MockUserUseCase) VerifyPhoneOTP(ctx context.Context, userUUID, code string) (*domain.User, error) {
+	args := m.Called(userUUID, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserUseCase) LoginWithPhone(ctx context.Context, phone, phoneRegion, password string) (string, *domain.User, error) {
+	args := m.Called(phone, phoneRegion, password)
+	if args.Get(1) == nil {
+		return args.String(0), nil, args.Error(2)
+	}
+	return args.String(0), args.Get(1).(*domain.User), args.Error(2)
+}
+
+func (m *MockUserUseCase) SendLoginOTP(ctx context.Context, phone, phoneRegion string) (*domain.OTPChallenge, error) {
+	args := m.Called(phone, phoneRegion)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.OTPChallenge), args.Error(1)
+}
+
+func (m *MockUserUseCase) LoginWithOTP(ctx context.Context, phone, phoneRegion, code string) (string, *domain.User, error) {
+	args := m.Called(phone, phoneRegion, code)
+	if args.Get(1) == nil {
+		return args.String(0), nil, args.Error(2)
+	}
+	return args.String(0), args.Get(1).(*domain.User), args.Error(2)
+}
+
+func (m *MockUserUseCase) SendLoginLink(ctx context.Context, email string) (*domain.LoginLinkChallenge, error) {
+	args := m.Called(email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LoginLinkChallenge), args.Error(1)
+}
+
+func (m *MockUserUseCase) LoginWithLink(ctx context.Context, token, nonce string) (string, *domain.User, error) {
+	args := m.Called(token, nonce)
+	if args.Get(1) == nil {
+		return args.String(0), nil, args.Error(2)
+	}
+	return args.String(0), args.Get(1).(*domain.User), args.Error(2)
+}
+
+func (m *MockUserUseCase) SetRole(ctx context.Context, identifier string, roleID uint) (*domain.User, error) {
+	args := m.Called(identifier, roleID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserUseCase) ResetPassword(ctx context.Context, identifier, newPassword string) (*domain.User, error) {
+	args := m.Called(identifier, newPassword)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserUseCase) SetDisabled(ctx context.Context, identifier string, disabled bool) (*domain.User, error) {
+	args := m.Called(identifier, disabled)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserUseCase) RequestEmailChange(ctx context.Context, userUUID, newEmail, currentPassword string, revokeSessions bool) (*domain.EmailChangeChallenge, error) {
+	args := m.Called(userUUID, newEmail, currentPassword, revokeSessions)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.EmailChangeChallenge), args.Error(1)
+}
+
+func (m *MockUserUseCase) ConfirmEmailChange(ctx context.Context, token string) (*domain.User, error) {
+	args := m.Called(token)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*domain.User), args.Error(1)
 }
 
-func (m *MockUserUseCase) GetAllAdmins() ([]domain.User, error) {
-	return nil, nil
+func (m *MockUserUseCase) CancelEmailChange(ctx context.Context, token string) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockUserUseCase) ChangePassword(ctx context.Context, userUUID, currentPassword, newPassword string) (string, *domain.User, error) {
+	args := m.Called(userUUID, currentPassword, newPassword)
+	if args.Get(1) == nil {
+		return args.String(0), nil, args.Error(2)
+	}
+	return args.String(0), args.Get(1).(*domain.User), args.Error(2)
+}
+
+func (m *MockUserUseCase) RequestDataExport(ctx context.Context, userUUID string) error {
+	args := m.Called(userUUID)
+	return args.Error(0)
 }
 
-func (m *MockUserUseCase) GetCountryCodes() []utils.Country {
-	return nil
+func (m *MockUserUseCase) DownloadDataExport(ctx context.Context, token string) (*domain.DataExport, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.DataExport), args.Error(1)
+}
+
+func (m *MockUserUseCase) RequestAccountDeletion(ctx context.Context, userUUID, currentPassword string) (*domain.User, error) {
+	args := m.Called(userUUID, currentPassword)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserUseCase) CancelAccountDeletion(ctx context.Context, token string) (*domain.User, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserUseCase) SetPendingDeletion(ctx context.Context, identifier string, pending bool) (*domain.User, error) {
+	args := m.Called(identifier, pending)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+
+func (m *MockUserUseCase) GetAuditEvents(ctx context.Context, filter domain.AuditFilter, page, limit int) ([]domain.AuditEvent, int64, error) {
+	args := m.Called(filter, page, limit)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]domain.AuditEvent), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserUseCase) ExportAuditEvents(ctx context.Context, filter domain.AuditFilter, w io.Writer) error {
+	args := m.Called(filter, w)
+	return args.Error(0)
+}
+
+func (m *MockUserUseCase) VerifyAuditLog(ctx context.Context) (*domain.AuditVerification, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AuditVerification), args.Error(1)
 }
\ No newline at end of file
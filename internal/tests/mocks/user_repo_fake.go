@@ -0,0 +1,340 @@
+package mocks
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"khalif-identify/internal/domain"
+
+)
+
+// FakeUserRepo UserRepository in-memory untuk test usecase. Perilakunya meniru UserRepo
+// Postgres: email dinormalisasi, unique email/nomor HP, UpdateEmail kondisional, dan
+// user yang dikembalikan adalah salinan (mengubahnya tidak mengubah "database").
+type FakeUserRepo struct {
+	mu     sync.Mutex
+	users  []domain.User
+	logins []domain.LoginEvent
+	audit  []domain.AuditEvent
+	roles  map[uint]domain.Role
+}
+
+func NewFakeUserRepo() *FakeUserRepo {
+	return &FakeUserRepo{roles: map[uint]domain.Role{
+		1: {ID: 1, Name: "Admin"},
+		2: {ID: 2, Name: "Staff"},
+		3: {ID: 3, Name: "User"},
+	}}
+}
+
+// Seed menambahkan user langsung ke "database" (ID diisi otomatis jika kosong)
+func (r *FakeUserRepo) Seed(user domain.User) *domain.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user.ID == 0 {
+		user.ID = uint(len(r.users) + 1)
+	}
+	if user.RoleID == 0 {
+		user.RoleID = 3
+	}
+	user.Email = domain.NormalizeEmail(user.Email)
+	user.Role = r.roles[user.RoleID]
+	r.users = append(r.users, user)
+	return &user
+}
+
+// User mengembalikan salinan terbaru user yang tersimpan (nil jika tidak ada)
+func (r *FakeUserRepo) User(uuid string) *domain.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if i := r.indexBy(func(u *domain.User) bool { return u.UUID == uuid }); i >= 0 {
+		user := r.users[i]
+		return &user
+	}
+	return nil
+}
+
+// AuditEvents mengembalikan semua baris audit yang sudah ditulis, urut ID naik
+func (r *FakeUserRepo) AuditEvents() []domain.AuditEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]domain.AuditEvent(nil), r.audit...)
+}
+
+func (r *FakeUserRepo) indexBy(match func(u *domain.User) bool) int {
+	for i := range r.users {
+		if match(&r.users[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *FakeUserRepo) find(match func(u *domain.User) bool) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.indexBy(match)
+	if i < 0 {
+		return &domain.User{}, domain.ErrUserNotFound
+	}
+	user := r.users[i]
+	user.Role = r.roles[user.RoleID]
+	return &user, nil
+}
+
+// checkUnique meniru unique index email & nomor HP (skip = index user yang sedang disimpan)
+func (r *FakeUserRepo) checkUnique(user *domain.User, skip int) error {
+	for i := range r.users {
+		if i == skip {
+			continue
+		}
+		if r.users[i].Email == user.Email {
+			return domain.ErrEmailTaken
+		}
+		if user.PhoneNumber != "" && r.users[i].PhoneNumber == user.PhoneNumber {
+			return domain.ErrPhoneTaken
+		}
+	}
+	return nil
+}
+
+func (r *FakeUserRepo) Create(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user.Email = domain.NormalizeEmail(user.Email)
+	if err := r.checkUnique(user, -1); err != nil {
+		return err
+	}
+	user.ID = uint(len(r.users) + 1)
+	user.SessionVersion, user.SessionsRevokedAt = 0, nil
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = user.CreatedAt
+	r.users = append(r.users, *user)
+	return nil
+}
+
+func (r *FakeUserRepo) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
+	email = domain.NormalizeEmail(email)
+	return r.find(func(u *domain.User) bool { return u.Email == email })
+}
+
+func (r *FakeUserRepo) FindByPhone(ctx context.Context, phone string) (*domain.User, error) {
+	return r.find(func(u *domain.User) bool { return u.PhoneNumber == phone })
+}
+
+func (r *FakeUserRepo) FindByID(ctx context.Context, id uint) (*domain.User, error) {
+	return r.find(func(u *domain.User) bool { return u.ID == id })
+}
+
+func (r *FakeUserRepo) FindByUUID(ctx context.Context, uuid string) (*domain.User, error) {
+	return r.find(func(u *domain.User) bool { return u.UUID == uuid })
+}
+
+func (r *FakeUserRepo) FindAll(ctx context.Context, page, limit int) ([]domain.User, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	total := len(r.users)
+	start := (page - 1) * limit
+	if start > total {
+		start = total
+	}
+	end := start + limit
+	if end > total {
+		end = total
+	}
+	return append([]domain.User(nil), r.users[start:end]...), int64(total), nil
+}
+
+func (r *FakeUserRepo) Update(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user.Email = domain.NormalizeEmail(user.Email)
+	i := r.indexBy(func(u *domain.User) bool { return u.ID == user.ID })
+	if i < 0 {
+		return domain.ErrUserNotFound
+	}
+	if err := r.checkUnique(user, i); err != nil {
+		return err
+	}
+	// Kolom read-only (gorm:"->") tidak ikut tersimpan oleh Save
+	user.SessionVersion, user.SessionsRevokedAt = r.users[i].SessionVersion, r.users[i].SessionsRevokedAt
+	user.UpdatedAt = time.Now()
+	r.users[i] = *user
+	return nil
+}
+
+func (r *FakeUserRepo) UpdateEmail(ctx context.Context, userUUID, oldEmail, newEmail string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	oldEmail, newEmail = domain.NormalizeEmail(oldEmail), domain.NormalizeEmail(newEmail)
+	i := r.indexBy(func(u *domain.User) bool { return u.UUID == userUUID && u.Email == oldEmail })
+	if i < 0 {
+		return domain.ErrUserNotFound
+	}
+	updated := r.users[i]
+	updated.Email = newEmail
+	if err := r.checkUnique(&updated, i); err != nil {
+		return err
+	}
+	r.users[i] = updated
+	return nil
+}
+
+func (r *FakeUserRepo) UpdateProfileImage(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.indexBy(func(u *domain.User) bool {
+		return u.ID == user.ID && u.AnonymizedAt == nil && u.DeletionScheduledAt == nil
+	})
+	if i < 0 {
+		return domain.ErrUserNotFound
+	}
+	stored := &r.users[i]
+	stored.ProfileImage, stored.Theme, stored.DominantColor, stored.ImageStatus = user.ProfileImage, user.Theme, user.DominantColor, user.ImageStatus
+	stored.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *FakeUserRepo) Anonymize(ctx context.Context, user *domain.User) error {
+	if err := r.Update(ctx, user); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.logins[:0]
+	for _, event := range r.logins {
+		if event.UserID != user.ID {
+			kept = append(kept, event)
+		}
+	}
+	r.logins = kept
+	return nil
+}
+
+func (r *FakeUserRepo) RevokeSessions(ctx context.Context, userUUID string, at time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.indexBy(func(u *domain.User) bool { return u.UUID == userUUID })
+	if i < 0 {
+		return 0, domain.ErrUserNotFound
+	}
+	r.users[i].SessionVersion++
+	r.users[i].SessionsRevokedAt = &at
+	return r.users[i].SessionVersion, nil
+}
+
+func (r *FakeUserRepo) FindDeletionDue(ctx context.Context, before time.Time, limit int) ([]domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []domain.User
+	for _, user := range r.users {
+		if user.DeletionScheduledAt != nil && !user.DeletionScheduledAt.After(before) && user.AnonymizedAt == nil {
+			user.Role = r.roles[user.RoleID]
+			due = append(due, user)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].DeletionScheduledAt.Before(*due[j].DeletionScheduledAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (r *FakeUserRepo) RecordLogin(ctx context.Context, event *domain.LoginEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	event.ID = uint(len(r.logins) + 1)
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	r.logins = append(r.logins, *event)
+	return nil
+}
+
+func (r *FakeUserRepo) FindLogins(ctx context.Context, userID uint, since time.Time) ([]domain.LoginEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []domain.LoginEvent
+	for i := len(r.logins) - 1; i >= 0; i-- {
+		if r.logins[i].UserID == userID && !r.logins[i].CreatedAt.Before(since) {
+			events = append(events, r.logins[i])
+		}
+	}
+	return events, nil
+}
+
+func (r *FakeUserRepo) AppendAudit(ctx context.Context, event *domain.AuditEvent, seal func(prevHash string)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	prevHash := ""
+	if len(r.audit) > 0 {
+		prevHash = r.audit[len(r.audit)-1].Hash
+	}
+	seal(prevHash)
+	event.ID = uint64(len(r.audit) + 1)
+	r.audit = append(r.audit, *event)
+	return nil
+}
+
+func (r *FakeUserRepo) FindAudit(ctx context.Context, filter domain.AuditFilter, page, limit int) ([]domain.AuditEvent, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matched []domain.AuditEvent
+	for i := len(r.audit) - 1; i >= 0; i-- {
+		if auditMatches(r.audit[i], filter) {
+			matched = append(matched, r.audit[i])
+		}
+	}
+	total := len(matched)
+	start := (page - 1) * limit
+	if start > total {
+		start = total
+	}
+	end := start + limit
+	if end > total {
+		end = total
+	}
+	return matched[start:end], int64(total), nil
+}
+
+func (r *FakeUserRepo) ScanAudit(ctx context.Context, filter domain.AuditFilter, afterID uint64, limit int) ([]domain.AuditEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []domain.AuditEvent
+	for _, event := range r.audit {
+		if event.ID > afterID && auditMatches(event, filter) && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func auditMatches(event domain.AuditEvent, filter domain.AuditFilter) bool {
+	return (filter.ActorID == "" || event.ActorID == filter.ActorID) &&
+		(filter.SubjectID == "" || event.SubjectID == filter.SubjectID) &&
+		(filter.Action == "" || event.Action == filter.Action) &&
+		(filter.From.IsZero() || !event.CreatedAt.Before(filter.From)) &&
+		(filter.To.IsZero() || event.CreatedAt.Before(filter.To))
+}
+
+func (r *FakeUserRepo) CountByRoleID(ctx context.Context, roleID uint) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, user := range r.users {
+		if user.RoleID == roleID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *FakeUserRepo) FindRoleByID(ctx context.Context, id uint) (*domain.Role, error) {
+	role, ok := r.roles[id]
+	if !ok {
+		return &domain.Role{}, domain.ErrRoleNotFound
+	}
+	return &role, nil
+}
\ No newline at end of file
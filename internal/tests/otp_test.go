@@ -0,0 +1,197 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/internal/usecase"
+
+)
+
+func TestOTPManager(t *testing.T) {
+	ctx := context.Background()
+	userUUID := "2b1c6a9e-0f4d-4c1a-9f0e-555555555555"
+	phone := "+6281234567890"
+
+	setup := func(t *testing.T) *usecaseEnv {
+		env := newUsecaseEnv(t)
+		env.repo.Seed(domain.User{UUID: userUUID, Name: "Khalif", Email: "khalif@gmail.com", PhoneNumber: phone, PhoneRegion: "ID"})
+		return env
+	}
+
+	t.Run("Correct Code Verifies Once", func(t *testing.T) {
+		env := setup(t)
+		challenge, err := env.uc.SendPhoneOTP(ctx, userUUID)
+		require.NoError(t, err)
+		assert.Equal(t, "+62812****7890", challenge.Destination)
+		assert.Equal(t, phone, env.sms.Last().To)
+
+		user, err := env.uc.VerifyPhoneOTP(ctx, userUUID, env.lastOTP(t))
+		require.NoError(t, err)
+		assert.True(t, user.PhoneVerified)
+		assert.True(t, env.repo.User(userUUID).PhoneVerified)
+		key := "otp:" + usecase.OTPPurposeVerifyPhone + ":" + userUUID + ":" + phone
+		assert.False(t, env.redis.Exists(key), "kode hangus setelah berhasil")
+		assert.False(t, env.redis.Exists(key+":attempts"))
+		assert.True(t, env.redis.Exists(key+":cooldown"), "cooldown kirim ulang tetap berlaku")
+	})
+
+	t.Run("Only The HMAC Of The Code Is Stored", func(t *testing.T) {
+		env := setup(t)
+		_, err := env.uc.SendPhoneOTP(ctx, userUUID)
+		require.NoError(t, err)
+		code := env.lastOTP(t)
+
+		stored, err := env.redis.Get("otp:" + usecase.OTPPurposeVerifyPhone + ":" + userUUID + ":" + phone)
+		require.NoError(t, err)
+		assert.Len(t, stored, 64)
+		assert.NotContains(t, stored, code)
+	})
+
+	t.Run("Wrong Code Is Rejected", func(t *testing.T) {
+		env := setup(t)
+		_, err := env.uc.SendPhoneOTP(ctx, userUUID)
+		require.NoError(t, err)
+
+		wrong := "000000"
+		if env.lastOTP(t) == wrong {
+			wrong = "111111"
+		}
+		_, err = env.uc.VerifyPhoneOTP(ctx, userUUID, wrong)
+		assert.ErrorIs(t, err, domain.ErrOTPInvalid)
+		assert.False(t, env.repo.User(userUUID).PhoneVerified)
+	})
+
+	t.Run("Resend Within Cooldown Is Refused", func(t *testing.T) {
+		env := setup(t)
+		_, err := env.uc.SendPhoneOTP(ctx, userUUID)
+		require.NoError(t, err)
+
+		_, err = env.uc.SendPhoneOTP(ctx, userUUID)
+		var cooldown *domain.OTPCooldownError
+		require.True(t, errors.As(err, &cooldown))
+		assert.ErrorIs(t, err, domain.ErrOTPCooldown)
+		assert.InDelta(t, usecase.OTPResendCooldown.Seconds(), cooldown.RetryAfter.Seconds(), 1)
+		assert.Len(t, env.sms.Sent(), 1)
+
+		// Setelah cooldown lewat boleh kirim lagi, dan kode lama tidak berlaku
+		oldCode := env.lastOTP(t)
+		env.redis.FastForward(usecase.OTPResendCooldown)
+		_, err = env.uc.SendPhoneOTP(ctx, userUUID)
+		require.NoError(t, err)
+		if newCode := env.lastOTP(t); newCode != oldCode {
+			_, err = env.uc.VerifyPhoneOTP(ctx, userUUID, oldCode)
+			assert.ErrorIs(t, err, domain.ErrOTPInvalid)
+		}
+	})
+
+	t.Run("Hourly Send Cap", func(t *testing.T) {
+		env := setup(t)
+		for i := 0; i < usecase.OTPMaxSendsPerHour; i++ {
+			_, err := env.uc.SendPhoneOTP(ctx, userUUID)
+			require.NoError(t, err, "kiriman ke-%d", i+1)
+			env.redis.FastForward(usecase.OTPResendCooldown)
+		}
+
+		_, err := env.uc.SendPhoneOTP(ctx, userUUID)
+		var cooldown *domain.OTPCooldownError
+		require.True(t, errors.As(err, &cooldown))
+		assert.Greater(t, cooldown.RetryAfter, 50*time.Minute, "harus menunggu sisa jendela satu jam")
+		assert.Len(t, env.sms.Sent(), usecase.OTPMaxSendsPerHour)
+
+		env.redis.FastForward(time.Hour)
+		_, err = env.uc.SendPhoneOTP(ctx, userUUID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Code Burns After Max Attempts", func(t *testing.T) {
+		env := setup(t)
+		_, err := env.uc.SendPhoneOTP(ctx, userUUID)
+		require.NoError(t, err)
+		code := env.lastOTP(t)
+
+		wrong := "000000"
+		if code == wrong {
+			wrong = "111111"
+		}
+		for i := 0; i < usecase.OTPMaxAttempts; i++ {
+			_, err := env.uc.VerifyPhoneOTP(ctx, userUUID, wrong)
+			require.ErrorIs(t, err, domain.ErrOTPInvalid)
+		}
+
+		// Kode benar pun ditolak setelah batas, lalu kodenya hangus
+		_, err = env.uc.VerifyPhoneOTP(ctx, userUUID, code)
+		assert.ErrorIs(t, err, domain.ErrOTPTooManyAttempts)
+		_, err = env.uc.VerifyPhoneOTP(ctx, userUUID, code)
+		assert.ErrorIs(t, err, domain.ErrOTPExpired)
+	})
+
+	t.Run("Code Expires", func(t *testing.T) {
+		env := setup(t)
+		_, err := env.uc.SendPhoneOTP(ctx, userUUID)
+		require.NoError(t, err)
+
+		env.redis.FastForward(usecase.OTPTTL + time.Second)
+		_, err = env.uc.VerifyPhoneOTP(ctx, userUUID, env.lastOTP(t))
+		assert.ErrorIs(t, err, domain.ErrOTPExpired)
+	})
+
+	t.Run("Code Is Bound To Purpose And Number", func(t *testing.T) {
+		env := setup(t)
+		_, err := env.uc.SendPhoneOTP(ctx, userUUID)
+		require.NoError(t, err)
+		code := env.lastOTP(t)
+
+		// Kode verifikasi nomor tidak bisa dipakai login
+		_, _, err = env.uc.LoginWithOTP(ctx, phone, "ID", code)
+		assert.ErrorIs(t, err, domain.ErrOTPExpired)
+
+		// Nomor diganti setelah kode dikirim: kode lama tidak berlaku untuk nomor baru
+		user := env.repo.User(userUUID)
+		user.PhoneNumber = "+6281298765432"
+		require.NoError(t, env.repo.Update(ctx, user))
+		_, err = env.uc.VerifyPhoneOTP(ctx, userUUID, code)
+		assert.ErrorIs(t, err, domain.ErrOTPExpired)
+	})
+}
+
+func TestCacheIncr(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Window Starts At First Increment", func(t *testing.T) {
+		env := newUsecaseEnv(t)
+		count, err := env.cache.Incr(ctx, "counter", time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+
+		env.redis.FastForward(30 * time.Minute)
+		count, err = env.cache.Incr(ctx, "counter", time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+		assert.Equal(t, 30*time.Minute, env.redis.TTL("counter"), "TTL tidak digeser oleh increment berikutnya")
+	})
+
+	t.Run("Counter Without TTL Gets One", func(t *testing.T) {
+		env := newUsecaseEnv(t)
+		require.NoError(t, env.redis.Set("counter", "4"))
+
+		count, err := env.cache.Incr(ctx, "counter", time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), count)
+		assert.Equal(t, time.Hour, env.redis.TTL("counter"))
+	})
+
+	t.Run("Redis Error Is Returned", func(t *testing.T) {
+		env := newUsecaseEnv(t)
+		env.redis.SetError("redis sedang mati")
+
+		_, err := env.cache.Incr(ctx, "counter", time.Hour)
+		assert.Error(t, err)
+	})
+}
\ No newline at end of file
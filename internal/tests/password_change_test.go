@@ -0,0 +1,262 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/internal/handler"
+	"khalif-identify/internal/tests/mocks"
+	"khalif-identify/internal/usecase"
+	"khalif-identify/pkg/middleware"
+	"khalif-identify/pkg/utils"
+
+)
+
+func TestChangePassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userID := "2b1c6a9e-0f4d-4c1a-9f0e-111111111111"
+	setup := func() *handlerEnv {
+		return newHandlerEnv(userID, func(r *gin.Engine, h *handler.UserHandler) {
+			r.POST("/password/change", h.ChangePassword)
+			r.POST("/profile/update", h.UpdateProfile)
+		})
+	}
+
+	t.Run("Success Returns Fresh Token", func(t *testing.T) {
+		env := setup()
+		env.uc.On("ChangePassword", userID, "rahasia123", "rahasiaBaru456").
+			Return("token-baru", &domain.User{UUID: userID}, nil)
+
+		w := env.postJSON("/password/change", `{"current_password":"rahasia123","new_password":"rahasiaBaru456"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, "token-baru", response["token"])
+		env.uc.AssertExpectations(t)
+	})
+
+	t.Run("Wrong Current Password", func(t *testing.T) {
+		env := setup()
+		env.uc.On("ChangePassword", userID, "salah1234", "rahasiaBaru456").
+			Return("", nil, domain.ErrCurrentPasswordInvalid)
+
+		w := env.postJSON("/password/change", `{"current_password":"salah1234","new_password":"rahasiaBaru456"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, "current_password_invalid", decodeProblem(t, w).Code)
+	})
+
+	t.Run("Both Fields Required", func(t *testing.T) {
+		env := setup()
+		w := env.postJSON("/password/change", `{}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Len(t, decodeProblem(t, w).Errors, 2)
+		env.uc.AssertNotCalled(t, "ChangePassword")
+	})
+
+	t.Run("Profile Update Ignores Password", func(t *testing.T) {
+		env := setup()
+		env.uc.On("UpdateProfile", userID, "Khalif", "", "", "", mock.Anything, (*multipart.FileHeader)(nil)).
+			Return(&domain.User{Name: "Khalif"}, nil)
+
+		w := env.postJSON("/profile/update", `{"name":"Khalif","password":"rahasiaBaru456"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		env.uc.AssertNotCalled(t, "ChangePassword")
+		env.uc.AssertExpectations(t)
+	})
+}
+
+func TestSessionRevocation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	userUUID := "2b1c6a9e-0f4d-4c1a-9f0e-121212121212"
+	email := "khalif@gmail.com"
+
+	// setup memasang AuthMiddleware asli di atas usecase asli; mengembalikan token login pertama
+	setup := func(t *testing.T) (*usecaseEnv, *gin.Engine, string) {
+		env := newUsecaseEnv(t)
+		hash, err := utils.HashPasswordWithCost("rahasia123", bcrypt.MinCost)
+		require.NoError(t, err)
+		env.repo.Seed(domain.User{UUID: userUUID, Name: "Khalif", Email: email, Password: hash})
+
+		rdb := redis.NewClient(&redis.Options{Addr: env.redis.Addr()})
+		t.Cleanup(func() { rdb.Close() })
+		r := gin.New()
+		r.Use(middleware.ErrorHandler())
+		r.GET("/me", middleware.AuthMiddleware(testJWTSecret, rdb, env.uc.SessionVersion), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		token, _, err := env.uc.Login(ctx, email, "rahasia123")
+		require.NoError(t, err)
+		return env, r, token
+	}
+
+	me := func(r *gin.Engine, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Change Password Revokes Token Issued In The Same Second", func(t *testing.T) {
+		env, r, oldToken := setup(t)
+		assert.Equal(t, http.StatusOK, me(r, oldToken).Code)
+
+		freshToken, _, err := env.uc.ChangePassword(ctx, userUUID, "rahasia123", "rahasiaBaru456")
+		require.NoError(t, err)
+
+		w := me(r, oldToken)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "token_revoked", decodeProblem(t, w).Code)
+		assert.Equal(t, http.StatusOK, me(r, freshToken).Code, "token dari ganti password tetap berlaku")
+		assert.NotNil(t, env.repo.User(userUUID).SessionsRevokedAt)
+	})
+
+	t.Run("Cache Failure After Revocation Still Returns Token", func(t *testing.T) {
+		env, r, oldToken := setup(t)
+		env.redis.SetError("redis sedang mati")
+
+		freshToken, _, err := env.uc.ChangePassword(ctx, userUUID, "rahasia123", "rahasiaBaru456")
+		require.NoError(t, err)
+		assert.Equal(t, email, env.mail.Last().To, "pemberitahuan ganti password tetap terkirim")
+
+		env.redis.SetError("")
+		assert.Equal(t, http.StatusUnauthorized, me(r, oldToken).Code)
+		assert.Equal(t, http.StatusOK, me(r, freshToken).Code)
+	})
+
+	t.Run("Revocation Failure Is Reported Separately", func(t *testing.T) {
+		env, _, _ := setup(t)
+		uc := usecase.NewUserUseCase(failingRevokeRepo{env.repo}, env.cache, env.jobs, env.storage, env.sms, env.mail, env.settings, testJWTSecret)
+
+		_, _, err := uc.ChangePassword(ctx, userUUID, "rahasia123", "rahasiaBaru456")
+
+		assert.ErrorIs(t, err, domain.ErrSessionsNotRevoked)
+		assert.True(t, utils.CheckPasswordHash("rahasiaBaru456", env.repo.User(userUUID).Password), "password tetap tersimpan")
+		assert.Equal(t, email, env.mail.Last().To, "pemberitahuan ganti password tetap terkirim")
+	})
+
+	t.Run("Revocation Survives Redis Flush", func(t *testing.T) {
+		env, r, oldToken := setup(t)
+		_, err := env.uc.ResetPassword(ctx, userUUID, "rahasiaBaru456")
+		require.NoError(t, err)
+
+		env.redis.FlushAll()
+
+		w := me(r, oldToken)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "token_revoked", decodeProblem(t, w).Code)
+	})
+
+	t.Run("Redis Error Fails Closed", func(t *testing.T) {
+		env, r, token := setup(t)
+		env.redis.SetError("redis sedang mati")
+
+		w := me(r, token)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "internal_error", decodeProblem(t, w).Code)
+	})
+
+	t.Run("Malformed Authorization Header", func(t *testing.T) {
+		_, r, _ := setup(t)
+		for header, code := range map[string]string{
+			"":            "unauthorized",
+			"Basic abc":   "unauthorized",
+			"Bearer":      "token_invalid",
+			"Bearer ":     "token_invalid",
+			"xBearer abc": "token_invalid",
+		} {
+			req, _ := http.NewRequest(http.MethodGet, "/me", nil)
+			req.Header.Set("Authorization", header)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code, header)
+			assert.Equal(t, code, decodeProblem(t, w).Code, header)
+		}
+	})
+
+	t.Run("Deleted User Token Invalid", func(t *testing.T) {
+		_, r, _ := setup(t)
+		token, err := utils.GenerateToken("2b1c6a9e-0f4d-4c1a-9f0e-000000000000", "User", testJWTSecret, 0, time.Hour)
+		require.NoError(t, err)
+
+		w := me(r, token)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "token_invalid", decodeProblem(t, w).Code)
+	})
+}
+
+// TestPasswordPolicy: aturan password ditegakkan usecase, jadi CLI admin (create-admin,
+// reset-password) tidak bisa melewatinya seperti validator request
+func TestPasswordPolicy(t *testing.T) {
+	ctx := context.Background()
+	userUUID := "2b1c6a9e-0f4d-4c1a-9f0e-282828282828"
+
+	setup := func(t *testing.T) *usecaseEnv {
+		env := newUsecaseEnv(t)
+		hash, err := utils.HashPasswordWithCost("rahasia123", bcrypt.MinCost)
+		require.NoError(t, err)
+		env.repo.Seed(domain.User{UUID: userUUID, Name: "Khalif", Email: "khalif@gmail.com", Password: hash})
+		return env
+	}
+
+	t.Run("Register Rejects Weak Password", func(t *testing.T) {
+		env := setup(t)
+		_, err := env.uc.Register(ctx, "Admin Baru", "admin@khalif.id", "081234567891", "ID", "pendek", nil, nil)
+		assert.ErrorIs(t, err, domain.ErrPasswordWeak)
+		_, err = env.uc.RegisterCustomer(ctx, "Pelanggan", "pelanggan@khalif.id", "081234567892", "ID", "tanpaangka", nil, nil)
+		assert.ErrorIs(t, err, domain.ErrPasswordWeak)
+	})
+
+	t.Run("Reset Password Rejects Weak Password", func(t *testing.T) {
+		env := setup(t)
+		_, err := env.uc.ResetPassword(ctx, userUUID, "12345678")
+		assert.ErrorIs(t, err, domain.ErrPasswordWeak)
+		assert.True(t, utils.CheckPasswordHash("rahasia123", env.repo.User(userUUID).Password))
+	})
+
+	t.Run("Change Password Points At New Password", func(t *testing.T) {
+		env := setup(t)
+		_, _, err := env.uc.ChangePassword(ctx, userUUID, "rahasia123", "pendek")
+		assert.ErrorIs(t, err, domain.ErrPasswordWeak)
+		var domainErr *domain.Error
+		require.True(t, errors.As(err, &domainErr))
+		assert.Equal(t, "new_password", domainErr.Fields[0].Field)
+	})
+
+	t.Run("Custom Policy Applies To Usecase", func(t *testing.T) {
+		domain.SetPasswordPolicy(func(password string) bool { return password != "rahasiaBaru456" })
+		defer domain.SetPasswordPolicy(domain.DefaultPasswordPolicy)
+
+		_, err := setup(t).uc.ResetPassword(ctx, userUUID, "rahasiaBaru456")
+		assert.ErrorIs(t, err, domain.ErrPasswordWeak)
+	})
+}
+
+// failingRevokeRepo FakeUserRepo yang selalu gagal mencabut sesi (database bermasalah setelah password tersimpan)
+type failingRevokeRepo struct{ *mocks.FakeUserRepo }
+
+func (r failingRevokeRepo) RevokeSessions(ctx context.Context, userUUID string, at time.Time) (int, error) {
+	return 0, errors.New("database sedang mati")
+}
\ No newline at end of file
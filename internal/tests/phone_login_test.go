@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/internal/handler"
+	"khalif-identify/internal/tests/mocks"
+	"khalif-identify/pkg/middleware"
+
+)
+
+func TestPhoneLogin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func(mockUC *mocks.MockUserUseCase) *gin.Engine {
+		h := handler.NewUserHandler(mockUC)
+		r := gin.Default()
+		r.Use(middleware.ErrorHandler())
+		r.POST("/login", h.Login)
+		r.POST("/login/otp", h.SendLoginOTP)
+		return r
+	}
+
+	post := func(r *gin.Engine, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Phone And Password", func(t *testing.T) {
+		mockUC := new(mocks.MockUserUseCase)
+		mockUC.On("LoginWithPhone", "0812-3456-7890", "ID", "password123").
+			Return("token-hp", &domain.User{Name: "Khalif", PhoneNumber: "+6281234567890"}, nil)
+
+		w := post(setup(mockUC), "/login", `{"phone":"0812-3456-7890","country":"ID","password":"password123"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, "token-hp", response["token"])
+		mockUC.AssertExpectations(t)
+	})
+
+	t.Run("Phone And OTP", func(t *testing.T) {
+		mockUC := new(mocks.MockUserUseCase)
+		mockUC.On("LoginWithOTP", "+6281234567890", "", "123456").
+			Return("token-otp", &domain.User{Name: "Khalif", PhoneNumber: "+6281234567890"}, nil)
+
+		w := post(setup(mockUC), "/login", `{"phone":"+6281234567890","otp":"123456"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockUC.AssertExpectations(t)
+	})
+
+	t.Run("Wrong OTP", func(t *testing.T) {
+		mockUC := new(mocks.MockUserUseCase)
+		mockUC.On("LoginWithOTP", "+6281234567890", "", "000000").
+			Return("", nil, domain.ErrOTPInvalid)
+
+		w := post(setup(mockUC), "/login", `{"phone":"+6281234567890","otp":"000000"}`)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		mockUC.AssertNotCalled(t, "Login")
+	})
+
+	t.Run("Send Login OTP", func(t *testing.T) {
+		mockUC := new(mocks.MockUserUseCase)
+		mockUC.On("SendLoginOTP", "081234567890", "ID").
+			Return(&domain.OTPChallenge{Destination: "+62812****7890", ExpiresIn: 300, ResendIn: 60}, nil)
+
+		w := post(setup(mockUC), "/login/otp", `{"phone":"081234567890","country":"ID"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockUC.AssertExpectations(t)
+	})
+}
\ No newline at end of file
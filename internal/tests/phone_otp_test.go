@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/internal/handler"
+	"khalif-identify/internal/tests/mocks"
+	"khalif-identify/pkg/middleware"
+
+)
+
+func TestPhoneOTP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	userID := "2b1c6a9e-0f4d-4c1a-9f0e-111111111111"
+
+	setup := func(mockUC *mocks.MockUserUseCase) *gin.Engine {
+		h := handler.NewUserHandler(mockUC)
+		r := gin.Default()
+		r.Use(middleware.ErrorHandler())
+		r.Use(func(c *gin.Context) {
+			c.Set("user_id", userID)
+		})
+		r.POST("/phone/otp/send", h.SendPhoneOTP)
+		r.POST("/phone/otp/verify", h.VerifyPhoneOTP)
+		return r
+	}
+
+	t.Run("Send Success", func(t *testing.T) {
+		mockUC := new(mocks.MockUserUseCase)
+		mockUC.On("SendPhoneOTP", userID).
+			Return(&domain.OTPChallenge{Destination: "+62812****7890", ExpiresIn: 300, ResendIn: 60}, nil)
+
+		req, _ := http.NewRequest(http.MethodPost, "/phone/otp/send", nil)
+		w := httptest.NewRecorder()
+		setup(mockUC).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, "+62812****7890", data["destination"])
+		mockUC.AssertExpectations(t)
+	})
+
+	t.Run("Send During Cooldown", func(t *testing.T) {
+		mockUC := new(mocks.MockUserUseCase)
+		mockUC.On("SendPhoneOTP", userID).
+			Return(nil, &domain.OTPCooldownError{RetryAfter: 42 * time.Second})
+
+		req, _ := http.NewRequest(http.MethodPost, "/phone/otp/send", nil)
+		w := httptest.NewRecorder()
+		setup(mockUC).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "42", w.Header().Get("Retry-After"))
+	})
+
+	t.Run("Verify Wrong Code", func(t *testing.T) {
+		mockUC := new(mocks.MockUserUseCase)
+		mockUC.On("VerifyPhoneOTP", userID, "000000").Return(nil, domain.ErrOTPInvalid)
+
+		req, _ := http.NewRequest(http.MethodPost, "/phone/otp/verify", bytes.NewBufferString(`{"code":"000000"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		setup(mockUC).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockUC.AssertExpectations(t)
+	})
+
+	t.Run("Verify Success", func(t *testing.T) {
+		mockUC := new(mocks.MockUserUseCase)
+		mockUC.On("VerifyPhoneOTP", userID, "123456").
+			Return(&domain.User{UUID: userID, PhoneVerified: true}, nil)
+
+		req, _ := http.NewRequest(http.MethodPost, "/phone/otp/verify", bytes.NewBufferString(`{"code":"123456"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		setup(mockUC).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, true, data["phone_verified"])
+	})
+}
\ No newline at end of file
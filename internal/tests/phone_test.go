@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"khalif-identify/pkg/utils"
+
+)
+
+func TestFormatPhoneNumber(t *testing.T) {
+	t.Run("Nomor seluler Indonesia", func(t *testing.T) {
+		phone, err := utils.FormatPhoneNumber("0812-3456-7890", "id")
+		assert.NoError(t, err)
+		assert.Equal(t, "+6281234567890", phone)
+	})
+
+	t.Run("Nomor seluler Singapura", func(t *testing.T) {
+		phone, err := utils.FormatPhoneNumber("8123 4567", "SG")
+		assert.NoError(t, err)
+		assert.Equal(t, "+6581234567", phone)
+	})
+
+	cases := []struct {
+		name   string
+		phone  string
+		region string
+		code   string
+	}{
+		{"Kosong", "", "ID", utils.PhoneErrRequired},
+		{"Negara tidak dikenal", "08123456789", "XX", utils.PhoneErrUnknownRegion},
+		{"Bukan angka", "halo", "ID", utils.PhoneErrInvalidFormat},
+		{"Terlalu pendek", "0812", "ID", utils.PhoneErrTooShort},
+		{"Nomor Indonesia dengan negara Singapura", "+6281234567890", "SG", utils.PhoneErrInvalidForRegion},
+		{"Telepon rumah", "021-5551234", "ID", utils.PhoneErrNotMobile},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := utils.FormatPhoneNumber(tc.phone, tc.region)
+
+			var phoneErr *utils.PhoneError
+			assert.True(t, errors.As(err, &phoneErr))
+			if phoneErr != nil {
+				assert.Equal(t, tc.code, phoneErr.Code)
+			}
+		})
+	}
+}
\ No newline at end of file
@@ -16,6 +16,8 @@ import (
 	"khalif-identify/internal/domain"
 	"khalif-identify/internal/handler"
 	"khalif-identify/internal/tests/mocks"
+	"khalif-identify/pkg/middleware"
+	"khalif-identify/pkg/utils"
 
 )
 
@@ -26,21 +28,22 @@ func TestUpdateProfile(t *testing.T) {
 		mockUC := new(mocks.MockUserUseCase)
 
 		// Data Dummy
-		userID := uint(1)
+		userID := "2b1c6a9e-0f4d-4c1a-9f0e-111111111111"
 		updatedUser := &domain.User{ID: 1, Name: "Khalif Baru", PhoneNumber: "08999"}
 
 		// Ekspektasi Mock:
 		// Menggunakan mock.Anything untuk file karena pointer file sulit diprediksi di test
-		mockUC.On("UpdateProfile", userID, "Khalif Baru", "08999", "", mock.Anything, mock.Anything).
+		mockUC.On("UpdateProfile", userID, "Khalif Baru", "08999", "ID", "", mock.Anything, mock.Anything).
 			Return(updatedUser, nil)
 
 		h := handler.NewUserHandler(mockUC)
 
 		// Setup Router dengan Middleware Dummy untuk set User ID
 		r := gin.Default()
+		r.Use(middleware.ErrorHandler())
 		r.Use(func(c *gin.Context) {
-			// Pura-pura AuthMiddleware sudah jalan dan set user_id (float64 dari JWT)
-			c.Set("user_id", float64(userID)) 
+			// Pura-pura AuthMiddleware sudah jalan dan set user_id (UUID string dari JWT)
+			c.Set("user_id", userID)
 			c.Next()
 		})
 		r.POST("/profile/update", h.UpdateProfile)
@@ -51,6 +54,7 @@ func TestUpdateProfile(t *testing.T) {
 		
 		writer.WriteField("name", "Khalif Baru")
 		writer.WriteField("phone", "08999")
+		writer.WriteField("country", "ID")
 		
 		// Simulasi File Upload
 		part, _ := writer.CreateFormFile("image", "avatar.jpg")
@@ -80,6 +84,8 @@ func TestUpdateProfile(t *testing.T) {
 		h := handler.NewUserHandler(mockUC)
 
 		r := gin.Default()
+
+		r.Use(middleware.ErrorHandler())
 		// Tidak ada middleware yang set "user_id" disini
 		r.POST("/profile/update", h.UpdateProfile)
 
@@ -93,17 +99,19 @@ func TestUpdateProfile(t *testing.T) {
 
 	t.Run("Usecase Error", func(t *testing.T) {
 		mockUC := new(mocks.MockUserUseCase)
-		userID := uint(1)
+		userID := "2b1c6a9e-0f4d-4c1a-9f0e-111111111111"
 
 		// Ekspektasi Error dari usecase
-		mockUC.On("UpdateProfile", userID, "Khalif", "", "", mock.Anything, mock.Anything).
+		mockUC.On("UpdateProfile", userID, "Khalif", "", "", "", mock.Anything, mock.Anything).
 			Return(nil, errors.New("database error"))
 
 		h := handler.NewUserHandler(mockUC)
 
 		r := gin.Default()
+
+		r.Use(middleware.ErrorHandler())
 		r.Use(func(c *gin.Context) {
-			c.Set("user_id", float64(userID))
+			c.Set("user_id", userID)
 		})
 		r.POST("/profile/update", h.UpdateProfile)
 
@@ -121,4 +129,45 @@ func TestUpdateProfile(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
 		mockUC.AssertExpectations(t)
 	})
+	t.Run("Invalid Phone For Region", func(t *testing.T) {
+		mockUC := new(mocks.MockUserUseCase)
+		userID := "2b1c6a9e-0f4d-4c1a-9f0e-111111111111"
+
+		phoneErr := &utils.PhoneError{Code: utils.PhoneErrInvalidForRegion, Region: "SG", Message: "phone number is not valid for SG"}
+		mockUC.On("UpdateProfile", userID, "", "+6281234567890", "SG", "", mock.Anything, mock.Anything).
+			Return(nil, phoneErr)
+
+		h := handler.NewUserHandler(mockUC)
+
+		r := gin.Default()
+
+		r.Use(middleware.ErrorHandler())
+		r.Use(func(c *gin.Context) {
+			c.Set("user_id", userID)
+		})
+		r.POST("/profile/update", h.UpdateProfile)
+
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		writer.WriteField("phone", "+6281234567890")
+		writer.WriteField("country", "SG")
+		writer.Close()
+
+		req, _ := http.NewRequest(http.MethodPost, "/profile/update", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, utils.PhoneErrInvalidForRegion, response["code"])
+		if fields, ok := response["errors"].([]interface{}); assert.True(t, ok) && assert.Len(t, fields, 1) {
+			assert.Equal(t, "phone", fields[0].(map[string]interface{})["field"])
+		}
+
+		mockUC.AssertExpectations(t)
+	})
 }
\ No newline at end of file
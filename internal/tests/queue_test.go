@@ -0,0 +1,269 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"khalif-identify/pkg/queue"
+
+)
+
+const testQueue = "test"
+
+func newTestQueue(t *testing.T) (*redis.Client, *queue.Queue) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return rdb, queue.New(rdb, testQueue)
+}
+
+// startWorker menjalankan worker di background dan menghentikannya saat test selesai.
+// Koneksi ditutup agar BLMove yang sedang menunggu tidak menahan test sampai timeout-nya.
+func startWorker(t *testing.T, rdb *redis.Client, w *queue.Worker) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		rdb.Close()
+		wg.Wait()
+	})
+}
+
+// rawJob membuat job seperti yang ditulis Enqueue, untuk disisipkan langsung ke Redis
+func rawJob(t *testing.T, attempts int) string {
+	data, err := json.Marshal(queue.Job{
+		ID:          "job-1",
+		Type:        "test.job",
+		Payload:     json.RawMessage(`{}`),
+		Attempts:    attempts,
+		MaxAttempts: queue.DefaultMaxAttempts,
+		EnqueuedAt:  time.Now(),
+	})
+	require.NoError(t, err)
+	return string(data)
+}
+
+func retryEntries(t *testing.T, rdb *redis.Client) []redis.Z {
+	entries, err := rdb.ZRangeWithScores(context.Background(), "queue:"+testQueue+":retry", 0, -1).Result()
+	require.NoError(t, err)
+	return entries
+}
+
+func decodeJob(t *testing.T, member interface{}) queue.Job {
+	var job queue.Job
+	require.NoError(t, json.Unmarshal([]byte(member.(string)), &job))
+	return job
+}
+
+func TestQueueBackoff(t *testing.T) {
+	assert.Equal(t, 2*time.Second, queue.Backoff(1))
+	assert.Equal(t, 4*time.Second, queue.Backoff(2))
+	assert.Equal(t, 16*time.Second, queue.Backoff(4))
+	assert.Equal(t, 10*time.Minute, queue.Backoff(20), "dibatasi maksimal 10 menit")
+}
+
+func TestQueueWorker(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Failed Job Is Retried With Backoff", func(t *testing.T) {
+		rdb, q := newTestQueue(t)
+		w := queue.NewWorker(q, 1, time.Minute)
+		w.Handle("test.job", func(ctx context.Context, job *queue.Job) error {
+			return errors.New("smtp timeout")
+		})
+		require.NoError(t, q.Enqueue(ctx, "test.job", map[string]string{"to": "khalif@gmail.com"}))
+		startWorker(t, rdb, w)
+
+		require.Eventually(t, func() bool { return len(retryEntries(t, rdb)) == 1 }, 5*time.Second, 20*time.Millisecond)
+
+		entry := retryEntries(t, rdb)[0]
+		job := decodeJob(t, entry.Member)
+		assert.Equal(t, 1, job.Attempts)
+		assert.Equal(t, "smtp timeout", job.LastError)
+		dueIn := time.Until(time.UnixMilli(int64(entry.Score)))
+		assert.InDelta(t, queue.Backoff(1).Seconds(), dueIn.Seconds(), 1, "dijadwalkan ulang setelah back-off pertama")
+
+		assert.Zero(t, rdb.LLen(ctx, "queue:"+testQueue+":processing").Val())
+		assert.Zero(t, rdb.ZCard(ctx, "queue:"+testQueue+":leases").Val())
+	})
+
+	t.Run("Permanent Error Goes Straight To Dead Letter", func(t *testing.T) {
+		rdb, q := newTestQueue(t)
+		var calls atomic.Int32
+		w := queue.NewWorker(q, 1, time.Minute)
+		w.Handle("test.job", func(ctx context.Context, job *queue.Job) error {
+			calls.Add(1)
+			return queue.Permanent(errors.New("file bukan gambar"))
+		})
+		require.NoError(t, q.Enqueue(ctx, "test.job", nil))
+		startWorker(t, rdb, w)
+
+		require.Eventually(t, func() bool {
+			dead, err := q.DeadLetters(ctx, 10)
+			return err == nil && len(dead) == 1
+		}, 5*time.Second, 20*time.Millisecond)
+
+		dead, _ := q.DeadLetters(ctx, 10)
+		assert.Equal(t, "file bukan gambar", dead[0].LastError)
+		assert.NotNil(t, dead[0].FailedAt)
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Empty(t, retryEntries(t, rdb))
+	})
+
+	t.Run("Dead Letter List Is Capped", func(t *testing.T) {
+		rdb, q := newTestQueue(t)
+		deadKey := "queue:" + testQueue + ":dead"
+		for i := 0; i < queue.DeadLetterLimit; i++ {
+			require.NoError(t, rdb.LPush(ctx, deadKey, rawJob(t, queue.DefaultMaxAttempts)).Err())
+		}
+		w := queue.NewWorker(q, 1, time.Minute)
+		w.Handle("test.job", func(ctx context.Context, job *queue.Job) error {
+			return queue.Permanent(errors.New("file bukan gambar"))
+		})
+		require.NoError(t, q.Enqueue(ctx, "test.job", nil))
+		startWorker(t, rdb, w)
+
+		require.Eventually(t, func() bool {
+			dead, err := q.DeadLetters(ctx, 1)
+			return err == nil && len(dead) == 1 && dead[0].LastError == "file bukan gambar"
+		}, 5*time.Second, 20*time.Millisecond)
+		assert.Equal(t, int64(queue.DeadLetterLimit), rdb.LLen(ctx, deadKey).Val())
+	})
+
+	t.Run("Discard Removes Waiting Jobs Only", func(t *testing.T) {
+		rdb, q := newTestQueue(t)
+		require.NoError(t, q.Enqueue(ctx, "test.job", map[string]string{"user": "a"}))
+		require.NoError(t, q.Enqueue(ctx, "test.job", map[string]string{"user": "b"}))
+		require.NoError(t, q.EnqueueAt(ctx, "test.job", map[string]string{"user": "a"}, time.Now().Add(time.Hour)))
+		require.NoError(t, q.Enqueue(ctx, "other.job", map[string]string{"user": "a"}))
+
+		discarded, err := q.Discard(ctx, "test.job", func(payload json.RawMessage) bool {
+			return string(payload) == `{"user":"a"}`
+		})
+
+		require.NoError(t, err)
+		assert.Len(t, discarded, 2)
+		assert.Equal(t, int64(2), rdb.LLen(ctx, "queue:"+testQueue).Val(), "job user lain & tipe lain tetap")
+		assert.Empty(t, retryEntries(t, rdb))
+	})
+
+	t.Run("Last Attempt Goes To Dead Letter", func(t *testing.T) {
+		rdb, q := newTestQueue(t)
+		w := queue.NewWorker(q, 1, time.Minute)
+		w.Handle("test.job", func(ctx context.Context, job *queue.Job) error {
+			assert.True(t, job.IsLastAttempt())
+			return errors.New("masih gagal")
+		})
+		require.NoError(t, rdb.LPush(ctx, "queue:"+testQueue, rawJob(t, queue.DefaultMaxAttempts-1)).Err())
+		startWorker(t, rdb, w)
+
+		require.Eventually(t, func() bool {
+			dead, err := q.DeadLetters(ctx, 10)
+			return err == nil && len(dead) == 1
+		}, 5*time.Second, 20*time.Millisecond)
+
+		dead, _ := q.DeadLetters(ctx, 10)
+		assert.Equal(t, queue.DefaultMaxAttempts, dead[0].Attempts)
+		assert.Empty(t, retryEntries(t, rdb))
+	})
+
+	t.Run("Due Scheduled Job Is Promoted", func(t *testing.T) {
+		rdb, q := newTestQueue(t)
+		handled := make(chan string, 2)
+		w := queue.NewWorker(q, 1, time.Minute)
+		w.Handle("test.job", func(ctx context.Context, job *queue.Job) error {
+			var payload map[string]string
+			json.Unmarshal(job.Payload, &payload)
+			handled <- payload["when"]
+			return nil
+		})
+		require.NoError(t, q.EnqueueAt(ctx, "test.job", map[string]string{"when": "lalu"}, time.Now().Add(-time.Second)))
+		require.NoError(t, q.EnqueueAt(ctx, "test.job", map[string]string{"when": "nanti"}, time.Now().Add(time.Hour)))
+		startWorker(t, rdb, w)
+
+		select {
+		case when := <-handled:
+			assert.Equal(t, "lalu", when)
+		case <-time.After(5 * time.Second):
+			t.Fatal("job yang sudah jatuh tempo tidak diproses")
+		}
+
+		// Job yang belum jatuh tempo tetap menunggu di ZSET retry
+		entries := retryEntries(t, rdb)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "test.job", decodeJob(t, entries[0].Member).Type)
+		assert.Empty(t, handled)
+	})
+
+	t.Run("Orphaned Job Is Requeued", func(t *testing.T) {
+		rdb, q := newTestQueue(t)
+		// Worker crash: job tertinggal di processing tanpa ada yang mengerjakan
+		require.NoError(t, rdb.LPush(ctx, "queue:"+testQueue+":processing", rawJob(t, 0)).Err())
+		startWorker(t, rdb, queue.NewWorker(q, 1, 200*time.Millisecond))
+
+		require.Eventually(t, func() bool { return len(retryEntries(t, rdb)) == 1 }, 5*time.Second, 20*time.Millisecond)
+
+		job := decodeJob(t, retryEntries(t, rdb)[0].Member)
+		assert.Equal(t, "job-1", job.ID)
+		assert.Equal(t, 1, job.Attempts, "crash dihitung sebagai satu percobaan")
+		assert.NotEmpty(t, job.LastError)
+		assert.Zero(t, rdb.LLen(ctx, "queue:"+testQueue+":processing").Val())
+		assert.Zero(t, rdb.ZCard(ctx, "queue:"+testQueue+":leases").Val())
+	})
+
+	t.Run("Orphan On Last Attempt Is Buried", func(t *testing.T) {
+		rdb, q := newTestQueue(t)
+		require.NoError(t, rdb.LPush(ctx, "queue:"+testQueue+":processing", rawJob(t, queue.DefaultMaxAttempts-1)).Err())
+		startWorker(t, rdb, queue.NewWorker(q, 1, 200*time.Millisecond))
+
+		require.Eventually(t, func() bool {
+			dead, err := q.DeadLetters(ctx, 10)
+			return err == nil && len(dead) == 1
+		}, 5*time.Second, 20*time.Millisecond)
+		assert.Empty(t, retryEntries(t, rdb))
+	})
+
+	t.Run("Running Job Keeps Its Lease", func(t *testing.T) {
+		rdb, q := newTestQueue(t)
+		var calls atomic.Int32
+		done := make(chan struct{})
+		w := queue.NewWorker(q, 1, 300*time.Millisecond)
+		w.Handle("test.job", func(ctx context.Context, job *queue.Job) error {
+			calls.Add(1)
+			// Lebih lama dari beberapa visibility timeout dan dua putaran reaper
+			time.Sleep(2500 * time.Millisecond)
+			close(done)
+			return nil
+		})
+		require.NoError(t, q.Enqueue(ctx, "test.job", nil))
+		startWorker(t, rdb, w)
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("job tidak selesai")
+		}
+		require.Eventually(t, func() bool {
+			return rdb.LLen(ctx, "queue:"+testQueue+":processing").Val() == 0
+		}, time.Second, 20*time.Millisecond)
+
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Empty(t, retryEntries(t, rdb), "job yang di-heartbeat tidak boleh diambil reaper")
+		assert.Zero(t, rdb.ZCard(ctx, "queue:"+testQueue+":leases").Val())
+	})
+}
\ No newline at end of file
@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/internal/handler"
+
+)
+
+func TestRequestValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func() *handlerEnv {
+		return newHandlerEnv("uuid-1", func(r *gin.Engine, h *handler.UserHandler) {
+			r.POST("/register", h.RegisterCustomer)
+			r.POST("/profile/update", h.UpdateProfile)
+			r.POST("/password/change", h.ChangePassword)
+		})
+	}
+
+	multipartRequest := func(path string, fields map[string]string, image []byte) *http.Request {
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		for name, value := range fields {
+			writer.WriteField(name, value)
+		}
+		if image != nil {
+			part, _ := writer.CreateFormFile("image", "avatar.jpg")
+			part.Write(image)
+		}
+		writer.Close()
+
+		req, _ := http.NewRequest(http.MethodPost, path, body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Header.Set("Accept-Language", "en")
+		return req
+	}
+
+	fieldCodes := func(problem problemBody) map[string]string {
+		codes := map[string]string{}
+		for _, field := range problem.Errors {
+			codes[field.Field] = field.Code
+		}
+		return codes
+	}
+
+	t.Run("All Field Errors At Once", func(t *testing.T) {
+		env := setup()
+		w := env.serve(multipartRequest("/register", map[string]string{
+			"name":     strings.Repeat("a", 10*1024),
+			"email":    "bukan-email",
+			"password": "pendek",
+		}, []byte{}))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		problem := decodeProblem(t, w)
+		assert.Equal(t, "validation_failed", problem.Code)
+		assert.Equal(t, map[string]string{
+			"name":     "max",
+			"email":    "email",
+			"phone":    "required",
+			"password": "password",
+			"image":    "upload",
+		}, fieldCodes(problem))
+		env.uc.AssertNotCalled(t, "RegisterCustomer")
+	})
+
+	t.Run("Name Characters", func(t *testing.T) {
+		w := setup().serve(multipartRequest("/register", map[string]string{
+			"name": "<script>", "email": "a@b.co", "phone": "0812", "password": "rahasia123",
+		}, nil))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, map[string]string{"name": "person_name"}, fieldCodes(decodeProblem(t, w)))
+	})
+
+	t.Run("JSON Is Normalized", func(t *testing.T) {
+		env := setup()
+		env.uc.On("RegisterCustomer", "Siti Nur'aini", "siti@khalif.id", "081234567890", "ID", "rahasia123", nil, (*multipart.FileHeader)(nil)).
+			Return(&domain.User{Name: "Siti Nur'aini"}, nil)
+
+		w := env.postJSON("/register", `{
+			"name": "  Siti   Nur'aini ", "email": " Siti@Khalif.ID ",
+			"phone": " 081234567890 ", "country": "ID", "password": "rahasia123"
+		}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		env.uc.AssertExpectations(t)
+	})
+
+	t.Run("Password Policy Hook", func(t *testing.T) {
+		domain.SetPasswordPolicy(func(password string) bool { return password != "rahasia123" })
+		defer domain.SetPasswordPolicy(domain.DefaultPasswordPolicy)
+
+		req := jsonRequest(http.MethodPost, "/password/change", `{"current_password":"lama12345","new_password":"rahasia123"}`)
+		req.Header.Set("Accept-Language", "en")
+		w := setup().serve(req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		problem := decodeProblem(t, w)
+		if assert.Len(t, problem.Errors, 1) {
+			assert.Equal(t, "new_password", problem.Errors[0].Field)
+			assert.Contains(t, problem.Errors[0].Message, "at least one letter and one digit")
+		}
+	})
+
+	t.Run("Profile Fields Stay Optional", func(t *testing.T) {
+		env := setup()
+		env.uc.On("UpdateProfile", "uuid-1", "", "", "", "en", nil, (*multipart.FileHeader)(nil)).
+			Return(&domain.User{Locale: "en"}, nil)
+
+		w := env.postJSON("/profile/update", `{"locale":"en"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		env.uc.AssertExpectations(t)
+	})
+
+	t.Run("Image Part Is Passed Through", func(t *testing.T) {
+		env := setup()
+		env.uc.On("UpdateProfile", "uuid-1", "", "", "", "", mock.Anything, mock.MatchedBy(func(h *multipart.FileHeader) bool {
+			return h != nil && h.Filename == "avatar.jpg"
+		})).Return(&domain.User{}, nil)
+
+		w := env.serve(multipartRequest("/profile/update", map[string]string{}, []byte("fake image")))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		env.uc.AssertExpectations(t)
+	})
+}
\ No newline at end of file
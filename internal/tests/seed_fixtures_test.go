@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"khalif-identify/pkg/database"
+	"khalif-identify/pkg/utils"
+
+)
+
+func TestSeedFixtures(t *testing.T) {
+	t.Run("Production Only Base", func(t *testing.T) {
+		fixture, err := database.LoadFixtures("production", "")
+		assert.NoError(t, err)
+		assert.Empty(t, fixture.Users)
+
+		roles := map[uint]string{}
+		for _, r := range fixture.Roles {
+			roles[r.ID] = r.Name
+		}
+		// Role 3 harus sama dengan nama yang dipakai RegisterCustomer
+		assert.Equal(t, map[uint]string{1: "Admin", 2: "Editor", 3: "Customer"}, roles)
+	})
+
+	t.Run("Role Permissions Exist", func(t *testing.T) {
+		fixture, err := database.LoadFixtures("production", "")
+		assert.NoError(t, err)
+
+		known := map[string]bool{}
+		for _, p := range fixture.Permissions {
+			known[p.Name] = true
+		}
+		for _, r := range fixture.Roles {
+			for _, name := range r.Permissions {
+				assert.True(t, known[name], "role %s: permission %s tidak ada", r.Name, name)
+			}
+		}
+	})
+
+	t.Run("Staging Demo Users Are Valid", func(t *testing.T) {
+		fixture, err := database.LoadFixtures("staging", "")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, fixture.Users)
+		assert.NotEmpty(t, fixture.Roles)
+
+		for _, u := range fixture.Users {
+			_, err := utils.FormatPhoneNumber(u.Phone, u.Country)
+			assert.NoError(t, err, "nomor demo %s", u.Email)
+		}
+	})
+
+	t.Run("Development Extends Staging", func(t *testing.T) {
+		dev, err := database.LoadFixtures("development", "")
+		assert.NoError(t, err)
+		staging, _ := database.LoadFixtures("staging", "")
+		assert.Equal(t, len(staging.Users), len(dev.Users))
+	})
+}
\ No newline at end of file
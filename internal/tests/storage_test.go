@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"khalif-identify/pkg/utils"
+
+)
+
+// Connection string Azurite (emulator lokal); SAS ditandatangani lokal, tidak ada request ke jaringan
+const azuriteConnStr = "DefaultEndpointsProtocol=http;AccountName=devstoreaccount1;" +
+	"AccountKey=Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw==;" +
+	"BlobEndpoint=http://127.0.0.1:10000/devstoreaccount1;"
+
+func TestAzureResolveURL(t *testing.T) {
+	stored := "http://127.0.0.1:10000/devstoreaccount1/profiles/foto.webp"
+
+	t.Run("Public Container Returns Stored URL", func(t *testing.T) {
+		uploader, err := utils.NewAzureUploader(azuriteConnStr, "profiles", false, time.Hour)
+		require.NoError(t, err)
+
+		resolved, expiresAt := uploader.ResolveURL(stored)
+		assert.Equal(t, stored, resolved)
+		assert.Nil(t, expiresAt)
+	})
+
+	t.Run("Foreign URL Is Not Signed", func(t *testing.T) {
+		uploader, err := utils.NewAzureUploader(azuriteConnStr, "profiles", true, time.Hour)
+		require.NoError(t, err)
+
+		avatar := "https://ui-avatars.com/api/?name=Khalif"
+		resolved, expiresAt := uploader.ResolveURL(avatar)
+		assert.Equal(t, avatar, resolved)
+		assert.Nil(t, expiresAt)
+	})
+
+	t.Run("Private Blob Is Signed And Stable", func(t *testing.T) {
+		uploader, err := utils.NewAzureUploader(azuriteConnStr, "profiles", true, time.Hour)
+		require.NoError(t, err)
+
+		first, firstExpiry := uploader.ResolveURL(stored)
+		second, secondExpiry := uploader.ResolveURL(stored + "?sv=lama&sig=lama")
+		require.NotNil(t, firstExpiry)
+		require.NotNil(t, secondExpiry)
+
+		parsed, err := url.Parse(first)
+		require.NoError(t, err)
+		assert.Equal(t, "r", parsed.Query().Get("sp"))
+		assert.NotEmpty(t, parsed.Query().Get("sig"))
+		assert.True(t, firstExpiry.After(time.Now().Add(time.Hour)), "berlaku minimal satu TTL penuh")
+
+		// Dua panggilan di window yang sama harus menghasilkan URL identik (termasuk st=)
+		if firstExpiry.Equal(*secondExpiry) {
+			assert.Equal(t, first, second)
+		}
+	})
+
+	t.Run("Window Is Bucketed", func(t *testing.T) {
+		ttl := 15 * time.Minute
+		base := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+
+		start1, expiry1 := utils.SASWindow(base.Add(time.Minute), ttl)
+		start2, expiry2 := utils.SASWindow(base.Add(14*time.Minute), ttl)
+		assert.Equal(t, start1, start2)
+		assert.Equal(t, expiry1, expiry2)
+		assert.Equal(t, base.Add(-5*time.Minute), start1)
+		assert.Equal(t, base.Add(30*time.Minute), expiry1)
+
+		start3, _ := utils.SASWindow(base.Add(16*time.Minute), ttl)
+		assert.NotEqual(t, start1, start3)
+	})
+}
+
+func TestLocalStorageServe(t *testing.T) {
+	upload := func(t *testing.T, private bool) (*utils.LocalStorage, string) {
+		storage, err := utils.NewLocalStorage(t.TempDir(), "", private, 15*time.Minute, testJWTSecret)
+		require.NoError(t, err)
+		stored, err := storage.UploadFile(context.Background(), strings.NewReader("isi foto"), "foto.webp")
+		require.NoError(t, err)
+		return storage, stored
+	}
+	fetch := func(storage *utils.LocalStorage, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		storage.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	t.Run("Public Storage Serves Stored URL", func(t *testing.T) {
+		storage, stored := upload(t, false)
+
+		resolved, expiresAt := storage.ResolveURL(stored)
+		assert.Equal(t, stored, resolved)
+		assert.Nil(t, expiresAt)
+
+		w := fetch(storage, resolved)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "isi foto", w.Body.String())
+	})
+
+	t.Run("Private Storage Requires Signature", func(t *testing.T) {
+		storage, stored := upload(t, true)
+
+		resolved, expiresAt := storage.ResolveURL(stored)
+		require.NotNil(t, expiresAt)
+		assert.True(t, expiresAt.After(time.Now().Add(15*time.Minute)), "berlaku minimal satu TTL penuh")
+
+		w := fetch(storage, resolved)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "isi foto", w.Body.String())
+
+		assert.Equal(t, http.StatusForbidden, fetch(storage, stored).Code, "URL tanpa tanda tangan")
+		base, signature, _ := strings.Cut(resolved, "&sig=")
+		assert.Equal(t, http.StatusForbidden, fetch(storage, base+"&sig="+tamper(signature)).Code, "tanda tangan diubah")
+
+		// URL yang sudah ditandatangani bisa di-resolve ulang tanpa menumpuk query
+		again, _ := storage.ResolveURL(resolved)
+		assert.Equal(t, resolved, again)
+	})
+
+	t.Run("Expired Signature Is Rejected", func(t *testing.T) {
+		storage, stored := upload(t, true)
+
+		resolved, _ := storage.ResolveURL(stored)
+		parsed, err := url.Parse(resolved)
+		require.NoError(t, err)
+		query := parsed.Query()
+		query.Set("expires", "1000000000")
+		parsed.RawQuery = query.Encode()
+
+		assert.Equal(t, http.StatusForbidden, fetch(storage, parsed.String()).Code)
+	})
+
+	t.Run("Path Traversal Is Rejected", func(t *testing.T) {
+		storage, _ := upload(t, false)
+		assert.Equal(t, http.StatusNotFound, fetch(storage, "/uploads/..%2Fsecret").Code)
+	})
+}
\ No newline at end of file
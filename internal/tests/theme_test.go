@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/internal/usecase"
+	"khalif-identify/pkg/queue"
+	"khalif-identify/pkg/utils"
+
+)
+
+func TestExtractColorTheme(t *testing.T) {
+	t.Run("Palette dari gambar dua warna", func(t *testing.T) {
+		// Buat gambar 3/4 merah, 1/4 biru
+		img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+		for x := 0; x < 40; x++ {
+			for y := 0; y < 40; y++ {
+				c := color.RGBA{R: 220, G: 30, B: 30, A: 255}
+				if x >= 30 {
+					c = color.RGBA{R: 20, G: 40, B: 200, A: 255}
+				}
+				img.Set(x, y, c)
+			}
+		}
+		buf := new(bytes.Buffer)
+		png.Encode(buf, img)
+
+		theme, err := utils.ExtractColorTheme(buf)
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, theme.Palette)
+		assert.NotEmpty(t, theme.BlurHash)
+		assert.NotEmpty(t, theme.Vibrant)
+		assert.NotEmpty(t, theme.Muted)
+
+		// Palet diurutkan dari bobot terbesar
+		assert.Equal(t, theme.Dominant(), theme.Palette[0].Hex)
+		for i := 1; i < len(theme.Palette); i++ {
+			assert.GreaterOrEqual(t, theme.Palette[0].Weight, theme.Palette[i].Weight)
+		}
+		for _, p := range theme.Palette {
+			// Teks yang dipilih harus memenuhi kontras minimum WCAG untuk teks besar
+			assert.GreaterOrEqual(t, p.Contrast, 3.0)
+		}
+	})
+
+	t.Run("File bukan gambar", func(t *testing.T) {
+		_, err := utils.ExtractColorTheme(bytes.NewBufferString("bukan gambar"))
+		assert.ErrorIs(t, err, utils.ErrInvalidImage)
+	})
+
+	t.Run("Tema satu warna untuk avatar", func(t *testing.T) {
+		theme := utils.ThemeFromHex("#ffffff")
+		assert.Equal(t, "#000000", theme.Palette[0].TextColor)
+		assert.Equal(t, 21.0, theme.Palette[0].Contrast)
+	})
+}
+
+func TestProfileImageJob(t *testing.T) {
+	ctx := context.Background()
+	userUUID := "5d0e7c1a-3b2f-4e6d-8a9c-272727272727"
+
+	setup := func(t *testing.T) (*usecaseEnv, *usecase.ImageJobHandler) {
+		env := newUsecaseEnv(t)
+		hash, err := utils.HashPasswordWithCost("rahasia123", bcrypt.MinCost)
+		require.NoError(t, err)
+		env.repo.Seed(domain.User{UUID: userUUID, Name: "Khalif", Email: "khalif@gmail.com", Password: hash})
+		return env, usecase.NewImageJobHandler(env.repo, env.cache, env.storage)
+	}
+
+	// uploadPhoto mengirim foto PNG lewat UpdateProfile dan mengembalikan lokasi foto di storage
+	uploadPhoto := func(t *testing.T, env *usecaseEnv) string {
+		img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+		for x := 0; x < 8; x++ {
+			for y := 0; y < 8; y++ {
+				img.Set(x, y, color.RGBA{R: 220, G: 30, B: 30, A: 255})
+			}
+		}
+		path := filepath.Join(t.TempDir(), "foto.png")
+		buf := new(bytes.Buffer)
+		require.NoError(t, png.Encode(buf, img))
+		require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+		file, err := os.Open(path)
+		require.NoError(t, err)
+		defer file.Close()
+
+		_, err = env.uc.UpdateProfile(ctx, userUUID, "", "", "", "", file, &multipart.FileHeader{Filename: "foto.png"})
+		require.NoError(t, err)
+
+		jobs := env.jobs.Of(usecase.JobProcessProfileImage)
+		require.NotEmpty(t, jobs)
+		var payload usecase.ProfileImagePayload
+		require.NoError(t, json.Unmarshal(jobs[len(jobs)-1].Payload, &payload))
+		return payload.Stored
+	}
+
+	t.Run("Job Carries Storage Reference Only", func(t *testing.T) {
+		env, images := setup(t)
+		stored := uploadPhoto(t, env)
+
+		jobs := env.jobs.Of(usecase.JobProcessProfileImage)
+		require.Len(t, jobs, 1)
+		var payload map[string]interface{}
+		require.NoError(t, json.Unmarshal(jobs[0].Payload, &payload))
+		assert.NotContains(t, payload, "data", "isi foto tidak boleh ikut ke Redis")
+		assert.Equal(t, stored, payload["stored"])
+		assert.Contains(t, env.storage.Files, stored)
+		assert.Empty(t, env.repo.User(userUUID).ProfileImage, "foto baru dipasang setelah job selesai")
+
+		env.runJobs(t, usecase.JobProcessProfileImage, images.Handle)
+
+		user := env.repo.User(userUUID)
+		assert.Equal(t, stored, user.ProfileImage)
+		assert.Equal(t, domain.ImageStatusReady, user.ImageStatus)
+		assert.NotEmpty(t, user.DominantColor)
+	})
+
+	t.Run("Undecodable File Is Discarded", func(t *testing.T) {
+		env, images := setup(t)
+		stored, err := env.storage.UploadFile(ctx, bytes.NewReader([]byte("bukan gambar")), "rusak.png")
+		require.NoError(t, err)
+		raw, err := json.Marshal(usecase.ProfileImagePayload{UserUUID: userUUID, Stored: stored})
+		require.NoError(t, err)
+
+		err = images.Handle(ctx, &queue.Job{Type: usecase.JobProcessProfileImage, Payload: raw, MaxAttempts: queue.DefaultMaxAttempts})
+
+		assert.Error(t, err)
+		assert.Equal(t, []string{stored}, env.storage.Deleted)
+		user := env.repo.User(userUUID)
+		assert.Empty(t, user.ProfileImage)
+		assert.Equal(t, domain.ImageStatusFailed, user.ImageStatus)
+	})
+
+	t.Run("User Pending Deletion Is Skipped", func(t *testing.T) {
+		env, images := setup(t)
+		stored := uploadPhoto(t, env)
+		scheduled := time.Now().Add(time.Hour)
+		user := env.repo.User(userUUID)
+		user.DeletionScheduledAt = &scheduled
+		require.NoError(t, env.repo.Update(ctx, user))
+
+		env.runJobs(t, usecase.JobProcessProfileImage, images.Handle)
+
+		assert.Empty(t, env.repo.User(userUUID).ProfileImage)
+		assert.Equal(t, []string{stored}, env.storage.Deleted)
+	})
+
+	t.Run("Anonymized User Is Never Written", func(t *testing.T) {
+		env, images := setup(t)
+		stored := uploadPhoto(t, env)
+		anonymizedAt := time.Now()
+		user := env.repo.User(userUUID)
+		user.AnonymizedAt = &anonymizedAt
+		require.NoError(t, env.repo.Update(ctx, user))
+
+		env.runJobs(t, usecase.JobProcessProfileImage, images.Handle)
+
+		wiped := env.repo.User(userUUID)
+		assert.Empty(t, wiped.ProfileImage)
+		assert.Empty(t, wiped.DominantColor)
+		assert.Equal(t, []string{stored}, env.storage.Deleted)
+	})
+
+	t.Run("Deletion Drops Pending Jobs", func(t *testing.T) {
+		env, _ := setup(t)
+		stored := uploadPhoto(t, env)
+
+		_, err := env.uc.RequestAccountDeletion(ctx, userUUID, "rahasia123")
+		require.NoError(t, err)
+
+		assert.Empty(t, env.jobs.Of(usecase.JobProcessProfileImage))
+		assert.Equal(t, []string{stored}, env.storage.Deleted)
+	})
+}
\ No newline at end of file
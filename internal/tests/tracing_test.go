@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"khalif-identify/pkg/middleware"
+	"khalif-identify/pkg/tracing"
+
+)
+
+// setupTestTracer memasang provider global yang menyimpan span di memori
+func setupTestTracer(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestTracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Continues Upstream Trace", func(t *testing.T) {
+		recorder := setupTestTracer(t)
+
+		r := gin.New()
+		r.Use(middleware.RequestID(), tracing.Middleware())
+		r.GET("/users/:id", func(c *gin.Context) {
+			// Span use case/DB memakai ctx request sebagai parent
+			_, span := tracing.Start(c.Request.Context(), "userUseCase.GetProfile")
+			span.End()
+			c.Status(http.StatusOK)
+		})
+
+		req, _ := http.NewRequest(http.MethodGet, "/users/abc", nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		spans := recorder.Ended()
+		if assert.Len(t, spans, 2) {
+			child, server := spans[0], spans[1]
+			assert.Equal(t, "GET /users/:id", server.Name())
+			assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+			assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+			assert.Equal(t, server.SpanContext().SpanID(), child.Parent().SpanID())
+		}
+	})
+
+	t.Run("Server Error Marks Span", func(t *testing.T) {
+		recorder := setupTestTracer(t)
+
+		r := gin.New()
+		r.Use(tracing.Middleware())
+		r.GET("/boom", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+
+		req, _ := http.NewRequest(http.MethodGet, "/boom", nil)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		spans := recorder.Ended()
+		if assert.Len(t, spans, 1) {
+			assert.Equal(t, codes.Error, spans[0].Status().Code)
+		}
+	})
+
+	t.Run("Error Message Is Redacted", func(t *testing.T) {
+		recorder := setupTestTracer(t)
+
+		_, span := tracing.Start(context.Background(), "userUseCase.Login")
+		tracing.End(span, errors.New("user khalif@gmail.com tidak ditemukan"))
+
+		spans := recorder.Ended()
+		if assert.Len(t, spans, 1) {
+			assert.Equal(t, codes.Error, spans[0].Status().Code)
+			assert.NotContains(t, spans[0].Status().Description, "khalif@gmail.com")
+			for _, event := range spans[0].Events() {
+				for _, attr := range event.Attributes {
+					assert.NotContains(t, attr.Value.Emit(), "khalif@gmail.com")
+				}
+			}
+		}
+	})
+}
\ No newline at end of file
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/internal/repository"
+	"khalif-identify/internal/tests/mocks"
+	"khalif-identify/internal/usecase"
+	"khalif-identify/pkg/queue"
+
+)
+
+const testJWTSecret = "rahasia-jwt-untuk-test-minimal-32-karakter"
+
+// usecaseEnv usecase asli di atas repo in-memory dan Redis palsu (miniredis), dipakai test
+// yang perlu memeriksa perilaku usecase, bukan hanya handler
+type usecaseEnv struct {
+	uc       domain.UserUseCase
+	repo     *mocks.FakeUserRepo
+	redis    *miniredis.Miniredis
+	cache    domain.CacheRepository
+	sms      *mocks.FakeSMS
+	mail     *mocks.FakeMailer
+	jobs     *mocks.FakeJobQueue
+	storage  *mocks.FakeStorage
+	settings usecase.Settings
+}
+
+func newUsecaseEnv(t *testing.T) *usecaseEnv {
+	return newUsecaseEnvWith(t, usecase.DefaultSettings())
+}
+
+func newUsecaseEnvWith(t *testing.T, settings usecase.Settings) *usecaseEnv {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	settings.AppBaseURL = "https://app.khalif.id"
+	settings.BcryptCost = bcrypt.MinCost
+
+	env := &usecaseEnv{
+		repo:     mocks.NewFakeUserRepo(),
+		redis:    mr,
+		cache:    repository.NewCacheRepository(rdb),
+		sms:      &mocks.FakeSMS{},
+		mail:     &mocks.FakeMailer{},
+		jobs:     &mocks.FakeJobQueue{},
+		storage:  &mocks.FakeStorage{},
+		settings: settings,
+	}
+	env.uc = usecase.NewUserUseCase(env.repo, env.cache, env.jobs, env.storage, env.sms, env.mail, settings, testJWTSecret)
+	return env
+}
+
+var (
+	otpCodePattern   = regexp.MustCompile(`\b\d{6}\b`)
+	linkTokenPattern = regexp.MustCompile(`token=([^\s&]+)`)
+)
+
+// lastOTP mengambil kode OTP dari SMS terakhir
+func (e *usecaseEnv) lastOTP(t *testing.T) string {
+	code := otpCodePattern.FindString(e.sms.Last().Body)
+	require.NotEmpty(t, code, "SMS terakhir tidak berisi kode OTP")
+	return code
+}
+
+
+// runJobs menjalankan job antrian palsu dengan tipe tertentu lewat handler worker-nya
+func (e *usecaseEnv) runJobs(t *testing.T, jobType string, handle queue.HandlerFunc) {
+	for _, queued := range e.jobs.Of(jobType) {
+		require.NoError(t, handle(context.Background(), &queue.Job{Type: jobType, Payload: queued.Payload}))
+	}
+}
+
+
+// linkToken mengambil token dari link (…?token=…) di badan email
+func linkToken(t *testing.T, body string) string {
+	match := linkTokenPattern.FindStringSubmatch(body)
+	require.Len(t, match, 2, "email tidak berisi link bertoken")
+	token, err := url.QueryUnescape(match[1])
+	require.NoError(t, err)
+	return token
+}
+
+// tamper mengganti karakter pertama token dengan karakter lain yang tetap valid base64url
+func tamper(s string) string {
+	if s[0] == 'A' {
+		return "B" + s[1:]
+	}
+	return "A" + s[1:]
+}
\ No newline at end of file
@@ -0,0 +1,355 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/pkg/i18n"
+	"khalif-identify/pkg/logger"
+	"khalif-identify/pkg/metrics"
+	"khalif-identify/pkg/queue"
+	"khalif-identify/pkg/reqinfo"
+	"khalif-identify/pkg/utils"
+
+)
+
+const (
+	JobAnonymizeAccount = "account.anonymize"
+
+	// Path halaman frontend untuk link pembatalan hapus akun
+	AccountDeletionCancelPath = "/account/delete/cancel"
+
+	// Jumlah akun per batch saat PurgeDue menyapu akun yang terlewat
+	purgeBatchSize = 100
+)
+
+// AccountDeletionPayload isi job anonimisasi yang dijadwalkan di akhir masa tenggang
+type AccountDeletionPayload struct {
+	UserUUID string `json:"user_uuid"`
+}
+
+// RequestAccountDeletion menghapus akun sendiri (wajib password saat ini). Akun langsung
+// tidak bisa login, lalu dianonimkan setelah masa tenggang kecuali dibatalkan lewat link di email.
+func (u *userUseCase) RequestAccountDeletion(ctx context.Context, userUUID, currentPassword string) (*domain.User, error) {
+	user, err := u.repo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	if !u.checkPassword(ctx, currentPassword, user.Password) {
+		return nil, domain.ErrCurrentPasswordInvalid
+	}
+
+	if err := u.scheduleDeletion(ctx, user); err != nil {
+		return nil, err
+	}
+	u.presentUser(user)
+	return user, nil
+}
+
+// CancelAccountDeletion memulihkan akun lewat link di email selama masa tenggang
+func (u *userUseCase) CancelAccountDeletion(ctx context.Context, token string) (*domain.User, error) {
+	userUUID, scheduledAt, ok := u.parseDeletionToken(token)
+	if !ok {
+		return nil, domain.ErrAccountDeletionInvalid
+	}
+
+	user, err := u.repo.FindByUUID(ctx, userUUID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, domain.ErrAccountDeletionInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Link hanya berlaku untuk jadwal hapus yang sama dan sebelum anonimisasi berjalan
+	pending := user.DeletionScheduledAt
+	if user.AnonymizedAt != nil || pending == nil || pending.Unix() != scheduledAt || !time.Now().Before(*pending) {
+		return nil, domain.ErrAccountDeletionInvalid
+	}
+
+	if err := u.restoreAccount(ctx, user); err != nil {
+		return nil, err
+	}
+	u.presentUser(user)
+	return user, nil
+}
+
+// SetPendingDeletion dipakai admin: menjadwalkan hapus akun (masa tenggang tetap berlaku) atau memulihkannya
+func (u *userUseCase) SetPendingDeletion(ctx context.Context, identifier string, pending bool) (*domain.User, error) {
+	user, err := u.findUser(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if pending {
+		err = u.scheduleDeletion(ctx, user)
+	} else if user.AnonymizedAt != nil {
+		err = domain.ErrAccountDeleted
+	} else if user.DeletionScheduledAt != nil {
+		err = u.restoreAccount(ctx, user)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// scheduleDeletion melakukan soft delete. Permintaan ulang saat masih menunggu tidak menggeser jadwal.
+func (u *userUseCase) scheduleDeletion(ctx context.Context, user *domain.User) error {
+	if user.AnonymizedAt != nil {
+		return domain.ErrAccountDeleted
+	}
+	if user.DeletionScheduledAt != nil {
+		return nil
+	}
+
+	// Dibulatkan ke detik agar sama dengan waktu yang ditandatangani di link pembatalan
+	scheduledAt := time.Now().Add(u.settings.DeletionGracePeriod).Truncate(time.Second)
+	user.DeletionScheduledAt = &scheduledAt
+	if err := u.repo.Update(ctx, user); err != nil {
+		user.DeletionScheduledAt = nil
+		return err
+	}
+	metrics.AccountDeletions.WithLabelValues("scheduled").Inc()
+	u.audit.record(ctx, domain.AuditDeletionScheduled, user.UUID, domain.AuditChanges{
+		"deletion_scheduled_at": {After: auditTime(&scheduledAt)},
+	}, nil)
+
+	log := logger.FromContext(ctx)
+	if _, err := u.revokeSessions(ctx, user.UUID, "account_deleted"); err != nil {
+		log.Warn("⚠️ Gagal mencabut sesi akun yang dihapus", "user_id", user.UUID, "error", err)
+	}
+	// Gagal di sini diulang lagi oleh anonymize di akhir masa tenggang
+	if err := discardPendingRequests(ctx, u.cache, u.uploader, user.UUID); err != nil {
+		log.Warn("⚠️ Gagal menghapus permintaan yang tertunda", "user_id", user.UUID, "error", err)
+	}
+	// Job foto yang lolos dari sini dilewati sendiri oleh ImageJobHandler
+	if err := u.discardImageJobs(ctx, user.UUID); err != nil {
+		log.Warn("⚠️ Gagal membuang job foto profil yang tertunda", "user_id", user.UUID, "error", err)
+	}
+	// Job yang hilang tetap tertangani `server admin purge-deleted` karena jadwalnya ada di database
+	if err := u.queue.EnqueueAt(ctx, JobAnonymizeAccount, AccountDeletionPayload{UserUUID: user.UUID}, scheduledAt); err != nil {
+		log.Warn("⚠️ Gagal menjadwalkan anonimisasi akun", "user_id", user.UUID, "error", err)
+	}
+	u.cache.Del(ctx, "list_admins")
+
+	u.sendDeletionNotice(ctx, user, scheduledAt)
+	return nil
+}
+
+// discardImageJobs membuang job foto profil user yang belum diproses beserta foto yang sudah di-upload
+func (u *userUseCase) discardImageJobs(ctx context.Context, userUUID string) error {
+	payloads, err := u.queue.Discard(ctx, JobProcessProfileImage, func(raw json.RawMessage) bool {
+		var payload ProfileImagePayload
+		return json.Unmarshal(raw, &payload) == nil && payload.UserUUID == userUUID
+	})
+	for _, raw := range payloads {
+		var payload ProfileImagePayload
+		if json.Unmarshal(raw, &payload) == nil {
+			if err := u.uploader.Delete(ctx, payload.Stored); err != nil && !errors.Is(err, utils.ErrNotInStorage) {
+				logger.FromContext(ctx).Warn("⚠️ Gagal menghapus foto profil yang tertunda", "user_id", userUUID, "error", err)
+			}
+		}
+	}
+	return err
+}
+
+// restoreAccount membatalkan soft delete; job anonimisasi yang sudah terjadwal akan melewati akun ini
+func (u *userUseCase) restoreAccount(ctx context.Context, user *domain.User) error {
+	previous := user.DeletionScheduledAt
+	user.DeletionScheduledAt = nil
+	if err := u.repo.Update(ctx, user); err != nil {
+		user.DeletionScheduledAt = previous
+		return err
+	}
+	metrics.AccountDeletions.WithLabelValues("cancelled").Inc()
+	u.audit.record(ctx, domain.AuditDeletionCancelled, user.UUID, domain.AuditChanges{
+		"deletion_scheduled_at": {Before: auditTime(previous)},
+	}, nil)
+	u.cache.Del(ctx, "list_admins")
+	return nil
+}
+
+// sendDeletionNotice mengirim jadwal hapus + link pembatalan. Gagal kirim hanya dicatat:
+// akun tetap terjadwal dihapus dan admin masih bisa memulihkannya.
+func (u *userUseCase) sendDeletionNotice(ctx context.Context, user *domain.User, scheduledAt time.Time) {
+	link := strings.TrimRight(u.settings.AppBaseURL, "/") + AccountDeletionCancelPath + "?token=" + url.QueryEscape(u.deletionToken(user.UUID, scheduledAt))
+	locale := i18n.ForUser(ctx, user.Locale)
+	body := i18n.T(locale, "email.account_deletion.body", i18n.Params{
+		"name": user.Name,
+		"time": scheduledAt.UTC().Format("2006-01-02 15:04 UTC"),
+		"link": link,
+	})
+	if err := u.mailer.Send(ctx, user.Email, i18n.T(locale, "email.account_deletion.subject", nil), body); err != nil {
+		logger.FromContext(ctx).Warn("⚠️ Gagal mengirim email hapus akun", "user_id", user.UUID, "error", err)
+	}
+}
+
+// deletionToken: link pembatalan tidak disimpan di Redis karena masa tenggang bisa berminggu-minggu.
+// Format <uuid>.<unix jadwal hapus>.<hmac>, jadi link lama tidak berlaku untuk jadwal hapus berikutnya.
+func (u *userUseCase) deletionToken(userUUID string, scheduledAt time.Time) string {
+	payload := userUUID + "." + strconv.FormatInt(scheduledAt.Unix(), 10)
+	return payload + "." + u.signDeletion(payload)
+}
+
+func (u *userUseCase) parseDeletionToken(token string) (string, int64, bool) {
+	i := strings.LastIndex(token, ".")
+	if i < 0 {
+		return "", 0, false
+	}
+	payload, signature := token[:i], token[i+1:]
+	if !hmac.Equal([]byte(signature), []byte(u.signDeletion(payload))) {
+		return "", 0, false
+	}
+	userUUID, unix, ok := strings.Cut(payload, ".")
+	if !ok || userUUID == "" {
+		return "", 0, false
+	}
+	scheduledAt, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return userUUID, scheduledAt, true
+}
+
+func (u *userUseCase) signDeletion(payload string) string {
+	mac := hmac.New(sha256.New, []byte("account_deletion:"+u.jwtSecret))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// AccountDeletionJobHandler menganonimkan akun yang masa tenggang hapusnya sudah lewat
+type AccountDeletionJobHandler struct {
+	repo     domain.UserRepository
+	cache    domain.CacheRepository
+	uploader utils.Storage
+	audit    *auditLog
+}
+
+func NewAccountDeletionJobHandler(repo domain.UserRepository, cache domain.CacheRepository, uploader utils.Storage, settings Settings) *AccountDeletionJobHandler {
+	return &AccountDeletionJobHandler{repo: repo, cache: cache, uploader: uploader, audit: newAuditLog(repo, settings.AuditKey)}
+}
+
+func (h *AccountDeletionJobHandler) Handle(ctx context.Context, job *queue.Job) error {
+	var payload AccountDeletionPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return queue.Permanent(fmt.Errorf("payload job tidak valid: %w", err))
+	}
+
+	user, err := h.repo.FindByUUID(ctx, payload.UserUUID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return queue.Permanent(err)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = h.anonymize(ctx, user, time.Now())
+	return err
+}
+
+// PurgeDue menganonimkan semua akun yang sudah jatuh tempo (cadangan jika job di Redis hilang)
+func (h *AccountDeletionJobHandler) PurgeDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	total := 0
+	for {
+		users, err := h.repo.FindDeletionDue(ctx, now, purgeBatchSize)
+		if err != nil {
+			return total, err
+		}
+		for i := range users {
+			done, err := h.anonymize(ctx, &users[i], now)
+			if err != nil {
+				return total, err
+			}
+			if done {
+				total++
+			}
+		}
+		if len(users) < purgeBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// anonymize mengosongkan data pribadi dan menyisakan tombstone (ID, UUID, role, tanggal).
+// Akun yang sudah dipulihkan, dijadwal ulang, atau sudah dianonimkan dilewati (job boleh jalan berulang).
+func (h *AccountDeletionJobHandler) anonymize(ctx context.Context, user *domain.User, now time.Time) (bool, error) {
+	if user.AnonymizedAt != nil || user.DeletionScheduledAt == nil || user.DeletionScheduledAt.After(now) {
+		return false, nil
+	}
+
+	if err := discardPendingRequests(ctx, h.cache, h.uploader, user.UUID); err != nil {
+		return false, fmt.Errorf("gagal menghapus permintaan yang tertunda: %w", err)
+	}
+	// Foto dihapus lebih dulu: jika gagal, job di-retry sebelum URL-nya hilang dari database
+	if user.ProfileImage != "" {
+		if err := h.uploader.Delete(ctx, user.ProfileImage); err != nil && !errors.Is(err, utils.ErrNotInStorage) {
+			return false, fmt.Errorf("gagal menghapus foto profil: %w", err)
+		}
+	}
+
+	before := auditSnapshot(user)
+	user.Name = ""
+	user.Email = domain.TombstoneEmail(user.UUID)
+	user.PhoneNumber = ""
+	user.PhoneRegion = ""
+	user.PhoneVerified = false
+	user.PhoneVerifiedAt = nil
+	user.Password = ""
+	user.ProfileImage = ""
+	user.DominantColor = ""
+	user.Theme = utils.ColorTheme{}
+	user.Locale = ""
+	user.AnonymizedAt = &now
+	if err := h.repo.Anonymize(ctx, user); err != nil {
+		user.AnonymizedAt = nil
+		return false, err
+	}
+
+	h.cache.Del(ctx, userLocaleKey(user.UUID))
+	metrics.AccountDeletions.WithLabelValues("anonymized").Inc()
+	// Nilai lama disamarkan agar data pribadi tidak tersisa di audit log yang tidak bisa dihapus.
+	// Worker dan `purge-deleted` sama-sama dicatat sebagai system: anonimisasi terjadi karena jadwal.
+	changes := diffSnapshots(before, auditSnapshot(user))
+	for field, change := range changes {
+		if field != "anonymized_at" {
+			changes[field] = domain.AuditChange{Before: redactAudit(change.Before), After: change.After}
+		}
+	}
+	h.audit.record(reqinfo.WithActor(ctx, domain.AuditActorSystem), domain.AuditUserAnonymized, user.UUID, changes, nil)
+	h.cache.Del(ctx, "list_admins")
+	logger.FromContext(ctx).Info("🗑️ Akun dianonimkan", "user_id", user.UUID)
+	return true, nil
+}
+
+// discardPendingRequests menghapus arsip export data yang belum diunduh (termasuk file-nya di storage)
+// dan permintaan ganti email yang belum dikonfirmasi, supaya link di email tidak lagi membuka data
+// akun yang dihapus
+func discardPendingRequests(ctx context.Context, cache domain.CacheRepository, storage utils.Storage, userUUID string) error {
+	if err := discardDataExport(ctx, cache, storage, userUUID); err != nil {
+		return err
+	}
+	id, err := cache.Get(ctx, emailChangePendingKey(userUUID))
+	if err != nil && !errors.Is(err, domain.ErrCacheMiss) {
+		return err
+	}
+	if err == nil {
+		if err := cache.Del(ctx, emailChangeKey(id)); err != nil {
+			return err
+		}
+	}
+	if err := cache.Del(ctx, emailChangePendingKey(userUUID)); err != nil {
+		return err
+	}
+	return cache.Del(ctx, dataExportPendingKey(userUUID))
+}
\ No newline at end of file
@@ -0,0 +1,193 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/pkg/metrics"
+
+)
+
+// SetRole mengganti role user. Aturan kuota admin tetap berlaku, dan sesi lama dicabut
+// karena role ikut tersimpan di token.
+func (u *userUseCase) SetRole(ctx context.Context, identifier string, roleID uint) (*domain.User, error) {
+	user, err := u.findUser(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+	if user.RoleID == roleID {
+		return user, nil
+	}
+
+	role, err := u.repo.FindRoleByID(ctx, roleID)
+	if err != nil {
+		return nil, domain.ErrRoleNotFound
+	}
+
+	if roleID == AdminRoleID {
+		currentCount, err := u.repo.CountByRoleID(ctx, AdminRoleID)
+		if err != nil {
+			return nil, err
+		}
+		if currentCount >= int64(u.settings.MaxAdmins) {
+			return nil, domain.ErrAdminQuotaFull.WithParams(map[string]interface{}{"max": u.settings.MaxAdmins})
+		}
+	}
+
+	before := auditSnapshot(user)
+	// Role ikut di-set karena Save menyimpan association belongs-to
+	user.RoleID = role.ID
+	user.Role = *role
+	if err := u.repo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	u.audit.recordUser(ctx, domain.AuditRoleChanged, before, user, map[string]string{"role": role.Name})
+
+	u.cache.Del(ctx, "list_admins")
+	if _, err := u.revokeSessions(ctx, user.UUID, "role_changed"); err != nil {
+		return nil, fmt.Errorf("role tersimpan, tetapi sesi lama gagal dicabut: %w", err)
+	}
+	return user, nil
+}
+
+// ResetPassword dipakai admin saat user lupa password; semua sesi user dicabut
+func (u *userUseCase) ResetPassword(ctx context.Context, identifier, newPassword string) (*domain.User, error) {
+	if !domain.ValidPassword(newPassword) {
+		return nil, domain.ErrPasswordWeak
+	}
+	user, err := u.findUser(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := u.hashPassword(ctx, newPassword)
+	if err != nil {
+		return nil, err
+	}
+	before := auditSnapshot(user)
+	now := time.Now()
+	user.Password = hashedPassword
+	user.PasswordChangedAt = &now
+	if err := u.repo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	u.audit.recordUser(ctx, domain.AuditPasswordReset, before, user, nil)
+
+	if _, err := u.revokeSessions(ctx, user.UUID, "password_reset"); err != nil {
+		return nil, fmt.Errorf("password tersimpan, tetapi sesi lama gagal dicabut: %w", err)
+	}
+	return user, nil
+}
+
+// SetDisabled menonaktifkan (login ditolak, sesi dicabut) atau mengaktifkan kembali akun
+func (u *userUseCase) SetDisabled(ctx context.Context, identifier string, disabled bool) (*domain.User, error) {
+	user, err := u.findUser(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	before := auditSnapshot(user)
+	if disabled {
+		now := time.Now()
+		user.DisabledAt = &now
+	} else {
+		user.DisabledAt = nil
+	}
+	if err := u.repo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	action := domain.AuditUserEnabled
+	if disabled {
+		action = domain.AuditUserDisabled
+	}
+	u.audit.recordUser(ctx, action, before, user, nil)
+
+	u.cache.Del(ctx, "list_admins")
+	if disabled {
+		if _, err := u.revokeSessions(ctx, user.UUID, "account_disabled"); err != nil {
+			return nil, fmt.Errorf("akun dinonaktifkan, tetapi sesi lama gagal dicabut: %w", err)
+		}
+	}
+	return user, nil
+}
+
+// findUser mencari user berdasarkan email (jika mengandung @) atau UUID
+func (u *userUseCase) findUser(ctx context.Context, identifier string) (*domain.User, error) {
+	identifier = strings.TrimSpace(identifier)
+	var (
+		user *domain.User
+		err  error
+	)
+	if strings.Contains(identifier, "@") {
+		user, err = u.repo.FindByEmail(ctx, identifier)
+	} else {
+		user, err = u.repo.FindByUUID(ctx, identifier)
+	}
+	if err != nil {
+		return nil, domain.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// sessionVersionTTL lama versi sesi di-cache. revokeSessions langsung memperbarui cache; jika itu
+// gagal, versi lama paling lama bertahan selama ini sebelum dibaca ulang dari database.
+const sessionVersionTTL = time.Minute
+
+func sessionVersionKey(userUUID string) string {
+	return "session_version:" + userUUID
+}
+
+// revokeSessions mencabut semua token user: session_version dinaikkan di database (sumber
+// kebenaran, tahan restart Redis) dan token dengan versi lama ditolak AuthMiddleware.
+// Versi baru dikembalikan agar pemanggil bisa menerbitkan token yang tetap berlaku.
+func (u *userUseCase) revokeSessions(ctx context.Context, userUUID, reason string) (int, error) {
+	version, err := u.repo.RevokeSessions(ctx, userUUID, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	metrics.SessionRevocations.WithLabelValues(reason).Inc()
+	u.audit.record(ctx, domain.AuditSessionsRevoked, userUUID, nil, map[string]string{"reason": reason})
+
+	if err := u.cache.Set(ctx, sessionVersionKey(userUUID), version, sessionVersionTTL); err != nil {
+		u.cache.Del(ctx, sessionVersionKey(userUUID))
+		return version, fmt.Errorf("versi sesi tersimpan, tetapi cache gagal diperbarui: %w", err)
+	}
+	return version, nil
+}
+
+// SessionVersion: error Redis dikembalikan apa adanya (bukan dianggap cache miss) supaya
+// AuthMiddleware menolak request, bukan meloloskan token yang mungkin sudah dicabut
+func (u *userUseCase) SessionVersion(ctx context.Context, userUUID string) (int, error) {
+	cached, err := u.cache.Get(ctx, sessionVersionKey(userUUID))
+	if err == nil {
+		if version, err := strconv.Atoi(cached); err == nil {
+			return version, nil
+		}
+	} else if !errors.Is(err, domain.ErrCacheMiss) {
+		return 0, err
+	}
+
+	user, err := u.repo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return 0, err
+	}
+	// SetNX: revokeSessions yang selesai setelah database dibaca sudah menulis versi baru ke cache,
+	// versi lama hasil baca di atas tidak boleh menimpanya
+	stored, err := u.cache.SetNX(ctx, sessionVersionKey(userUUID), user.SessionVersion, sessionVersionTTL)
+	if err != nil {
+		return 0, err
+	}
+	if !stored {
+		if cached, err := u.cache.Get(ctx, sessionVersionKey(userUUID)); err == nil {
+			if version, err := strconv.Atoi(cached); err == nil && version > user.SessionVersion {
+				return version, nil
+			}
+		}
+	}
+	return user.SessionVersion, nil
+}
\ No newline at end of file
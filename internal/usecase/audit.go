@@ -0,0 +1,207 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/pkg/logger"
+	"khalif-identify/pkg/reqinfo"
+
+)
+
+// Jumlah baris per query saat export & verifikasi membaca seluruh audit log
+const auditScanBatch = 500
+
+// auditPersonalFields data pribadi di changes/details. Audit log tidak bisa dihapus (juga saat akun
+// dihapus), jadi nilainya disimpan sebagai sidik jari HMAC: perubahan tetap terlihat dan nilai yang
+// sudah diketahui bisa dicocokkan, tetapi nilai aslinya tidak bisa dibaca dari audit log.
+var auditPersonalFields = map[string]bool{
+	"name":         true,
+	"email":        true,
+	"phone_number": true,
+	"new_email":    true,
+}
+
+// auditLog menulis kejadian keamanan ke tabel audit_events yang dirantai hash
+type auditLog struct {
+	repo domain.UserRepository
+	key  []byte
+}
+
+func newAuditLog(repo domain.UserRepository, key string) *auditLog {
+	return &auditLog{repo: repo, key: []byte(key)}
+}
+
+// record dipanggil setelah perubahan tersimpan. Gagal menulis audit tidak membatalkan aksi
+// (datanya sudah berubah), tapi dicatat sebagai error agar bisa ditindaklanjuti.
+func (a *auditLog) record(ctx context.Context, action, subjectID string, changes domain.AuditChanges, details map[string]string) {
+	info := reqinfo.FromContext(ctx)
+	actorID := info.ActorID
+	if actorID == "" {
+		actorID = domain.AuditActorAnonymous
+	}
+	for field, change := range changes {
+		if auditPersonalFields[field] {
+			changes[field] = domain.AuditChange{Before: a.fingerprint(change.Before), After: a.fingerprint(change.After)}
+		}
+	}
+	for field, value := range details {
+		if auditPersonalFields[field] {
+			details[field] = a.fingerprint(value)
+		}
+	}
+	event := &domain.AuditEvent{
+		ActorID:   actorID,
+		SubjectID: subjectID,
+		Action:    action,
+		IP:        info.IP,
+		UserAgent: info.UserAgent,
+		RequestID: info.RequestID,
+		Changes:   changes,
+		Details:   details,
+		// Presisi mikrodetik = presisi timestamptz, jadi hash tetap cocok setelah dibaca ulang
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	err := a.repo.AppendAudit(ctx, event, func(prevHash string) {
+		event.PrevHash = prevHash
+		event.Hash = event.ComputeHash(a.key)
+	})
+	if err != nil {
+		logger.FromContext(ctx).Error("❌ Gagal menulis audit log", "action", action, "subject_id", subjectID, "error", err)
+	}
+}
+
+// recordUser mencatat aksi terhadap user beserta field yang berubah (before nil = user baru)
+func (a *auditLog) recordUser(ctx context.Context, action string, before map[string]string, after *domain.User, details map[string]string) {
+	a.record(ctx, action, after.UUID, diffSnapshots(before, auditSnapshot(after)), details)
+}
+
+// auditSnapshot field user yang relevan untuk audit. Diambil sebelum presentUser
+// (yang mengganti URL foto dengan signed URL).
+func auditSnapshot(user *domain.User) map[string]string {
+	return map[string]string{
+		"name":                  user.Name,
+		"email":                 user.Email,
+		"phone_number":          user.PhoneNumber,
+		"phone_region":          user.PhoneRegion,
+		"phone_verified":        strconv.FormatBool(user.PhoneVerified),
+		"password":              user.Password,
+		"role_id":               strconv.FormatUint(uint64(user.RoleID), 10),
+		"locale":                user.Locale,
+		"profile_image":         user.ProfileImage,
+		"disabled_at":           auditTime(user.DisabledAt),
+		"deletion_scheduled_at": auditTime(user.DeletionScheduledAt),
+		"anonymized_at":         auditTime(user.AnonymizedAt),
+	}
+}
+
+// diffSnapshots hanya menyimpan field yang berubah; nilai field rahasia tidak pernah ikut
+func diffSnapshots(before, after map[string]string) domain.AuditChanges {
+	changes := domain.AuditChanges{}
+	for field, value := range after {
+		old := before[field]
+		if old == value {
+			continue
+		}
+		if logger.IsSecretKey(field) {
+			old, value = redactAudit(old), redactAudit(value)
+		}
+		changes[field] = domain.AuditChange{Before: old, After: value}
+	}
+	return changes
+}
+
+// redactAudit: kosong tetap kosong agar terlihat field baru diisi atau dikosongkan
+func redactAudit(value string) string {
+	if value == "" {
+		return ""
+	}
+	return logger.Redacted
+}
+
+// fingerprint = "hmac:" + HMAC-SHA256 terpotong. Kunci diturunkan dari kunci rantai audit agar
+// nilai pendek (nomor HP) tidak bisa ditebak dengan brute force tanpa kunci. Nilai kosong dan
+// yang sudah disamarkan (anonimisasi) dibiarkan.
+func (a *auditLog) fingerprint(value string) string {
+	if value == "" || value == logger.Redacted {
+		return value
+	}
+	mac := hmac.New(sha256.New, append([]byte("audit_personal:"), a.key...))
+	mac.Write([]byte(value))
+	return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// tokenFingerprint menandai token yang dicabut tanpa menyimpan token aslinya (masih berlaku sampai exp)
+func tokenFingerprint(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:8])
+}
+
+func auditTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func (u *userUseCase) GetAuditEvents(ctx context.Context, filter domain.AuditFilter, page, limit int) ([]domain.AuditEvent, int64, error) {
+	return u.repo.FindAudit(ctx, filter, page, limit)
+}
+
+// ExportAuditEvents menulis audit log yang cocok dengan filter sebagai NDJSON (satu event per baris,
+// urut ID naik, lengkap dengan hash) tanpa memuat semuanya ke memory
+func (u *userUseCase) ExportAuditEvents(ctx context.Context, filter domain.AuditFilter, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	var afterID uint64
+	for {
+		events, err := u.repo.ScanAudit(ctx, filter, afterID, auditScanBatch)
+		if err != nil {
+			return err
+		}
+		for i := range events {
+			if err := encoder.Encode(&events[i]); err != nil {
+				return err
+			}
+		}
+		if len(events) < auditScanBatch {
+			return nil
+		}
+		afterID = events[len(events)-1].ID
+	}
+}
+
+// VerifyAuditLog menghitung ulang rantai hash dari baris pertama. Baris yang diubah, disisipkan
+// atau dihapus di tengah membuat rantai putus; BrokenAt menunjuk baris pertama yang tidak cocok.
+func (u *userUseCase) VerifyAuditLog(ctx context.Context) (*domain.AuditVerification, error) {
+	result := &domain.AuditVerification{Valid: true, CheckedAt: time.Now()}
+	prevHash := ""
+	var afterID uint64
+	for {
+		events, err := u.repo.ScanAudit(ctx, domain.AuditFilter{}, afterID, auditScanBatch)
+		if err != nil {
+			return nil, err
+		}
+		for i := range events {
+			event := &events[i]
+			if event.PrevHash != prevHash || event.Hash != event.ComputeHash(u.audit.key) {
+				result.Valid = false
+				result.BrokenAt = event.ID
+				return result, nil
+			}
+			prevHash = event.Hash
+			result.Checked++
+		}
+		if len(events) < auditScanBatch {
+			result.LastHash = prevHash
+			return result, nil
+		}
+		afterID = events[len(events)-1].ID
+	}
+}
\ No newline at end of file
@@ -0,0 +1,378 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/pkg/i18n"
+	"khalif-identify/pkg/logger"
+	"khalif-identify/pkg/mailer"
+	"khalif-identify/pkg/queue"
+	"khalif-identify/pkg/utils"
+
+)
+
+const (
+	JobBuildDataExport  = "data_export.build"
+	JobExpireDataExport = "data_export.expire"
+
+	DataExportTTL = 48 * time.Hour
+
+	// Satu export per user dalam satu waktu; kunci dilepas worker setelah arsip jadi
+	dataExportPendingTTL = time.Hour
+
+	// Path halaman frontend yang menerima token lalu memanggil endpoint unduh
+	DataExportDownloadPath = "/account/export"
+
+	DataExportFilename = "khalif-data-export.zip"
+)
+
+// DataExportPayload isi job pembuatan arsip. Locale dicatat saat request karena worker tidak punya Accept-Language.
+type DataExportPayload struct {
+	UserUUID string `json:"user_uuid"`
+	Locale   string `json:"locale"`
+}
+
+// DataExportExpiryPayload isi job terjadwal yang menghapus arsip dari storage saat link kedaluwarsa
+// (key Redis kedaluwarsa sendiri, file di storage tidak)
+type DataExportExpiryPayload struct {
+	UserUUID string `json:"user_uuid"`
+	Token    string `json:"token"`
+	Stored   string `json:"stored"`
+}
+
+// RequestDataExport menjadwalkan pembuatan arsip data pribadi; hasilnya dikirim lewat email
+func (u *userUseCase) RequestDataExport(ctx context.Context, userUUID string) error {
+	user, err := u.repo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return err
+	}
+
+	ok, err := u.cache.SetNX(ctx, dataExportPendingKey(user.UUID), "1", dataExportPendingTTL)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.ErrDataExportPending
+	}
+
+	payload := DataExportPayload{UserUUID: user.UUID, Locale: i18n.ForUser(ctx, user.Locale)}
+	if err := u.queue.Enqueue(ctx, JobBuildDataExport, payload); err != nil {
+		u.cache.Del(ctx, dataExportPendingKey(user.UUID))
+		return fmt.Errorf("gagal menjadwalkan export data: %w", err)
+	}
+	u.audit.record(ctx, domain.AuditDataExportRequested, user.UUID, nil, nil)
+	return nil
+}
+
+// DownloadDataExport mengambil arsip dengan token dari email (boleh diunduh berulang selama belum kedaluwarsa)
+func (u *userUseCase) DownloadDataExport(ctx context.Context, token string) (*domain.DataExport, error) {
+	if token == "" {
+		return nil, domain.ErrDataExportInvalid
+	}
+	stored, err := u.cache.Get(ctx, dataExportKey(token))
+	if errors.Is(err, domain.ErrCacheMiss) {
+		return nil, domain.ErrDataExportInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	content, err := u.uploader.Open(ctx, stored)
+	if errors.Is(err, utils.ErrNotInStorage) {
+		return nil, domain.ErrDataExportInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca arsip export data: %w", err)
+	}
+	return &domain.DataExport{Filename: DataExportFilename, Content: content}, nil
+}
+
+// DataExportJobHandler menyusun arsip ZIP data pribadi user di background
+type DataExportJobHandler struct {
+	repo     domain.UserRepository
+	cache    domain.CacheRepository
+	queue    domain.JobQueue
+	uploader utils.Storage
+	mailer   mailer.Mailer
+	settings Settings
+}
+
+func NewDataExportJobHandler(repo domain.UserRepository, cache domain.CacheRepository, queue domain.JobQueue, uploader utils.Storage, mail mailer.Mailer, settings Settings) *DataExportJobHandler {
+	return &DataExportJobHandler{repo: repo, cache: cache, queue: queue, uploader: uploader, mailer: mail, settings: settings.withDefaults()}
+}
+
+// exportSession sesi yang mungkin masih aktif: token dari login ini belum kedaluwarsa dan belum dicabut
+type exportSession struct {
+	Method    string    `json:"method"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	StartedAt time.Time `json:"started_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (h *DataExportJobHandler) Handle(ctx context.Context, job *queue.Job) error {
+	var payload DataExportPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return queue.Permanent(fmt.Errorf("payload job tidak valid: %w", err))
+	}
+
+	user, err := h.repo.FindByUUID(ctx, payload.UserUUID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		h.cache.Del(ctx, dataExportPendingKey(payload.UserUUID))
+		return queue.Permanent(err)
+	}
+	if err != nil {
+		return err
+	}
+	// Akun yang sedang menunggu dihapus tidak lagi dibuatkan arsip
+	if user.DeletionScheduledAt != nil || user.AnonymizedAt != nil {
+		h.cache.Del(ctx, dataExportPendingKey(user.UUID))
+		return nil
+	}
+
+	archive, err := h.buildArchive(ctx, user, payload.Locale)
+	if err != nil {
+		return err
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+	// Arsip disimpan di storage dengan nama acak (bukan token); Redis hanya memetakan token -> lokasi
+	stored, err := h.uploader.UploadFile(ctx, bytes.NewReader(archive), "data-export-"+uuid.New().String()+".zip")
+	if err != nil {
+		return fmt.Errorf("gagal menyimpan arsip export data: %w", err)
+	}
+	export := DataExportExpiryPayload{UserUUID: user.UUID, Token: token, Stored: stored}
+	ttl := h.settings.DataExportTTL
+	if err := h.queue.EnqueueAt(ctx, JobExpireDataExport, export, time.Now().Add(ttl)); err != nil {
+		h.discard(ctx, export)
+		return fmt.Errorf("gagal menjadwalkan penghapusan arsip export data: %w", err)
+	}
+
+	// Arsip baru menggantikan arsip sebelumnya; penunjuk per user dipakai saat akun dihapus
+	if err := discardDataExport(ctx, h.cache, h.uploader, user.UUID); err != nil {
+		h.discard(ctx, export)
+		return err
+	}
+	if err := h.cache.Set(ctx, dataExportKey(token), stored, ttl); err != nil {
+		h.discard(ctx, export)
+		return err
+	}
+	if err := h.cache.Set(ctx, dataExportUserKey(user.UUID), token, ttl); err != nil {
+		h.discard(ctx, export)
+		return err
+	}
+
+	link := strings.TrimRight(h.settings.AppBaseURL, "/") + DataExportDownloadPath + "?token=" + url.QueryEscape(token)
+	body := i18n.T(payload.Locale, "email.data_export_ready.body", i18n.Params{
+		"name":  user.Name,
+		"link":  link,
+		"hours": int(ttl.Hours()),
+	})
+	if err := h.mailer.Send(ctx, user.Email, i18n.T(payload.Locale, "email.data_export_ready.subject", nil), body); err != nil {
+		h.discard(ctx, export)
+		return fmt.Errorf("gagal mengirim email export data: %w", err)
+	}
+
+	h.cache.Del(ctx, dataExportPendingKey(user.UUID))
+	return nil
+}
+
+// Expire menghapus arsip yang link-nya sudah kedaluwarsa. Arsip yang sudah diganti atau dibuang
+// lebih dulu (akun dihapus) tidak lagi ada di storage, job tetap dianggap selesai.
+func (h *DataExportJobHandler) Expire(ctx context.Context, job *queue.Job) error {
+	var payload DataExportExpiryPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return queue.Permanent(fmt.Errorf("payload job tidak valid: %w", err))
+	}
+	return h.discard(ctx, payload)
+}
+
+// discard menghapus satu arsip beserta key Redis-nya. Penunjuk per user hanya dihapus jika masih
+// menunjuk arsip ini, agar arsip yang lebih baru tetap bisa diunduh.
+func (h *DataExportJobHandler) discard(ctx context.Context, export DataExportExpiryPayload) error {
+	if err := deleteStored(ctx, h.uploader, export.Stored); err != nil {
+		logger.FromContext(ctx).Warn("⚠️ Gagal menghapus arsip export data", "user_id", export.UserUUID, "error", err)
+		return err
+	}
+	if current, err := h.cache.Get(ctx, dataExportUserKey(export.UserUUID)); err == nil && current == export.Token {
+		if err := h.cache.Del(ctx, dataExportUserKey(export.UserUUID)); err != nil {
+			return err
+		}
+	}
+	return h.cache.Del(ctx, dataExportKey(export.Token))
+}
+
+// discardDataExport menghapus arsip user yang masih bisa diunduh (file di storage dan key Redis)
+func discardDataExport(ctx context.Context, cache domain.CacheRepository, storage utils.Storage, userUUID string) error {
+	token, err := cache.Get(ctx, dataExportUserKey(userUUID))
+	if errors.Is(err, domain.ErrCacheMiss) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	stored, err := cache.Get(ctx, dataExportKey(token))
+	if err != nil && !errors.Is(err, domain.ErrCacheMiss) {
+		return err
+	}
+	if err == nil {
+		if err := deleteStored(ctx, storage, stored); err != nil {
+			return err
+		}
+	}
+	if err := cache.Del(ctx, dataExportKey(token)); err != nil {
+		return err
+	}
+	return cache.Del(ctx, dataExportUserKey(userUUID))
+}
+
+// deleteStored menghapus file di storage; file yang sudah tidak ada dianggap berhasil
+func deleteStored(ctx context.Context, storage utils.Storage, stored string) error {
+	if err := storage.Delete(ctx, stored); err != nil && !errors.Is(err, utils.ErrNotInStorage) {
+		return err
+	}
+	return nil
+}
+
+// buildArchive menyusun isi ZIP. Password (hash) tidak pernah ikut karena bertag json:"-".
+func (h *DataExportJobHandler) buildArchive(ctx context.Context, user *domain.User, locale string) ([]byte, error) {
+	logins, err := h.repo.FindLogins(ctx, user.ID, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := h.activeSessions(ctx, user, logins)
+	if err != nil {
+		return nil, err
+	}
+	if logins == nil {
+		logins = []domain.LoginEvent{}
+	}
+
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"user.json", user},
+		{"role.json", user.Role},
+		{"sessions.json", sessions},
+		{"login_history.json", logins},
+		// Layanan ini tidak menyimpan data persetujuan (consent); file tetap ada agar struktur arsip konsisten
+		{"consents.json", map[string]interface{}{
+			"consents": []interface{}{},
+			"note":     i18n.T(locale, "export.no_consents", nil),
+		}},
+	}
+	for _, f := range files {
+		data, err := json.MarshalIndent(f.content, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if err := writeZipFile(zw, f.name, data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := h.addProfileImage(ctx, zw, user.ProfileImage); err != nil {
+		return nil, err
+	}
+
+	readme := i18n.T(locale, "export.readme", i18n.Params{
+		"name":         user.Name,
+		"generated_at": time.Now().UTC().Format(time.RFC3339),
+	})
+	if err := writeZipFile(zw, "README.txt", []byte(readme)); err != nil {
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// activeSessions: token stateless, jadi sesi diturunkan dari riwayat login yang tokennya
+// belum kedaluwarsa dan terbit setelah pencabutan sesi atau ganti password terakhir
+func (h *DataExportJobHandler) activeSessions(ctx context.Context, user *domain.User, logins []domain.LoginEvent) ([]exportSession, error) {
+	since := time.Now().Add(-h.settings.AccessTokenTTL)
+	for _, cutoff := range []*time.Time{user.PasswordChangedAt, user.SessionsRevokedAt} {
+		if cutoff != nil && cutoff.After(since) {
+			since = *cutoff
+		}
+	}
+
+	sessions := []exportSession{}
+	for _, login := range logins {
+		if login.CreatedAt.Before(since) {
+			continue
+		}
+		sessions = append(sessions, exportSession{
+			Method:    login.Method,
+			IP:        login.IP,
+			UserAgent: login.UserAgent,
+			StartedAt: login.CreatedAt,
+			ExpiresAt: login.CreatedAt.Add(h.settings.AccessTokenTTL),
+		})
+	}
+	return sessions, nil
+}
+
+// addProfileImage menyalin file foto profil dari storage; avatar dari layanan luar cukup tercatat URL-nya di user.json
+func (h *DataExportJobHandler) addProfileImage(ctx context.Context, zw *zip.Writer, stored string) error {
+	if stored == "" {
+		return nil
+	}
+	file, err := h.uploader.Open(ctx, stored)
+	if errors.Is(err, utils.ErrNotInStorage) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("gagal membaca foto profil: %w", err)
+	}
+	defer file.Close()
+
+	name, _, _ := strings.Cut(path.Base(stored), "?")
+	w, err := zw.Create("profile_image/" + name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, file)
+	return err
+}
+
+func writeZipFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func dataExportKey(token string) string {
+	return "data_export:" + token
+}
+
+func dataExportUserKey(userUUID string) string {
+	return "data_export_user:" + userUUID
+}
+
+func dataExportPendingKey(userUUID string) string {
+	return "data_export_pending:" + userUUID
+}
\ No newline at end of file
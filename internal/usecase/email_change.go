@@ -0,0 +1,200 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/pkg/i18n"
+	"khalif-identify/pkg/logger"
+
+)
+
+const (
+	EmailChangeTTL = 24 * time.Hour
+
+	// Path halaman frontend untuk link konfirmasi (ke email baru) dan pembatalan (ke email lama)
+	EmailChangeConfirmPath = "/account/email/confirm"
+	EmailChangeCancelPath  = "/account/email/cancel"
+)
+
+// emailChange disimpan di Redis selama menunggu konfirmasi
+type emailChange struct {
+	UserUUID       string `json:"user_uuid"`
+	OldEmail       string `json:"old_email"`
+	NewEmail       string `json:"new_email"`
+	RevokeSessions bool   `json:"revoke_sessions"`
+}
+
+// RequestEmailChange memulai penggantian email. Email baru baru dipakai setelah link
+// konfirmasi di email baru dibuka; email lama mendapat pemberitahuan + link pembatalan.
+// Permintaan baru menggantikan permintaan sebelumnya yang belum dikonfirmasi.
+func (u *userUseCase) RequestEmailChange(ctx context.Context, userUUID, newEmail, currentPassword string, revokeSessions bool) (*domain.EmailChangeChallenge, error) {
+	user, err := u.repo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	if !u.checkPassword(ctx, currentPassword, user.Password) {
+		return nil, domain.ErrCurrentPasswordInvalid
+	}
+
+	newEmail = domain.NormalizeEmail(newEmail)
+	if newEmail == domain.NormalizeEmail(user.Email) {
+		return nil, domain.ErrEmailUnchanged
+	}
+	if err := u.ensureEmailAvailable(ctx, newEmail); err != nil {
+		return nil, err
+	}
+
+	id, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(emailChange{
+		UserUUID:       user.UUID,
+		OldEmail:       user.Email,
+		NewEmail:       newEmail,
+		RevokeSessions: revokeSessions,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := u.settings.EmailChangeTTL
+	if previous, err := u.cache.Get(ctx, emailChangePendingKey(user.UUID)); err == nil {
+		u.cache.Del(ctx, emailChangeKey(previous))
+	}
+	if err := u.cache.Set(ctx, emailChangeKey(id), string(payload), ttl); err != nil {
+		return nil, err
+	}
+	if err := u.cache.Set(ctx, emailChangePendingKey(user.UUID), id, ttl); err != nil {
+		return nil, err
+	}
+
+	if err := u.sendEmailChangeMails(ctx, user, newEmail, id); err != nil {
+		u.cache.Del(ctx, emailChangeKey(id))
+		return nil, err
+	}
+	u.audit.record(ctx, domain.AuditEmailChangeRequested, user.UUID, nil, map[string]string{
+		"new_email":       newEmail,
+		"revoke_sessions": strconv.FormatBool(revokeSessions),
+	})
+
+	return &domain.EmailChangeChallenge{Email: newEmail, ExpiresIn: int(ttl.Seconds())}, nil
+}
+
+// ConfirmEmailChange menerapkan email baru (token sekali pakai dari email baru)
+func (u *userUseCase) ConfirmEmailChange(ctx context.Context, token string) (*domain.User, error) {
+	change, err := u.takeEmailChange(ctx, token, "confirm")
+	if err != nil {
+		return nil, err
+	}
+
+	// Swap atomik: gagal jika email sudah berubah sejak permintaan dibuat,
+	// atau email baru keburu dipakai akun lain (ErrEmailTaken dari unique index)
+	if err := u.repo.UpdateEmail(ctx, change.UserUUID, change.OldEmail, change.NewEmail); err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil, domain.ErrEmailChangeInvalid
+		}
+		return nil, err
+	}
+	u.audit.record(ctx, domain.AuditEmailChanged, change.UserUUID, domain.AuditChanges{
+		"email": {Before: change.OldEmail, After: change.NewEmail},
+	}, nil)
+
+	if change.RevokeSessions {
+		if _, err := u.revokeSessions(ctx, change.UserUUID, "email_changed"); err != nil {
+			logger.FromContext(ctx).Warn("⚠️ Gagal mencabut sesi setelah ganti email", "user_id", change.UserUUID, "error", err)
+		}
+	}
+	u.cache.Del(ctx, "list_admins")
+
+	user, err := u.repo.FindByUUID(ctx, change.UserUUID)
+	if err != nil {
+		return nil, err
+	}
+	u.presentUser(user)
+	return user, nil
+}
+
+// CancelEmailChange membatalkan permintaan yang belum dikonfirmasi (link di email lama)
+func (u *userUseCase) CancelEmailChange(ctx context.Context, token string) error {
+	change, err := u.takeEmailChange(ctx, token, "cancel")
+	if err != nil {
+		return err
+	}
+	u.audit.record(ctx, domain.AuditEmailChangeCancelled, change.UserUUID, nil, map[string]string{"new_email": change.NewEmail})
+	return nil
+}
+
+// takeEmailChange memverifikasi token lalu mengambil sekaligus menghapus permintaannya (GetDel),
+// jadi konfirmasi dan pembatalan yang bersamaan hanya salah satu yang berhasil
+func (u *userUseCase) takeEmailChange(ctx context.Context, token, purpose string) (*emailChange, error) {
+	id, signature, ok := strings.Cut(token, ".")
+	if !ok || id == "" || !hmac.Equal([]byte(signature), []byte(u.signEmailChange(id, purpose))) {
+		return nil, domain.ErrEmailChangeInvalid
+	}
+
+	raw, err := u.cache.GetDel(ctx, emailChangeKey(id))
+	if errors.Is(err, domain.ErrCacheMiss) {
+		return nil, domain.ErrEmailChangeInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var change emailChange
+	if err := json.Unmarshal([]byte(raw), &change); err != nil {
+		return nil, fmt.Errorf("data ganti email rusak: %w", err)
+	}
+	u.cache.Del(ctx, emailChangePendingKey(change.UserUUID))
+	return &change, nil
+}
+
+func (u *userUseCase) sendEmailChangeMails(ctx context.Context, user *domain.User, newEmail, id string) error {
+	baseURL := strings.TrimRight(u.settings.AppBaseURL, "/")
+	confirmLink := baseURL + EmailChangeConfirmPath + "?token=" + url.QueryEscape(id+"."+u.signEmailChange(id, "confirm"))
+	cancelLink := baseURL + EmailChangeCancelPath + "?token=" + url.QueryEscape(id+"."+u.signEmailChange(id, "cancel"))
+
+	locale := i18n.ForUser(ctx, user.Locale)
+	params := i18n.Params{
+		"name":      user.Name,
+		"old_email": user.Email,
+		"new_email": newEmail,
+		"hours":     int(u.settings.EmailChangeTTL.Hours()),
+	}
+
+	params["link"] = confirmLink
+	if err := u.mailer.Send(ctx, newEmail, i18n.T(locale, "email.email_change_confirm.subject", nil), i18n.T(locale, "email.email_change_confirm.body", params)); err != nil {
+		return fmt.Errorf("gagal mengirim email konfirmasi: %w", err)
+	}
+	params["link"] = cancelLink
+	if err := u.mailer.Send(ctx, user.Email, i18n.T(locale, "email.email_change_notice.subject", nil), i18n.T(locale, "email.email_change_notice.body", params)); err != nil {
+		return fmt.Errorf("gagal mengirim pemberitahuan ke email lama: %w", err)
+	}
+	return nil
+}
+
+// signEmailChange: purpose membedakan link konfirmasi dan pembatalan untuk id yang sama
+func (u *userUseCase) signEmailChange(id, purpose string) string {
+	mac := hmac.New(sha256.New, []byte("email_change:"+u.jwtSecret))
+	mac.Write([]byte(purpose + ":" + id))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func emailChangeKey(id string) string {
+	return "email_change:" + id
+}
+
+func emailChangePendingKey(userUUID string) string {
+	return "email_change_pending:" + userUUID
+}
\ No newline at end of file
@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/pkg/logger"
+	"khalif-identify/pkg/metrics"
+	"khalif-identify/pkg/queue"
+	"khalif-identify/pkg/tracing"
+	"khalif-identify/pkg/utils"
+
+)
+
+const JobProcessProfileImage = "profile_image.process"
+
+// ProfileImagePayload adalah isi job pemrosesan foto profil. Foto sudah di-upload ke storage saat
+// request, payload hanya membawa lokasinya agar job (termasuk di dead-letter) tetap kecil di Redis.
+type ProfileImagePayload struct {
+	UserUUID string `json:"user_uuid"`
+	Stored   string `json:"stored"` // Lokasi foto di storage, belum dipasang ke user sampai job selesai
+}
+
+// ImageJobHandler mengerjakan decode + K-Means di background lalu memasang foto ke user
+type ImageJobHandler struct {
+	repo     domain.UserRepository
+	cache    domain.CacheRepository
+	uploader utils.Storage
+}
+
+func NewImageJobHandler(repo domain.UserRepository, cache domain.CacheRepository, uploader utils.Storage) *ImageJobHandler {
+	return &ImageJobHandler{repo: repo, cache: cache, uploader: uploader}
+}
+
+func (h *ImageJobHandler) Handle(ctx context.Context, job *queue.Job) error {
+	var payload ProfileImagePayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return queue.Permanent(fmt.Errorf("payload job tidak valid: %w", err))
+	}
+
+	// Akun yang menunggu hapus/sudah dianonimkan tidak boleh mendapat foto lagi
+	user, err := h.repo.FindByUUID(ctx, payload.UserUUID)
+	if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+		return err
+	}
+	if err != nil || user.DeletionScheduledAt != nil || user.AnonymizedAt != nil {
+		h.skip(ctx, payload)
+		return nil
+	}
+
+	// 1. Baca foto dari storage (boleh di-retry jika storage sedang bermasalah)
+	file, err := h.uploader.Open(ctx, payload.Stored)
+	if errors.Is(err, utils.ErrNotInStorage) {
+		h.markFailed(ctx, payload.UserUUID)
+		return queue.Permanent(err)
+	}
+	if err != nil {
+		metrics.ImageJobFailures.WithLabelValues("download").Inc()
+		if job.IsLastAttempt() {
+			h.markFailed(ctx, payload.UserUUID)
+		}
+		return fmt.Errorf("gagal membaca foto profil: %w", err)
+	}
+	defer file.Close()
+
+	// 2. Ekstrak tema warna (file rusak tidak akan sembuh dengan retry, file-nya langsung dibuang)
+	_, span := tracing.Start(ctx, "image.extract_theme")
+	theme, err := utils.ExtractColorTheme(file)
+	tracing.End(span, err)
+	if err != nil {
+		metrics.ImageJobFailures.WithLabelValues("decode").Inc()
+		h.discard(ctx, payload.Stored)
+		h.markFailed(ctx, payload.UserUUID)
+		return queue.Permanent(err)
+	}
+
+	// 3. Simpan hasil ke user (bersyarat di repo: akun yang mulai dihapus selama decode dilewati)
+	user.ProfileImage = payload.Stored
+	user.Theme = theme
+	user.DominantColor = theme.Dominant()
+	user.ImageStatus = domain.ImageStatusReady
+	if err := h.repo.UpdateProfileImage(ctx, user); err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			h.skip(ctx, payload)
+			return nil
+		}
+		metrics.ImageJobFailures.WithLabelValues("save").Inc()
+		return err
+	}
+
+	h.cache.Del(ctx, "list_admins")
+	return nil
+}
+
+// skip melewati job untuk akun yang hilang atau sedang dihapus; fotonya ikut dibuang
+func (h *ImageJobHandler) skip(ctx context.Context, payload ProfileImagePayload) {
+	logger.FromContext(ctx).Info("ℹ️ Foto profil dilewati, akun tidak aktif", "user_id", payload.UserUUID)
+	h.discard(ctx, payload.Stored)
+}
+
+// discard menghapus foto yang tidak jadi dipakai; gagal hapus hanya dicatat
+func (h *ImageJobHandler) discard(ctx context.Context, stored string) {
+	if err := h.uploader.Delete(ctx, stored); err != nil && !errors.Is(err, utils.ErrNotInStorage) {
+		logger.FromContext(ctx).Warn("⚠️ Gagal menghapus foto profil yang tidak dipakai", "error", err)
+	}
+}
+
+func (h *ImageJobHandler) markFailed(ctx context.Context, userUUID string) {
+	user, err := h.repo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return
+	}
+	user.ImageStatus = domain.ImageStatusFailed
+	if err := h.repo.UpdateProfileImage(ctx, user); err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+		logger.FromContext(ctx).Warn("⚠️ Gagal menandai foto profil sebagai failed", "user_id", userUUID, "error", err)
+	}
+}
+
+// MaxProfileImageSize batas default ukuran foto profil
+const MaxProfileImageSize = 10 << 20
\ No newline at end of file
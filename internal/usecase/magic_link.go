@@ -0,0 +1,183 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/pkg/i18n"
+	"khalif-identify/pkg/logger"
+	"khalif-identify/pkg/mailer"
+	"khalif-identify/pkg/queue"
+
+)
+
+const (
+	JobSendLoginLink = "login_link.send"
+
+	MagicLinkTTL = 15 * time.Minute
+
+	// Path halaman frontend yang menerima token lalu memanggil endpoint verifikasi
+	MagicLinkPath = "/login/link"
+)
+
+// LoginLinkPayload isi job pengiriman magic link. Locale dicatat saat request karena worker
+// tidak punya Accept-Language; ExpiresAt agar masa berlaku dihitung dari saat link diminta.
+type LoginLinkPayload struct {
+	Email     string    `json:"email"`
+	Token     string    `json:"token"`
+	Locale    string    `json:"locale"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SendLoginLink membuat link login sekali pakai dan menjadwalkan pengirimannya ke email.
+//
+// Token di link berbentuk "<id>.<signature>" dengan signature = HMAC(id + hash nonce),
+// sedangkan nonce hanya dikembalikan ke device yang meminta. Link yang diteruskan ke
+// device lain tidak bisa dipakai karena device itu tidak punya nonce-nya.
+//
+// Request ini tidak mencari user sama sekali: email terdaftar maupun tidak hanya menghasilkan
+// satu job, jadi response dan waktunya sama (tidak bisa dipakai mengecek email). Pencarian user,
+// penyimpanan token dan pengiriman email dikerjakan LoginLinkJobHandler.
+func (u *userUseCase) SendLoginLink(ctx context.Context, email string) (*domain.LoginLinkChallenge, error) {
+	nonce, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	id, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := LoginLinkPayload{
+		Email:     domain.NormalizeEmail(email),
+		Token:     id + "." + u.signMagicLink(id, nonce),
+		Locale:    i18n.FromContext(ctx),
+		ExpiresAt: time.Now().Add(u.settings.MagicLinkTTL),
+	}
+	if err := u.queue.Enqueue(ctx, JobSendLoginLink, payload); err != nil {
+		return nil, fmt.Errorf("gagal menjadwalkan email login: %w", err)
+	}
+
+	return &domain.LoginLinkChallenge{
+		Nonce:     nonce,
+		ExpiresIn: int(u.settings.MagicLinkTTL.Seconds()),
+	}, nil
+}
+
+// LoginWithLink menukar token dari email + nonce device dengan token login biasa
+func (u *userUseCase) LoginWithLink(ctx context.Context, token, nonce string) (string, *domain.User, error) {
+	id, signature, ok := strings.Cut(token, ".")
+	if !ok || id == "" || nonce == "" {
+		return "", nil, domain.ErrLoginLinkInvalid
+	}
+
+	// Cek signature dulu (tanpa menyentuh Redis) supaya link dari device lain tidak ikut menghapus token
+	expected := u.signMagicLink(id, nonce)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return "", nil, domain.ErrLoginLinkInvalid
+	}
+
+	// GetDel = sekali pakai, aman walaupun link diklik dua kali bersamaan
+	userUUID, err := u.cache.GetDel(ctx, magicLinkKey(id))
+	if errors.Is(err, domain.ErrCacheMiss) {
+		return "", nil, domain.ErrLoginLinkInvalid
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	user, err := u.repo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		logger.FromContext(ctx).Warn("⚠️ Magic link untuk user yang tidak ditemukan", "user_id", userUUID, "error", err)
+		return "", nil, domain.ErrLoginLinkInvalid
+	}
+
+	return u.issueToken(ctx, user, "magic_link")
+}
+
+func (u *userUseCase) signMagicLink(id, nonce string) string {
+	nonceHash := sha256.Sum256([]byte(nonce))
+	mac := hmac.New(sha256.New, []byte("magic:"+u.jwtSecret))
+	mac.Write([]byte(id))
+	mac.Write(nonceHash[:])
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// LoginLinkJobHandler mengirim email magic link di background
+type LoginLinkJobHandler struct {
+	repo     domain.UserRepository
+	cache    domain.CacheRepository
+	mailer   mailer.Mailer
+	settings Settings
+}
+
+func NewLoginLinkJobHandler(repo domain.UserRepository, cache domain.CacheRepository, mail mailer.Mailer, settings Settings) *LoginLinkJobHandler {
+	return &LoginLinkJobHandler{repo: repo, cache: cache, mailer: mail, settings: settings.withDefaults()}
+}
+
+// Handle: email yang tidak terdaftar dan link yang sudah kedaluwarsa selesai tanpa mengirim apa pun
+func (h *LoginLinkJobHandler) Handle(ctx context.Context, job *queue.Job) error {
+	var payload LoginLinkPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return queue.Permanent(fmt.Errorf("payload job tidak valid: %w", err))
+	}
+	id, _, ok := strings.Cut(payload.Token, ".")
+	if !ok || id == "" {
+		return queue.Permanent(errors.New("token magic link tidak valid"))
+	}
+
+	ttl := time.Until(payload.ExpiresAt)
+	if ttl <= 0 {
+		logger.FromContext(ctx).Info("⌛ Magic link kedaluwarsa sebelum terkirim, dilewati")
+		return nil
+	}
+
+	user, err := h.repo.FindByEmail(ctx, payload.Email)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := h.cache.Set(ctx, magicLinkKey(id), user.UUID, ttl); err != nil {
+		return err
+	}
+
+	link := strings.TrimRight(h.settings.AppBaseURL, "/") + MagicLinkPath + "?token=" + url.QueryEscape(payload.Token)
+	locale := i18n.ForUser(i18n.WithLocale(ctx, payload.Locale), user.Locale)
+	subject := i18n.T(locale, "email.login_link.subject", nil)
+	body := i18n.T(locale, "email.login_link.body", i18n.Params{
+		"name":    user.Name,
+		"link":    link,
+		"minutes": int(h.settings.MagicLinkTTL.Minutes()),
+	})
+
+	if err := h.mailer.Send(ctx, user.Email, subject, body); err != nil {
+		h.cache.Del(ctx, magicLinkKey(id))
+		return fmt.Errorf("gagal mengirim email: %w", err)
+	}
+	return nil
+}
+
+func magicLinkKey(id string) string {
+	return "magic_link:" + id
+}
+
+func randomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
\ No newline at end of file
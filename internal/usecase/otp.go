@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"khalif-identify/internal/domain"
+
+)
+
+const (
+	OTPLength          = 6
+	OTPTTL             = 5 * time.Minute // Default, bisa diatur lewat auth.otp_ttl
+	OTPMaxAttempts     = 5
+	OTPResendCooldown  = 60 * time.Second
+	OTPMaxSendsPerHour = 5
+)
+
+// Tujuan OTP dipisah agar kode verifikasi nomor tidak bisa dipakai untuk login (dan sebaliknya)
+const (
+	OTPPurposeVerifyPhone = "verify_phone"
+	OTPPurposeLogin       = "login"
+)
+
+// otpManager menyimpan hash kode OTP di Redis (kode asli tidak pernah disimpan).
+// Key: otp:<purpose>:<subject> (+ :attempts, :cooldown, :sends)
+type otpManager struct {
+	cache  domain.CacheRepository
+	secret []byte
+	ttl    time.Duration
+}
+
+func newOTPManager(cache domain.CacheRepository, secret string, ttl time.Duration) *otpManager {
+	return &otpManager{cache: cache, secret: []byte("otp:" + secret), ttl: ttl}
+}
+
+func (m *otpManager) key(purpose, subject string) string {
+	return "otp:" + purpose + ":" + subject
+}
+
+func (m *otpManager) hash(purpose, subject, code string) string {
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write([]byte(purpose + "|" + subject + "|" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Issue membuat kode baru setelah cek cooldown & batas kirim per jam.
+// Kode asli dikembalikan untuk dikirim lewat SMS.
+func (m *otpManager) Issue(ctx context.Context, purpose, subject string) (string, *domain.OTPChallenge, error) {
+	key := m.key(purpose, subject)
+
+	// 1. Cooldown kirim ulang
+	ok, err := m.cache.SetNX(ctx, key+":cooldown", "1", OTPResendCooldown)
+	if err != nil {
+		return "", nil, err
+	}
+	if !ok {
+		ttl, _ := m.cache.TTL(ctx, key+":cooldown")
+		return "", nil, &domain.OTPCooldownError{RetryAfter: ttl}
+	}
+
+	// 2. Batas jumlah kirim per jam (mencegah SMS pumping)
+	sends, err := m.cache.Incr(ctx, key+":sends", time.Hour)
+	if err != nil {
+		return "", nil, err
+	}
+	if sends > OTPMaxSendsPerHour {
+		ttl, _ := m.cache.TTL(ctx, key+":sends")
+		return "", nil, &domain.OTPCooldownError{RetryAfter: ttl}
+	}
+
+	// 3. Generate & simpan hash kode, reset percobaan
+	code, err := randomDigits(OTPLength)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := m.cache.Set(ctx, key, m.hash(purpose, subject, code), m.ttl); err != nil {
+		return "", nil, err
+	}
+	m.cache.Del(ctx, key+":attempts")
+
+	return code, &domain.OTPChallenge{
+		ExpiresIn: int(m.ttl.Seconds()),
+		ResendIn:  int(OTPResendCooldown.Seconds()),
+	}, nil
+}
+
+// Verify mencocokkan kode. Kode hangus setelah berhasil atau setelah OTPMaxAttempts kali salah.
+func (m *otpManager) Verify(ctx context.Context, purpose, subject, code string) error {
+	key := m.key(purpose, subject)
+
+	stored, err := m.cache.Get(ctx, key)
+	if errors.Is(err, domain.ErrCacheMiss) {
+		return domain.ErrOTPExpired
+	}
+	if err != nil {
+		return err
+	}
+
+	attempts, err := m.cache.Incr(ctx, key+":attempts", m.ttl)
+	if err != nil {
+		return err
+	}
+	if attempts > OTPMaxAttempts {
+		m.cache.Del(ctx, key)
+		return domain.ErrOTPTooManyAttempts
+	}
+
+	if !hmac.Equal([]byte(stored), []byte(m.hash(purpose, subject, code))) {
+		return domain.ErrOTPInvalid
+	}
+
+	m.cache.Del(ctx, key)
+	m.cache.Del(ctx, key+":attempts")
+	return nil
+}
+
+func randomDigits(n int) (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+	v, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", n, v), nil
+}
+
+// maskPhone menyamarkan nomor untuk ditampilkan: +6281234567890 -> +62812****7890
+func maskPhone(phone string) string {
+	if len(phone) <= 8 {
+		return phone
+	}
+	return phone[:len(phone)-8] + "****" + phone[len(phone)-4:]
+}
\ No newline at end of file
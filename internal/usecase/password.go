@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/pkg/i18n"
+	"khalif-identify/pkg/logger"
+
+)
+
+// ChangePassword mengganti password setelah password saat ini dicek ulang.
+// Semua token yang terbit sebelumnya dicabut (session_version naik), lalu sesi yang
+// meminta mendapat token baru dengan versi terbaru supaya tidak ikut ter-logout.
+func (u *userUseCase) ChangePassword(ctx context.Context, userUUID, currentPassword, newPassword string) (string, *domain.User, error) {
+	user, err := u.repo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return "", nil, err
+	}
+	if !u.checkPassword(ctx, currentPassword, user.Password) {
+		return "", nil, domain.ErrCurrentPasswordInvalid
+	}
+	if currentPassword == newPassword {
+		return "", nil, domain.ErrPasswordUnchanged
+	}
+	if !domain.ValidPassword(newPassword) {
+		return "", nil, domain.ErrPasswordWeak.OnField("new_password")
+	}
+
+	hashedPassword, err := u.hashPassword(ctx, newPassword)
+	if err != nil {
+		return "", nil, err
+	}
+	before := auditSnapshot(user)
+	now := time.Now()
+	user.Password = hashedPassword
+	user.PasswordChangedAt = &now
+	if err := u.repo.Update(ctx, user); err != nil {
+		return "", nil, err
+	}
+	u.audit.recordUser(ctx, domain.AuditPasswordChanged, before, user, nil)
+	// Pemberitahuan dikirim begitu password tersimpan, apa pun hasil pencabutan sesi di bawah
+	u.sendPasswordChangedNotice(ctx, user, now)
+
+	// Versi > 0 berarti pencabutan sudah tersimpan di database dan hanya cache yang gagal
+	// (cache sudah dihapus, AuthMiddleware membaca database), jadi token baru tetap diterbitkan
+	version, err := u.revokeSessions(ctx, user.UUID, "password_changed")
+	if err != nil && version == 0 {
+		logger.FromContext(ctx).Error("❌ Password diganti tetapi sesi lama gagal dicabut", "user_id", user.UUID, "error", err)
+		return "", nil, domain.ErrSessionsNotRevoked
+	}
+	if err != nil {
+		logger.FromContext(ctx).Warn("⚠️ Sesi dicabut, tetapi cache versi sesi gagal diperbarui", "user_id", user.UUID, "error", err)
+	}
+	user.SessionVersion = version
+
+	return u.issueToken(ctx, user, "password_change")
+}
+
+// sendPasswordChangedNotice: gagal kirim tidak membatalkan penggantian password (sudah tersimpan)
+func (u *userUseCase) sendPasswordChangedNotice(ctx context.Context, user *domain.User, changedAt time.Time) {
+	locale := i18n.ForUser(ctx, user.Locale)
+	body := i18n.T(locale, "email.password_changed.body", i18n.Params{
+		"name": user.Name,
+		"time": changedAt.UTC().Format("2006-01-02 15:04 UTC"),
+	})
+	if err := u.mailer.Send(ctx, user.Email, i18n.T(locale, "email.password_changed.subject", nil), body); err != nil {
+		logger.FromContext(ctx).Warn("⚠️ Gagal mengirim pemberitahuan ganti password", "user_id", user.UUID, "error", err)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/pkg/i18n"
+	"khalif-identify/pkg/utils"
+
+)
+
+// LoginWithPhone: nomor (format bebas, dinormalisasi ke E.164) + password
+func (u *userUseCase) LoginWithPhone(ctx context.Context, phone, phoneRegion, password string) (string, *domain.User, error) {
+	formattedPhone, err := utils.FormatPhoneNumber(phone, phoneRegion)
+	if err != nil {
+		return "", nil, domain.ErrInvalidCredentials
+	}
+
+	user, err := u.repo.FindByPhone(ctx, formattedPhone)
+	if err != nil {
+		return "", nil, domain.ErrInvalidCredentials
+	}
+
+	if !u.checkPassword(ctx, password, user.Password) {
+		return "", nil, domain.ErrInvalidCredentials
+	}
+
+	return u.issueToken(ctx, user, "phone")
+}
+
+// SendLoginOTP mengirim kode login via SMS/WhatsApp. Untuk nomor yang tidak terdaftar
+// response tetap sama (tanpa SMS) agar endpoint ini tidak bisa dipakai mengecek nomor.
+func (u *userUseCase) SendLoginOTP(ctx context.Context, phone, phoneRegion string) (*domain.OTPChallenge, error) {
+	formattedPhone, err := utils.FormatPhoneNumber(phone, phoneRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	code, challenge, err := u.otp.Issue(ctx, OTPPurposeLogin, formattedPhone)
+	if err != nil {
+		return nil, err
+	}
+	challenge.Destination = maskPhone(formattedPhone)
+
+	user, err := u.repo.FindByPhone(ctx, formattedPhone)
+	if err != nil {
+		return challenge, nil
+	}
+
+	message := i18n.T(i18n.ForUser(ctx, user.Locale), "sms.login_otp", i18n.Params{"code": code, "minutes": int(u.settings.OTPTTL.Minutes())})
+	if err := u.sms.Send(ctx, formattedPhone, message); err != nil {
+		return nil, fmt.Errorf("gagal mengirim SMS: %w", err)
+	}
+	return challenge, nil
+}
+
+// LoginWithOTP menukar kode OTP dengan token. Berhasil login = nomor terbukti milik user.
+func (u *userUseCase) LoginWithOTP(ctx context.Context, phone, phoneRegion, code string) (string, *domain.User, error) {
+	formattedPhone, err := utils.FormatPhoneNumber(phone, phoneRegion)
+	if err != nil {
+		return "", nil, domain.ErrInvalidCredentials
+	}
+
+	if err := u.otp.Verify(ctx, OTPPurposeLogin, formattedPhone, code); err != nil {
+		return "", nil, err
+	}
+
+	user, err := u.repo.FindByPhone(ctx, formattedPhone)
+	if err != nil {
+		return "", nil, domain.ErrInvalidCredentials
+	}
+
+	if !user.PhoneVerified {
+		now := time.Now()
+		user.PhoneVerified = true
+		user.PhoneVerifiedAt = &now
+		if err := u.repo.Update(ctx, user); err != nil {
+			return "", nil, err
+		}
+	}
+
+	return u.issueToken(ctx, user, "otp")
+}
\ No newline at end of file
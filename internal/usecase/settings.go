@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"time"
+
+	"khalif-identify/pkg/utils"
+
+)
+
+// Settings adalah knob usecase yang bisa diatur lewat konfigurasi.
+// Field yang kosong (zero value) memakai default di bawah.
+type Settings struct {
+	AppBaseURL          string // URL frontend untuk link di email
+	AccessTokenTTL      time.Duration
+	MagicLinkTTL        time.Duration
+	EmailChangeTTL      time.Duration
+	DataExportTTL       time.Duration
+	DeletionGracePeriod time.Duration
+	OTPTTL              time.Duration
+	MaxAdmins           int
+	MaxProfileImageSize int64
+	BcryptCost          int
+	AuditKey            string // Kunci HMAC rantai audit log (fallback ke JWT secret diatur ProvideUseCaseSettings)
+}
+
+// DefaultSettings nilai bawaan, sama dengan perilaku sebelum konfigurasi bisa diatur
+func DefaultSettings() Settings {
+	return Settings{
+		AccessTokenTTL:      utils.TokenTTL,
+		MagicLinkTTL:        MagicLinkTTL,
+		EmailChangeTTL:      EmailChangeTTL,
+		DataExportTTL:       DataExportTTL,
+		DeletionGracePeriod: 30 * 24 * time.Hour, // Ikut privacy.deletion_grace_period di konfigurasi
+		OTPTTL:              OTPTTL,
+		MaxAdmins:           MaxAdminCount,
+		MaxProfileImageSize: MaxProfileImageSize,
+		BcryptCost:          utils.DefaultBcryptCost,
+	}
+}
+
+func (s Settings) withDefaults() Settings {
+	d := DefaultSettings()
+	if s.AccessTokenTTL <= 0 {
+		s.AccessTokenTTL = d.AccessTokenTTL
+	}
+	if s.MagicLinkTTL <= 0 {
+		s.MagicLinkTTL = d.MagicLinkTTL
+	}
+	if s.EmailChangeTTL <= 0 {
+		s.EmailChangeTTL = d.EmailChangeTTL
+	}
+	if s.DataExportTTL <= 0 {
+		s.DataExportTTL = d.DataExportTTL
+	}
+	if s.DeletionGracePeriod <= 0 {
+		s.DeletionGracePeriod = d.DeletionGracePeriod
+	}
+	if s.OTPTTL <= 0 {
+		s.OTPTTL = d.OTPTTL
+	}
+	if s.MaxAdmins <= 0 {
+		s.MaxAdmins = d.MaxAdmins
+	}
+	if s.MaxProfileImageSize <= 0 {
+		s.MaxProfileImageSize = d.MaxProfileImageSize
+	}
+	if s.BcryptCost == 0 {
+		s.BcryptCost = d.BcryptCost
+	}
+	return s
+}
\ No newline at end of file
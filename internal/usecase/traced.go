@@ -0,0 +1,248 @@
+package usecase
+
+import (
+	"context"
+	"io"
+	"mime/multipart"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/pkg/tracing"
+	"khalif-identify/pkg/utils"
+
+)
+
+// tracedUserUseCase membungkus userUseCase dengan satu span per method ("userUseCase.<Method>").
+// Span bcrypt, GORM, Redis dan storage di dalamnya otomatis menjadi child span ini.
+// Method baru di domain.UserUseCase wajib ditambahkan juga di sini.
+type tracedUserUseCase struct {
+	next domain.UserUseCase
+}
+
+func newTracedUserUseCase(next domain.UserUseCase) domain.UserUseCase {
+	return &tracedUserUseCase{next: next}
+}
+
+func (t *tracedUserUseCase) Register(ctx context.Context, name, email, phone, phoneRegion, password string, file multipart.File, fileHeader *multipart.FileHeader) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.Register", attribute.Bool("profile_image", file != nil))
+	user, err := t.next.Register(ctx, name, email, phone, phoneRegion, password, file, fileHeader)
+	tracing.End(span, err)
+	return user, err
+}
+
+func (t *tracedUserUseCase) RegisterCustomer(ctx context.Context, name, email, phone, phoneRegion, password string, file multipart.File, fileHeader *multipart.FileHeader) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.RegisterCustomer", attribute.Bool("profile_image", file != nil))
+	user, err := t.next.RegisterCustomer(ctx, name, email, phone, phoneRegion, password, file, fileHeader)
+	tracing.End(span, err)
+	return user, err
+}
+
+func (t *tracedUserUseCase) Login(ctx context.Context, email, password string) (string, *domain.User, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.Login")
+	token, user, err := t.next.Login(ctx, email, password)
+	tracing.End(span, err)
+	return token, user, err
+}
+
+func (t *tracedUserUseCase) LoginWithPhone(ctx context.Context, phone, phoneRegion, password string) (string, *domain.User, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.LoginWithPhone")
+	token, user, err := t.next.LoginWithPhone(ctx, phone, phoneRegion, password)
+	tracing.End(span, err)
+	return token, user, err
+}
+
+func (t *tracedUserUseCase) SendLoginOTP(ctx context.Context, phone, phoneRegion string) (*domain.OTPChallenge, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.SendLoginOTP")
+	challenge, err := t.next.SendLoginOTP(ctx, phone, phoneRegion)
+	tracing.End(span, err)
+	return challenge, err
+}
+
+func (t *tracedUserUseCase) LoginWithOTP(ctx context.Context, phone, phoneRegion, code string) (string, *domain.User, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.LoginWithOTP")
+	token, user, err := t.next.LoginWithOTP(ctx, phone, phoneRegion, code)
+	tracing.End(span, err)
+	return token, user, err
+}
+
+func (t *tracedUserUseCase) SendLoginLink(ctx context.Context, email string) (*domain.LoginLinkChallenge, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.SendLoginLink")
+	challenge, err := t.next.SendLoginLink(ctx, email)
+	tracing.End(span, err)
+	return challenge, err
+}
+
+func (t *tracedUserUseCase) LoginWithLink(ctx context.Context, token, nonce string) (string, *domain.User, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.LoginWithLink")
+	accessToken, user, err := t.next.LoginWithLink(ctx, token, nonce)
+	tracing.End(span, err)
+	return accessToken, user, err
+}
+
+func (t *tracedUserUseCase) SetRole(ctx context.Context, identifier string, roleID uint) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.SetRole", attribute.Int("role_id", int(roleID)))
+	user, err := t.next.SetRole(ctx, identifier, roleID)
+	tracing.End(span, err)
+	return user, err
+}
+
+func (t *tracedUserUseCase) ResetPassword(ctx context.Context, identifier, newPassword string) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.ResetPassword")
+	user, err := t.next.ResetPassword(ctx, identifier, newPassword)
+	tracing.End(span, err)
+	return user, err
+}
+
+func (t *tracedUserUseCase) SetDisabled(ctx context.Context, identifier string, disabled bool) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.SetDisabled", attribute.Bool("disabled", disabled))
+	user, err := t.next.SetDisabled(ctx, identifier, disabled)
+	tracing.End(span, err)
+	return user, err
+}
+
+func (t *tracedUserUseCase) Logout(ctx context.Context, tokenString string) error {
+	ctx, span := tracing.Start(ctx, "userUseCase.Logout")
+	err := t.next.Logout(ctx, tokenString)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedUserUseCase) UpdateProfile(ctx context.Context, userUUID string, name, phone, phoneRegion, locale string, file multipart.File, fileHeader *multipart.FileHeader) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.UpdateProfile", attribute.String("user.id", userUUID), attribute.Bool("profile_image", file != nil))
+	user, err := t.next.UpdateProfile(ctx, userUUID, name, phone, phoneRegion, locale, file, fileHeader)
+	tracing.End(span, err)
+	return user, err
+}
+
+func (t *tracedUserUseCase) GetAllAdmins(ctx context.Context, page, limit int) ([]domain.User, int64, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.GetAllAdmins", attribute.Int("page", page), attribute.Int("limit", limit))
+	users, total, err := t.next.GetAllAdmins(ctx, page, limit)
+	tracing.End(span, err)
+	return users, total, err
+}
+
+// GetCountryCodes murni in-memory (tanpa ctx), tidak perlu span
+func (t *tracedUserUseCase) GetCountryCodes(acceptLanguage, query, sortBy string) utils.CountryList {
+	return t.next.GetCountryCodes(acceptLanguage, query, sortBy)
+}
+
+func (t *tracedUserUseCase) GetProfile(ctx context.Context, userUUID string) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.GetProfile", attribute.String("user.id", userUUID))
+	user, err := t.next.GetProfile(ctx, userUUID)
+	tracing.End(span, err)
+	return user, err
+}
+
+func (t *tracedUserUseCase) PreferredLocale(ctx context.Context, userUUID string) (string, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.PreferredLocale", attribute.String("user.id", userUUID))
+	locale, err := t.next.PreferredLocale(ctx, userUUID)
+	tracing.End(span, err)
+	return locale, err
+}
+
+func (t *tracedUserUseCase) SessionVersion(ctx context.Context, userUUID string) (int, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.SessionVersion", attribute.String("user.id", userUUID))
+	version, err := t.next.SessionVersion(ctx, userUUID)
+	tracing.End(span, err)
+	return version, err
+}
+
+func (t *tracedUserUseCase) SendPhoneOTP(ctx context.Context, userUUID string) (*domain.OTPChallenge, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.SendPhoneOTP", attribute.String("user.id", userUUID))
+	challenge, err := t.next.SendPhoneOTP(ctx, userUUID)
+	tracing.End(span, err)
+	return challenge, err
+}
+
+func (t *tracedUserUseCase) VerifyPhoneOTP(ctx context.Context, userUUID, code string) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.VerifyPhoneOTP", attribute.String("user.id", userUUID))
+	user, err := t.next.VerifyPhoneOTP(ctx, userUUID, code)
+	tracing.End(span, err)
+	return user, err
+}
+
+func (t *tracedUserUseCase) RequestEmailChange(ctx context.Context, userUUID, newEmail, currentPassword string, revokeSessions bool) (*domain.EmailChangeChallenge, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.RequestEmailChange", attribute.String("user.id", userUUID), attribute.Bool("revoke_sessions", revokeSessions))
+	challenge, err := t.next.RequestEmailChange(ctx, userUUID, newEmail, currentPassword, revokeSessions)
+	tracing.End(span, err)
+	return challenge, err
+}
+
+func (t *tracedUserUseCase) ConfirmEmailChange(ctx context.Context, token string) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.ConfirmEmailChange")
+	user, err := t.next.ConfirmEmailChange(ctx, token)
+	tracing.End(span, err)
+	return user, err
+}
+
+func (t *tracedUserUseCase) CancelEmailChange(ctx context.Context, token string) error {
+	ctx, span := tracing.Start(ctx, "userUseCase.CancelEmailChange")
+	err := t.next.CancelEmailChange(ctx, token)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedUserUseCase) ChangePassword(ctx context.Context, userUUID, currentPassword, newPassword string) (string, *domain.User, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.ChangePassword", attribute.String("user.id", userUUID))
+	token, user, err := t.next.ChangePassword(ctx, userUUID, currentPassword, newPassword)
+	tracing.End(span, err)
+	return token, user, err
+}
+
+func (t *tracedUserUseCase) RequestDataExport(ctx context.Context, userUUID string) error {
+	ctx, span := tracing.Start(ctx, "userUseCase.RequestDataExport", attribute.String("user.id", userUUID))
+	err := t.next.RequestDataExport(ctx, userUUID)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedUserUseCase) DownloadDataExport(ctx context.Context, token string) (*domain.DataExport, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.DownloadDataExport")
+	export, err := t.next.DownloadDataExport(ctx, token)
+	tracing.End(span, err)
+	return export, err
+}
+
+func (t *tracedUserUseCase) RequestAccountDeletion(ctx context.Context, userUUID, currentPassword string) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.RequestAccountDeletion", attribute.String("user.id", userUUID))
+	user, err := t.next.RequestAccountDeletion(ctx, userUUID, currentPassword)
+	tracing.End(span, err)
+	return user, err
+}
+
+func (t *tracedUserUseCase) CancelAccountDeletion(ctx context.Context, token string) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.CancelAccountDeletion")
+	user, err := t.next.CancelAccountDeletion(ctx, token)
+	tracing.End(span, err)
+	return user, err
+}
+
+func (t *tracedUserUseCase) SetPendingDeletion(ctx context.Context, identifier string, pending bool) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.SetPendingDeletion", attribute.Bool("deletion.pending", pending))
+	user, err := t.next.SetPendingDeletion(ctx, identifier, pending)
+	tracing.End(span, err)
+	return user, err
+}
+
+
+func (t *tracedUserUseCase) GetAuditEvents(ctx context.Context, filter domain.AuditFilter, page, limit int) ([]domain.AuditEvent, int64, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.GetAuditEvents", attribute.Int("page", page), attribute.Int("limit", limit))
+	events, total, err := t.next.GetAuditEvents(ctx, filter, page, limit)
+	tracing.End(span, err)
+	return events, total, err
+}
+
+func (t *tracedUserUseCase) ExportAuditEvents(ctx context.Context, filter domain.AuditFilter, w io.Writer) error {
+	ctx, span := tracing.Start(ctx, "userUseCase.ExportAuditEvents")
+	err := t.next.ExportAuditEvents(ctx, filter, w)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedUserUseCase) VerifyAuditLog(ctx context.Context) (*domain.AuditVerification, error) {
+	ctx, span := tracing.Start(ctx, "userUseCase.VerifyAuditLog")
+	result, err := t.next.VerifyAuditLog(ctx)
+	tracing.End(span, err)
+	return result, err
+}
\ No newline at end of file
@@ -1,9 +1,10 @@
 package usecase
 
 import (
+	"bytes"
 	"context"
-	"errors"
 	"fmt"
+	"image"
 	"io"
 	"mime/multipart"
 	"path/filepath"
@@ -11,72 +12,106 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 
 	"khalif-identify/internal/domain"
+	"khalif-identify/pkg/i18n"
+	"khalif-identify/pkg/logger"
+	"khalif-identify/pkg/mailer"
+	"khalif-identify/pkg/metrics"
+	"khalif-identify/pkg/reqinfo"
+	"khalif-identify/pkg/sms"
+	"khalif-identify/pkg/tracing"
 	"khalif-identify/pkg/utils"
 
 )
 
+const (
+	AdminRoleID   = 1
+	MaxAdminCount = 3
+)
+
 type userUseCase struct {
 	repo      domain.UserRepository
 	cache     domain.CacheRepository
-	uploader  *utils.AzureUploader
+	queue     domain.JobQueue
+	uploader  utils.Storage
+	sms       sms.Sender
+	mailer    mailer.Mailer
+	otp       *otpManager
+	audit     *auditLog
+	settings  Settings
 	jwtSecret string
 }
 
-func NewUserUseCase(repo domain.UserRepository, cache domain.CacheRepository, uploader *utils.AzureUploader, jwtSecret string) domain.UserUseCase {
-	return &userUseCase{
+func NewUserUseCase(repo domain.UserRepository, cache domain.CacheRepository, queue domain.JobQueue, uploader utils.Storage, smsSender sms.Sender, mail mailer.Mailer, settings Settings, jwtSecret string) domain.UserUseCase {
+	settings = settings.withDefaults()
+	return newTracedUserUseCase(&userUseCase{
 		repo:      repo,
 		cache:     cache,
+		queue:     queue,
 		uploader:  uploader,
+		sms:       smsSender,
+		mailer:    mail,
+		otp:       newOTPManager(cache, jwtSecret, settings.OTPTTL),
+		audit:     newAuditLog(repo, settings.AuditKey),
+		settings:  settings,
 		jwtSecret: jwtSecret,
-	}
+	})
 }
 
-func (u *userUseCase) GetCountryCodes() []utils.Country {
-	return utils.GetCountryList()
+func (u *userUseCase) GetCountryCodes(acceptLanguage, query, sortBy string) utils.CountryList {
+	return utils.GetCountryList(acceptLanguage, query, sortBy)
 }
 
-func (u *userUseCase) Register(ctx context.Context, name, email, phone, password string, file multipart.File, fileHeader *multipart.FileHeader) (*domain.User, error) {
-	formattedPhone, err := utils.FormatPhoneNumber(phone, "ID")
+func (u *userUseCase) Register(ctx context.Context, name, email, phone, phoneRegion, password string, file multipart.File, fileHeader *multipart.FileHeader) (*domain.User, error) {
+	if !domain.ValidPassword(password) {
+		return nil, domain.ErrPasswordWeak
+	}
+	email = domain.NormalizeEmail(email)
+	phoneRegion = utils.NormalizeRegion(phoneRegion)
+	formattedPhone, err := utils.FormatPhoneNumber(phone, phoneRegion)
 	if err != nil {
 		return nil, err
 	}
+	if err := u.ensurePhoneAvailable(ctx, formattedPhone, ""); err != nil {
+		return nil, err
+	}
+	if err := u.ensureEmailAvailable(ctx, email); err != nil {
+		return nil, err
+	}
 
-	const MaxAdminCount = 3
-	const TargetRoleID = 1
+	const TargetRoleID = AdminRoleID
 	const TargetRoleName = "Admin"
 
 	currentCount, err := u.repo.CountByRoleID(ctx, TargetRoleID)
 	if err != nil {
-		return nil, fmt.Errorf("gagal mengecek kuota admin: %v", err)
+		return nil, fmt.Errorf("gagal mengecek kuota admin: %w", err)
 	}
 
-	if currentCount >= MaxAdminCount {
-		return nil, errors.New("kuota admin sudah penuh (maksimal 3 admin)")
+	if currentCount >= int64(u.settings.MaxAdmins) {
+		return nil, domain.ErrAdminQuotaFull.WithParams(map[string]interface{}{"max": u.settings.MaxAdmins})
 	}
 
-	hashedPassword, err := utils.HashPassword(password)
+	hashedPassword, err := u.hashPassword(ctx, password)
 	if err != nil {
 		return nil, err
 	}
 
-	imgResult, err := utils.HandleProfileImageLogic(file, name, email)
+	imageData, err := readProfileImage(file, fileHeader, u.settings.MaxProfileImageSize)
 	if err != nil {
 		return nil, err
 	}
 
-	finalImageUrl := imgResult.AvatarURL
-
-	if file != nil && fileHeader != nil {
-		ext := filepath.Ext(fileHeader.Filename)
-		filename := uuid.New().String() + ext
+	// Avatar sementara, foto asli diproses oleh worker
+	imgResult, err := utils.HandleProfileImageLogic(nil, name, email)
+	if err != nil {
+		return nil, err
+	}
 
-		uploadedUrl, err := u.uploader.UploadFile(file, filename)
-		if err != nil {
-			return nil, err
-		}
-		finalImageUrl = uploadedUrl
+	imageStatus := domain.ImageStatusReady
+	if imageData != nil {
+		imageStatus = domain.ImageStatusProcessing
 	}
 
 	user := &domain.User{
@@ -84,50 +119,67 @@ func (u *userUseCase) Register(ctx context.Context, name, email, phone, password
 		Name:          name,
 		Email:         email,
 		PhoneNumber:   formattedPhone,
+		PhoneRegion:   phoneRegion,
 		Password:      hashedPassword,
 		RoleID:        TargetRoleID,
-		ProfileImage:  finalImageUrl,
+		ProfileImage:  imgResult.AvatarURL,
 		DominantColor: imgResult.DominantColor,
+		Theme:         imgResult.Theme,
+		ImageStatus:   imageStatus,
 	}
 
 	if err := u.repo.Create(ctx, user); err != nil {
 		return nil, err
 	}
 
+	u.enqueueProfileImage(ctx, user, imageData, fileHeader)
+	u.audit.recordUser(ctx, domain.AuditUserRegistered, nil, user, nil)
+
 	user.Role = domain.Role{ID: TargetRoleID, Name: TargetRoleName}
 	u.cache.Del(ctx, "list_admins")
+	metrics.Registrations.WithLabelValues(TargetRoleName).Inc()
 	return user, nil
 }
 
-func (u *userUseCase) RegisterCustomer(ctx context.Context, name, email, phone, password string, file multipart.File, fileHeader *multipart.FileHeader) (*domain.User, error) {
-	formattedPhone, err := utils.FormatPhoneNumber(phone, "ID")
+func (u *userUseCase) RegisterCustomer(ctx context.Context, name, email, phone, phoneRegion, password string, file multipart.File, fileHeader *multipart.FileHeader) (*domain.User, error) {
+	if !domain.ValidPassword(password) {
+		return nil, domain.ErrPasswordWeak
+	}
+	email = domain.NormalizeEmail(email)
+	phoneRegion = utils.NormalizeRegion(phoneRegion)
+	formattedPhone, err := utils.FormatPhoneNumber(phone, phoneRegion)
 	if err != nil {
 		return nil, err
 	}
+	if err := u.ensurePhoneAvailable(ctx, formattedPhone, ""); err != nil {
+		return nil, err
+	}
+	if err := u.ensureEmailAvailable(ctx, email); err != nil {
+		return nil, err
+	}
 
 	const CustomerRoleID = 3
 	const CustomerRoleName = "Customer"
 
-	hashedPassword, err := utils.HashPassword(password)
+	hashedPassword, err := u.hashPassword(ctx, password)
 	if err != nil {
 		return nil, err
 	}
 
-	imgResult, err := utils.HandleProfileImageLogic(file, name, email)
+	imageData, err := readProfileImage(file, fileHeader, u.settings.MaxProfileImageSize)
 	if err != nil {
 		return nil, err
 	}
 
-	finalImageUrl := imgResult.AvatarURL
+	// Avatar sementara, foto asli diproses oleh worker
+	imgResult, err := utils.HandleProfileImageLogic(nil, name, email)
+	if err != nil {
+		return nil, err
+	}
 
-	if file != nil && fileHeader != nil {
-		ext := filepath.Ext(fileHeader.Filename)
-		filename := uuid.New().String() + ext
-		uploadedUrl, err := u.uploader.UploadFile(file, filename)
-		if err != nil {
-			return nil, err
-		}
-		finalImageUrl = uploadedUrl
+	imageStatus := domain.ImageStatusReady
+	if imageData != nil {
+		imageStatus = domain.ImageStatusProcessing
 	}
 
 	user := &domain.User{
@@ -135,53 +187,81 @@ func (u *userUseCase) RegisterCustomer(ctx context.Context, name, email, phone,
 		Name:          name,
 		Email:         email,
 		PhoneNumber:   formattedPhone,
+		PhoneRegion:   phoneRegion,
 		Password:      hashedPassword,
 		RoleID:        CustomerRoleID,
-		ProfileImage:  finalImageUrl,
+		ProfileImage:  imgResult.AvatarURL,
 		DominantColor: imgResult.DominantColor,
+		Theme:         imgResult.Theme,
+		ImageStatus:   imageStatus,
 	}
 
 	if err := u.repo.Create(ctx, user); err != nil {
 		return nil, err
 	}
 
+	u.enqueueProfileImage(ctx, user, imageData, fileHeader)
+	u.audit.recordUser(ctx, domain.AuditUserRegistered, nil, user, nil)
+
 	user.Role = domain.Role{ID: CustomerRoleID, Name: CustomerRoleName}
+	metrics.Registrations.WithLabelValues(CustomerRoleName).Inc()
 	return user, nil
 }
 
 func (u *userUseCase) Login(ctx context.Context, email, password string) (string, *domain.User, error) {
 	user, err := u.repo.FindByEmail(ctx, email)
 	if err != nil {
-		return "", nil, errors.New("invalid credentials")
+		return "", nil, domain.ErrInvalidCredentials
 	}
 
-	if !utils.CheckPasswordHash(password, user.Password) {
-		return "", nil, errors.New("invalid credentials")
+	if !u.checkPassword(ctx, password, user.Password) {
+		return "", nil, domain.ErrInvalidCredentials
+	}
+
+	return u.issueToken(ctx, user, "email")
+}
+
+// issueToken dipakai semua jalur login (email, nomor HP, OTP) agar token selalu sama bentuknya.
+// Setiap token yang terbit dicatat di riwayat login (method = jalur login).
+func (u *userUseCase) issueToken(ctx context.Context, user *domain.User, method string) (string, *domain.User, error) {
+	if user.DisabledAt != nil {
+		return "", nil, domain.ErrAccountDisabled
+	}
+	if user.DeletionScheduledAt != nil || user.AnonymizedAt != nil {
+		return "", nil, domain.ErrAccountPendingDeletion
 	}
 
 	// PERBAIKAN: Gunakan user.UUID (string), bukan user.ID (uint)
-	token, err := utils.GenerateToken(user.UUID, user.Role.Name, u.jwtSecret)
+	token, err := utils.GenerateToken(user.UUID, user.Role.Name, u.jwtSecret, user.SessionVersion, u.settings.AccessTokenTTL)
 	if err != nil {
 		return "", nil, err
 	}
 
+	// Riwayat login bersifat pelengkap: gagal mencatat tidak menggagalkan login
+	info := reqinfo.FromContext(ctx)
+	event := &domain.LoginEvent{UserID: user.ID, Method: method, IP: info.IP, UserAgent: info.UserAgent, RequestID: info.RequestID}
+	if err := u.repo.RecordLogin(ctx, event); err != nil {
+		logger.FromContext(ctx).Warn("⚠️ Gagal mencatat riwayat login", "user_id", user.UUID, "error", err)
+	}
+
+	u.presentUser(user)
 	return token, user, nil
 }
 
 func (u *userUseCase) Logout(ctx context.Context, tokenString string) error {
 	token, _, err := new(jwt.Parser).ParseUnverified(tokenString, jwt.MapClaims{})
 	if err != nil {
-		return err
+		return domain.ErrTokenInvalid
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return errors.New("invalid token claims")
+		return domain.ErrTokenInvalid
 	}
 
 	expFloat, ok := claims["exp"].(float64)
 	if !ok {
-		return errors.New("invalid expiration in token")
+		return domain.ErrTokenInvalid
 	}
 
 	expirationTime := time.Unix(int64(expFloat), 0)
@@ -191,63 +271,200 @@ func (u *userUseCase) Logout(ctx context.Context, tokenString string) error {
 		return nil
 	}
 
-	return u.cache.Set(ctx, "blacklist:"+tokenString, "revoked", timeRemaining)
+	if err := u.cache.Set(ctx, "blacklist:"+tokenString, "revoked", timeRemaining); err != nil {
+		return err
+	}
+	metrics.Logouts.Inc()
+	userID, _ := claims["user_id"].(string)
+	u.audit.record(ctx, domain.AuditSessionLoggedOut, userID, nil, map[string]string{
+		"token":      tokenFingerprint(tokenString),
+		"expires_at": expirationTime.UTC().Format(time.RFC3339),
+	})
+	return nil
 }
 
 func (u *userUseCase) GetAllAdmins(ctx context.Context, page, limit int) ([]domain.User, int64, error) {
-	return u.repo.FindAll(ctx, page, limit)
+	users, total, err := u.repo.FindAll(ctx, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range users {
+		u.presentUser(&users[i])
+	}
+	return users, total, nil
 }
 
-func (u *userUseCase) UpdateProfile(ctx context.Context, userID uint, name, phone, password string, file multipart.File, fileHeader *multipart.FileHeader) (*domain.User, error) {
-	user, err := u.repo.FindByID(ctx, userID)
+func (u *userUseCase) UpdateProfile(ctx context.Context, userUUID string, name, phone, phoneRegion, locale string, file multipart.File, fileHeader *multipart.FileHeader) (*domain.User, error) {
+	user, err := u.repo.FindByUUID(ctx, userUUID)
 	if err != nil {
-		return nil, errors.New("user not found")
+		return nil, err
 	}
+	before := auditSnapshot(user)
 
 	if name != "" {
 		user.Name = name
 	}
 	if phone != "" {
-		formattedPhone, err := utils.FormatPhoneNumber(phone, "ID")
+		// Negara tidak dikirim -> pakai negara yang tersimpan
+		if phoneRegion == "" {
+			phoneRegion = user.PhoneRegion
+		}
+		phoneRegion = utils.NormalizeRegion(phoneRegion)
+		formattedPhone, err := utils.FormatPhoneNumber(phone, phoneRegion)
 		if err != nil {
 			return nil, err
 		}
-		user.PhoneNumber = formattedPhone
-	}
-	if password != "" {
-		hashedPassword, err := utils.HashPassword(password)
-		if err != nil {
+		if err := u.ensurePhoneAvailable(ctx, formattedPhone, user.UUID); err != nil {
 			return nil, err
 		}
-		user.Password = hashedPassword
+		// Nomor baru harus diverifikasi ulang
+		if formattedPhone != user.PhoneNumber {
+			user.PhoneVerified = false
+			user.PhoneVerifiedAt = nil
+		}
+		user.PhoneNumber = formattedPhone
+		user.PhoneRegion = phoneRegion
 	}
-
-	if file != nil && fileHeader != nil {
-		newColor, err := utils.ExtractDominantColor(file)
-		if err == nil {
-			user.DominantColor = newColor
-		} else {
-			user.DominantColor = "#000000"
+	if locale != "" {
+		normalized, ok := i18n.Normalize(locale)
+		if !ok {
+			return nil, domain.ErrLocaleUnsupported
 		}
+		user.Locale = normalized
+	}
 
-		file.Seek(0, io.SeekStart)
-		ext := filepath.Ext(fileHeader.Filename)
-		filename := uuid.New().String() + ext
-		uploadedUrl, err := u.uploader.UploadFile(file, filename)
-		if err != nil {
-			return nil, err
-		}
-		user.ProfileImage = uploadedUrl
+	imageData, err := readProfileImage(file, fileHeader, u.settings.MaxProfileImageSize)
+	if err != nil {
+		return nil, err
+	}
+	if imageData != nil {
+		user.ImageStatus = domain.ImageStatusProcessing
 	}
 
 	if err := u.repo.Update(ctx, user); err != nil {
 		return nil, err
 	}
 
+	u.enqueueProfileImage(ctx, user, imageData, fileHeader)
+	u.audit.recordUser(ctx, domain.AuditProfileUpdated, before, user, nil)
+
 	u.cache.Del(ctx, "list_admins")
+	u.cache.Del(ctx, userLocaleKey(user.UUID))
+	u.presentUser(user)
 	return user, nil
 }
 
-func (u *userUseCase) GetProfile(ctx context.Context, userID uint) (*domain.User, error) {
-	return u.repo.FindByID(ctx, userID)
+// userLocaleTTL lama bahasa user di-cache; UpdateProfile dan anonimisasi menghapusnya lebih awal
+const userLocaleTTL = time.Hour
+
+func userLocaleKey(userUUID string) string {
+	return "user_locale:" + userUUID
+}
+
+func (u *userUseCase) PreferredLocale(ctx context.Context, userUUID string) (string, error) {
+	if locale, err := u.cache.Get(ctx, userLocaleKey(userUUID)); err == nil {
+		return locale, nil
+	}
+	user, err := u.repo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return "", err
+	}
+	// Bahasa kosong ikut di-cache supaya user tanpa preferensi tidak membaca database tiap request
+	u.cache.Set(ctx, userLocaleKey(userUUID), user.Locale, userLocaleTTL)
+	return user.Locale, nil
+}
+
+func (u *userUseCase) GetProfile(ctx context.Context, userUUID string) (*domain.User, error) {
+	user, err := u.repo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	u.presentUser(user)
+	return user, nil
+}
+
+// ensureEmailAvailable dicek sebelum hash bcrypt (mahal); unique index tetap jadi pengaman terakhir
+func (u *userUseCase) ensureEmailAvailable(ctx context.Context, email string) error {
+	if _, err := u.repo.FindByEmail(ctx, email); err == nil {
+		return domain.ErrEmailTaken
+	}
+	return nil
+}
+
+// ensurePhoneAvailable memastikan nomor belum dipakai user lain (nomor HP dipakai untuk login)
+func (u *userUseCase) ensurePhoneAvailable(ctx context.Context, phone, ownerUUID string) error {
+	existing, err := u.repo.FindByPhone(ctx, phone)
+	if err == nil && existing.UUID != ownerUUID {
+		return domain.ErrPhoneTaken
+	}
+	return nil
+}
+
+// presentUser menyiapkan user untuk response: URL foto di container private
+// diganti dengan signed URL yang kedaluwarsa. Jangan di-Save setelah dipanggil.
+func (u *userUseCase) presentUser(user *domain.User) {
+	user.ProfileImage, user.ProfileImageExpiresAt = u.uploader.ResolveURL(user.ProfileImage)
+}
+
+// readProfileImage membaca foto upload ke memory dan memastikan formatnya gambar.
+// Decode penuh + K-Means dikerjakan worker, di sini cukup cek header (murah).
+func readProfileImage(file multipart.File, fileHeader *multipart.FileHeader, maxSize int64) ([]byte, error) {
+	if file == nil || fileHeader == nil {
+		return nil, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, domain.ErrImageTooLarge.WithParams(map[string]interface{}{"max_mb": maxSize >> 20})
+	}
+	if _, _, err := image.DecodeConfig(bytes.NewReader(data)); err != nil {
+		return nil, domain.ErrImageInvalid
+	}
+	return data, nil
+}
+
+// enqueueProfileImage meng-upload foto ke storage lalu mengirim lokasinya ke antrian (bukan isi
+// file, agar payload di Redis tetap kecil). Gagal upload/enqueue tidak menggagalkan request,
+// user tetap memakai avatar dan status foto ditandai failed.
+func (u *userUseCase) enqueueProfileImage(ctx context.Context, user *domain.User, data []byte, fileHeader *multipart.FileHeader) {
+	if data == nil {
+		return
+	}
+
+	start := time.Now()
+	stored, err := u.uploader.UploadFile(ctx, bytes.NewReader(data), uuid.New().String()+filepath.Ext(fileHeader.Filename))
+	metrics.ImageUploadDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.ImageJobFailures.WithLabelValues("upload").Inc()
+		logger.FromContext(ctx).Warn("⚠️ Gagal upload foto profil", "user_id", user.UUID, "error", err)
+		user.ImageStatus = domain.ImageStatusFailed
+		u.repo.Update(ctx, user)
+		return
+	}
+
+	payload := ProfileImagePayload{UserUUID: user.UUID, Stored: stored}
+	if err := u.queue.Enqueue(ctx, JobProcessProfileImage, payload); err != nil {
+		logger.FromContext(ctx).Warn("⚠️ Gagal enqueue foto profil", "user_id", user.UUID, "error", err)
+		u.uploader.Delete(ctx, stored)
+		user.ImageStatus = domain.ImageStatusFailed
+		u.repo.Update(ctx, user)
+	}
+}
+
+// hashPassword & checkPassword dibungkus span karena bcrypt (cost tinggi) sering jadi bagian paling lambat
+func (u *userUseCase) hashPassword(ctx context.Context, password string) (string, error) {
+	_, span := tracing.Start(ctx, "bcrypt.hash", attribute.Int("bcrypt.cost", u.settings.BcryptCost))
+	hashed, err := utils.HashPasswordWithCost(password, u.settings.BcryptCost)
+	tracing.End(span, err)
+	return hashed, err
+}
+
+func (u *userUseCase) checkPassword(ctx context.Context, password, hash string) bool {
+	_, span := tracing.Start(ctx, "bcrypt.compare")
+	ok := utils.CheckPasswordHash(password, hash)
+	span.End()
+	return ok
 }
\ No newline at end of file
@@ -4,19 +4,22 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"net/url"
 	"strings"
 
-	"gorm.io/gorm"
 	_ "github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/gorm"
 
 )
 
 func EnsureDBExists(dsn string) {
 	dbName := extractDBName(dsn)
-	
-	rootDSN := strings.Replace(dsn, "dbname="+dbName, "dbname=postgres", 1)
+	if dbName == "" {
+		// Tanpa nama database, driver memakai database default user; tidak ada yang perlu dibuat
+		return
+	}
 
-	db, err := sql.Open("pgx", rootDSN)
+	db, err := sql.Open("pgx", maintenanceDSN(dsn, dbName))
 	if err != nil {
 		log.Fatalf("❌ Gagal konek Native SQL: %v", err)
 	}
@@ -57,12 +60,38 @@ func ResetSchema(db *gorm.DB) {
 	fmt.Println("✅ Schema Public berhasil di-reset (Semua tabel bersih).")
 }
 
+// DatabaseName nama database dari DSN (dipakai konfirmasi reset)
+func DatabaseName(dsn string) string {
+	return extractDBName(dsn)
+}
+
+// extractDBName mendukung DSN URL (postgres://.../nama) dan key=value (dbname=nama); kosong jika tidak ada
 func extractDBName(dsn string) string {
-	parts := strings.Split(dsn, " ")
-	for _, part := range parts {
+	if u, ok := parseURLDSN(dsn); ok {
+		return strings.TrimPrefix(u.Path, "/")
+	}
+	for _, part := range strings.Fields(dsn) {
 		if strings.HasPrefix(part, "dbname=") {
-			return strings.TrimPrefix(part, "dbname=")
+			return strings.Trim(strings.TrimPrefix(part, "dbname="), "'")
 		}
 	}
-	return "khalif_db"
+	return ""
+}
+
+// maintenanceDSN DSN yang sama tapi ke database "postgres", untuk CREATE DATABASE
+func maintenanceDSN(dsn, dbName string) string {
+	if u, ok := parseURLDSN(dsn); ok {
+		u.Path = "/postgres"
+		u.RawPath = ""
+		return u.String()
+	}
+	return strings.Replace(dsn, "dbname="+dbName, "dbname=postgres", 1)
+}
+
+func parseURLDSN(dsn string) (*url.URL, bool) {
+	u, err := url.Parse(strings.TrimSpace(dsn))
+	if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+		return nil, false
+	}
+	return u, true
 }
\ No newline at end of file
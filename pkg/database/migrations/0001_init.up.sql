@@ -0,0 +1,59 @@
+-- Skema awal, setara dengan hasil AutoMigrate(&domain.Role{}, &domain.User{}) terakhir.
+-- Ditulis idempotent supaya database lama (dibuat AutoMigrate) bisa langsung diadopsi.
+
+CREATE TABLE IF NOT EXISTS roles (
+    id   BIGSERIAL PRIMARY KEY,
+    name TEXT
+);
+
+CREATE TABLE IF NOT EXISTS users (
+    id         BIGSERIAL PRIMARY KEY,
+    uuid       VARCHAR(36),
+    name       TEXT,
+    email      TEXT,
+    password   TEXT,
+    role_id    BIGINT,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_number      TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_region      VARCHAR(2);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_verified    BOOLEAN DEFAULT false;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_verified_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS profile_image     TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS dominant_color    TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS theme             JSONB;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS image_status      VARCHAR(20) DEFAULT 'ready';
+
+-- Database lama (AutoMigrate) bisa saja punya nomor HP ganda. Tidak jelas akun mana yang berhak,
+-- jadi laporkan semua bentrokan dulu daripada gagal di CREATE UNIQUE INDEX tanpa keterangan.
+DO $$
+DECLARE
+    collisions TEXT;
+BEGIN
+    SELECT string_agg(format('%s -> user id %s', phone_number, ids), '; ' ORDER BY phone_number)
+      INTO collisions
+      FROM (
+          SELECT phone_number, string_agg(id::text, ', ' ORDER BY id) AS ids
+            FROM users
+           WHERE phone_number <> ''
+           GROUP BY phone_number
+          HAVING count(*) > 1
+      ) duplicated;
+
+    IF collisions IS NOT NULL THEN
+        RAISE EXCEPTION 'nomor HP dipakai lebih dari satu akun (ubah/kosongkan nomor akun berikut lalu jalankan ulang migrasi): %', collisions;
+    END IF;
+END $$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_uuid ON users (uuid);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_phone_number ON users (phone_number) WHERE phone_number <> '';
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_users_role') THEN
+        ALTER TABLE users ADD CONSTRAINT fk_users_role FOREIGN KEY (role_id) REFERENCES roles (id);
+    END IF;
+END $$;
@@ -0,0 +1,2 @@
+-- Bahasa pilihan user untuk email/SMS (id | en); kosong = ikut Accept-Language request
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale VARCHAR(8) NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+-- Huruf besar/kecil asli tidak bisa dikembalikan; email tetap dalam bentuk lower-case
+DROP INDEX IF EXISTS idx_users_email_lower;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
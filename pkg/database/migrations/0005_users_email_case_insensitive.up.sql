@@ -0,0 +1,26 @@
+-- Email jadi identitas case-insensitive: disimpan trim + lower-case dan unik berdasarkan lower(email).
+-- Akun yang hanya beda huruf besar/kecil tidak bisa digabung otomatis (tidak jelas mana yang benar),
+-- jadi migrasi berhenti dan melaporkan semua bentrokan untuk dibereskan manual dulu.
+DO $$
+DECLARE
+    collisions TEXT;
+BEGIN
+    SELECT string_agg(format('%s -> user id %s', normalized, ids), '; ' ORDER BY normalized)
+      INTO collisions
+      FROM (
+          SELECT lower(btrim(email)) AS normalized, string_agg(id::text, ', ' ORDER BY id) AS ids
+            FROM users
+           WHERE email IS NOT NULL
+           GROUP BY lower(btrim(email))
+          HAVING count(*) > 1
+      ) duplicated;
+
+    IF collisions IS NOT NULL THEN
+        RAISE EXCEPTION 'email bentrok setelah dinormalisasi (gabungkan/ubah akun berikut lalu jalankan ulang migrasi): %', collisions;
+    END IF;
+END $$;
+
+UPDATE users SET email = lower(btrim(email)) WHERE email <> lower(btrim(email));
+
+DROP INDEX IF EXISTS idx_users_email;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users (lower(email));
@@ -0,0 +1,2 @@
+-- Waktu password terakhir diganti (ganti sendiri atau reset admin); token yang terbit sebelumnya dicabut
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMPTZ;
@@ -0,0 +1,12 @@
+-- Riwayat login yang berhasil (metode, IP, user agent); ikut diekspor di export data pribadi
+CREATE TABLE IF NOT EXISTS login_events (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    method     VARCHAR(20) NOT NULL,
+    ip         VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    request_id TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_events_user_created ON login_events (user_id, created_at DESC);
@@ -0,0 +1,7 @@
+-- Hapus akun: soft delete (login ditolak, bisa dibatalkan) sampai deletion_scheduled_at,
+-- lalu worker mengosongkan data pribadi dan mengisi anonymized_at (baris tetap sebagai tombstone)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deletion_scheduled_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS anonymized_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_users_deletion_scheduled_at ON users (deletion_scheduled_at)
+    WHERE deletion_scheduled_at IS NOT NULL AND anonymized_at IS NULL;
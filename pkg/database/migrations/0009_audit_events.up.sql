@@ -0,0 +1,39 @@
+-- Audit log kejadian keamanan. Append-only: setiap baris memuat hash baris sebelumnya (prev_hash),
+-- dan trigger di bawah menolak UPDATE/DELETE sehingga perubahan hanya mungkin dengan mematikan trigger.
+CREATE TABLE IF NOT EXISTS audit_events (
+    id         BIGSERIAL PRIMARY KEY,
+    actor_id   VARCHAR(64) NOT NULL DEFAULT '',
+    subject_id VARCHAR(36) NOT NULL DEFAULT '',
+    action     VARCHAR(64) NOT NULL,
+    ip         VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    request_id TEXT NOT NULL DEFAULT '',
+    changes    JSONB,
+    details    JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    prev_hash  VARCHAR(64) NOT NULL,
+    hash       VARCHAR(64) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_events_prev_hash ON audit_events (prev_hash);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_events_hash ON audit_events (hash);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor_id ON audit_events (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_subject_id ON audit_events (subject_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_action ON audit_events (action);
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events (created_at);
+
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events bersifat append-only (% ditolak)', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_events_no_update ON audit_events;
+CREATE TRIGGER audit_events_no_update
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+DROP TRIGGER IF EXISTS audit_events_no_truncate ON audit_events;
+CREATE TRIGGER audit_events_no_truncate
+    BEFORE TRUNCATE ON audit_events
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
@@ -0,0 +1,4 @@
+-- Pencabutan sesi yang tahan restart Redis: token membawa versi sesi (claim sv) dan ditolak jika
+-- lebih kecil dari session_version. sessions_revoked_at mencatat kapan versi terakhir dinaikkan.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS session_version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS sessions_revoked_at TIMESTAMPTZ;
@@ -1,25 +1,390 @@
 package database
 
 import (
+	"embed"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
 
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
 	"gorm.io/gorm"
 
 	"khalif-identify/internal/domain"
+	"khalif-identify/pkg/utils"
 
 )
 
-func SeedRoles(db *gorm.DB) {
-	var count int64
-	db.Model(&domain.Role{}).Count(&count)
-	if count == 0 {
-		fmt.Println("🌱 Seeding Roles...")
-		roles := []domain.Role{
-			{ID: 1, Name: "Admin"},
-			{ID: 2, Name: "Editor"},
-			{ID: 3, Name: "User"},
+//go:embed fixtures/*.yaml
+var fixtureFiles embed.FS
+
+// Fixture adalah isi satu file seed (YAML atau JSON). "extends" memuat file lain lebih dulu.
+type Fixture struct {
+	Extends     string              `yaml:"extends" json:"extends"`
+	Permissions []PermissionFixture `yaml:"permissions" json:"permissions"`
+	Roles       []RoleFixture       `yaml:"roles" json:"roles"`
+	Users       []UserFixture       `yaml:"users" json:"users"`
+}
+
+type PermissionFixture struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description" json:"description"`
+}
+
+type RoleFixture struct {
+	ID          uint     `yaml:"id" json:"id"`
+	Name        string   `yaml:"name" json:"name"`
+	Permissions []string `yaml:"permissions" json:"permissions"`
+}
+
+// UserFixture hanya dibuat jika email belum ada; user yang sudah ada tidak pernah ditimpa
+type UserFixture struct {
+	Name     string `yaml:"name" json:"name"`
+	Email    string `yaml:"email" json:"email"`
+	Phone    string `yaml:"phone" json:"phone"`
+	Country  string `yaml:"country" json:"country"`
+	Password string `yaml:"password" json:"password"`
+	RoleID   uint   `yaml:"role_id" json:"role_id"`
+}
+
+type SeedOptions struct {
+	Env         string       // development | staging | production; memilih <env>.yaml
+	FixturesDir string       // Kosong = fixture bawaan yang di-embed
+	DemoUsers   bool         // false = user dari fixture dilewati (hanya roles & permissions)
+	Admin       *UserFixture // Admin default dari env, nil = tidak dibuat
+	BcryptCost  int          // 0 = utils.DefaultBcryptCost
+}
+
+// SeedReport mencatat apa saja yang berubah
+type SeedReport struct {
+	Created      []string
+	Updated      []string
+	Unchanged    int
+	SkippedUsers int // User fixture yang dilewati karena DemoUsers tidak aktif
+}
+
+func (r *SeedReport) String() string {
+	var b strings.Builder
+	if r.SkippedUsers > 0 {
+		fmt.Fprintf(&b, "  - %d user demo dilewati (aktifkan dengan SEED_DEMO_USERS=true)\n", r.SkippedUsers)
+	}
+	if len(r.Created) == 0 && len(r.Updated) == 0 {
+		fmt.Fprintf(&b, "tidak ada perubahan (%d data sudah sesuai)", r.Unchanged)
+		return b.String()
+	}
+	for _, c := range r.Created {
+		fmt.Fprintf(&b, "  + %s\n", c)
+	}
+	for _, u := range r.Updated {
+		fmt.Fprintf(&b, "  ~ %s\n", u)
+	}
+	fmt.Fprintf(&b, "  %d dibuat, %d diubah, %d tetap", len(r.Created), len(r.Updated), r.Unchanged)
+	return b.String()
+}
+
+// Seed menerapkan fixture base + environment secara idempotent (upsert) dalam satu transaksi
+func Seed(db *gorm.DB, opts SeedOptions) (*SeedReport, error) {
+	fixture, err := LoadFixtures(opts.Env, opts.FixturesDir)
+	if err != nil {
+		return nil, err
+	}
+	report := &SeedReport{}
+	// Akun demo hanya dengan opt-in eksplisit, tidak pernah sebagai efek samping nama environment
+	if !opts.DemoUsers {
+		report.SkippedUsers = len(fixture.Users)
+		fixture.Users = nil
+	}
+	if opts.Admin != nil && opts.Admin.Email != "" {
+		fixture.Users = append(fixture.Users, *opts.Admin)
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		permissionIDs, err := seedPermissions(tx, fixture.Permissions, report)
+		if err != nil {
+			return err
+		}
+		if err := seedRoles(tx, fixture.Roles, permissionIDs, report); err != nil {
+			return err
+		}
+		return seedUsers(tx, fixture.Users, opts.BcryptCost, report)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// LoadFixtures membaca base + fixture environment (mengikuti "extends"), file belakangan menimpa data
+// dengan kunci yang sama (nama permission, ID role, email user)
+func LoadFixtures(env, dir string) (*Fixture, error) {
+	var fsys fs.FS
+	if dir != "" {
+		fsys = os.DirFS(dir)
+	} else {
+		sub, err := fs.Sub(fixtureFiles, "fixtures")
+		if err != nil {
+			return nil, err
+		}
+		fsys = sub
+	}
+
+	merged, err := readFixture(fsys, "base", nil)
+	if err != nil {
+		return nil, err
+	}
+	if merged == nil {
+		return nil, fmt.Errorf("fixture base tidak ditemukan")
+	}
+
+	env = strings.ToLower(strings.TrimSpace(env))
+	if env != "" && env != "base" {
+		envFixture, err := readFixture(fsys, env, map[string]bool{"base": true})
+		if err != nil {
+			return nil, err
+		}
+		if envFixture != nil {
+			mergeFixture(merged, envFixture)
+		}
+	}
+	return merged, nil
+}
+
+// readFixture membaca <name>.yaml/.yml/.json; nil tanpa error jika file tidak ada
+func readFixture(fsys fs.FS, name string, seen map[string]bool) (*Fixture, error) {
+	if seen == nil {
+		seen = map[string]bool{}
+	}
+	if seen[name] {
+		return nil, fmt.Errorf("fixture %q di-extends berulang (siklus)", name)
+	}
+	seen[name] = true
+
+	for _, ext := range []string{".yaml", ".yml", ".json"} {
+		data, err := fs.ReadFile(fsys, name+ext)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var f Fixture
+		if path.Ext(name+ext) == ".json" {
+			err = json.Unmarshal(data, &f)
+		} else {
+			err = yaml.Unmarshal(data, &f)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("fixture %s%s tidak valid: %w", name, ext, err)
+		}
+
+		if f.Extends == "" || f.Extends == "base" {
+			return &f, nil
+		}
+		parent, err := readFixture(fsys, f.Extends, seen)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil {
+			return nil, fmt.Errorf("fixture %s meng-extends %q yang tidak ada", name, f.Extends)
+		}
+		mergeFixture(parent, &f)
+		return parent, nil
+	}
+	return nil, nil
+}
+
+func mergeFixture(dst, src *Fixture) {
+	for _, p := range src.Permissions {
+		replaced := false
+		for i := range dst.Permissions {
+			if dst.Permissions[i].Name == p.Name {
+				dst.Permissions[i], replaced = p, true
+			}
+		}
+		if !replaced {
+			dst.Permissions = append(dst.Permissions, p)
+		}
+	}
+	for _, r := range src.Roles {
+		replaced := false
+		for i := range dst.Roles {
+			if dst.Roles[i].ID == r.ID {
+				dst.Roles[i], replaced = r, true
+			}
+		}
+		if !replaced {
+			dst.Roles = append(dst.Roles, r)
+		}
+	}
+	for _, u := range src.Users {
+		replaced := false
+		for i := range dst.Users {
+			if strings.EqualFold(dst.Users[i].Email, u.Email) {
+				dst.Users[i], replaced = u, true
+			}
+		}
+		if !replaced {
+			dst.Users = append(dst.Users, u)
+		}
+	}
+}
+
+func seedPermissions(tx *gorm.DB, permissions []PermissionFixture, report *SeedReport) (map[string]uint, error) {
+	ids := make(map[string]uint, len(permissions))
+	for _, p := range permissions {
+		var existing domain.Permission
+		err := tx.Where("name = ?", p.Name).First(&existing).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			existing = domain.Permission{Name: p.Name, Description: p.Description}
+			if err := tx.Create(&existing).Error; err != nil {
+				return nil, fmt.Errorf("gagal membuat permission %s: %w", p.Name, err)
+			}
+			report.Created = append(report.Created, "permission "+p.Name)
+		case err != nil:
+			return nil, err
+		case existing.Description != p.Description:
+			if err := tx.Model(&existing).Update("description", p.Description).Error; err != nil {
+				return nil, err
+			}
+			report.Updated = append(report.Updated, "permission "+p.Name+" (description)")
+		default:
+			report.Unchanged++
+		}
+		ids[p.Name] = existing.ID
+	}
+	return ids, nil
+}
+
+func seedRoles(tx *gorm.DB, roles []RoleFixture, permissionIDs map[string]uint, report *SeedReport) error {
+	for _, r := range roles {
+		label := fmt.Sprintf("role %d %s", r.ID, r.Name)
+
+		var existing domain.Role
+		err := tx.First(&existing, r.ID).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := tx.Create(&domain.Role{ID: r.ID, Name: r.Name}).Error; err != nil {
+				return fmt.Errorf("gagal membuat %s: %w", label, err)
+			}
+			report.Created = append(report.Created, label)
+		case err != nil:
+			return err
+		case existing.Name != r.Name:
+			if err := tx.Model(&existing).Update("name", r.Name).Error; err != nil {
+				return err
+			}
+			report.Updated = append(report.Updated, fmt.Sprintf("role %d: %s -> %s", r.ID, existing.Name, r.Name))
+		default:
+			report.Unchanged++
+		}
+
+		if err := syncRolePermissions(tx, r, permissionIDs, report); err != nil {
+			return err
+		}
+	}
+
+	// ID role ditulis eksplisit, jadi sequence harus dimajukan agar insert berikutnya tidak bentrok
+	return tx.Exec("SELECT setval(pg_get_serial_sequence('roles', 'id'), (SELECT COALESCE(MAX(id), 1) FROM roles))").Error
+}
+
+// syncRolePermissions membuat isi role_permissions persis sama dengan fixture
+func syncRolePermissions(tx *gorm.DB, r RoleFixture, permissionIDs map[string]uint, report *SeedReport) error {
+	var current []uint
+	if err := tx.Table("role_permissions").Where("role_id = ?", r.ID).Pluck("permission_id", &current).Error; err != nil {
+		return err
+	}
+	has := make(map[uint]bool, len(current))
+	for _, id := range current {
+		has[id] = true
+	}
+
+	want := make(map[uint]bool, len(r.Permissions))
+	for _, name := range r.Permissions {
+		id, ok := permissionIDs[name]
+		if !ok {
+			return fmt.Errorf("role %s memakai permission %q yang tidak ada di fixture", r.Name, name)
+		}
+		want[id] = true
+		if has[id] {
+			continue
+		}
+		if err := tx.Exec("INSERT INTO role_permissions (role_id, permission_id) VALUES (?, ?) ON CONFLICT DO NOTHING", r.ID, id).Error; err != nil {
+			return err
+		}
+		report.Updated = append(report.Updated, fmt.Sprintf("role %s + %s", r.Name, name))
+	}
+
+	var extra []uint
+	for _, id := range current {
+		if !want[id] {
+			extra = append(extra, id)
+		}
+	}
+	if len(extra) > 0 {
+		if err := tx.Exec("DELETE FROM role_permissions WHERE role_id = ? AND permission_id IN ?", r.ID, extra).Error; err != nil {
+			return err
+		}
+		report.Updated = append(report.Updated, fmt.Sprintf("role %s - %d permission", r.Name, len(extra)))
+	}
+	return nil
+}
+
+func seedUsers(tx *gorm.DB, users []UserFixture, bcryptCost int, report *SeedReport) error {
+	if bcryptCost == 0 {
+		bcryptCost = utils.DefaultBcryptCost
+	}
+
+	for _, u := range users {
+		u.Email = domain.NormalizeEmail(u.Email)
+		var count int64
+		if err := tx.Model(&domain.User{}).Where("lower(email) = ?", u.Email).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			report.Unchanged++
+			continue
+		}
+
+		if u.Password == "" {
+			return fmt.Errorf("user seed %s tidak punya password", u.Email)
+		}
+		region := utils.NormalizeRegion(u.Country)
+		phone, err := utils.FormatPhoneNumber(u.Phone, region)
+		if err != nil {
+			return fmt.Errorf("user seed %s: %w", u.Email, err)
+		}
+		hashedPassword, err := utils.HashPasswordWithCost(u.Password, bcryptCost)
+		if err != nil {
+			return err
+		}
+		imgResult, err := utils.HandleProfileImageLogic(nil, u.Name, u.Email)
+		if err != nil {
+			return err
+		}
+
+		user := domain.User{
+			UUID:          uuid.New().String(),
+			Name:          u.Name,
+			Email:         u.Email,
+			PhoneNumber:   phone,
+			PhoneRegion:   region,
+			Password:      hashedPassword,
+			RoleID:        u.RoleID,
+			ProfileImage:  imgResult.AvatarURL,
+			DominantColor: imgResult.DominantColor,
+			Theme:         imgResult.Theme,
+			ImageStatus:   domain.ImageStatusReady,
+		}
+		if err := tx.Omit("Role").Create(&user).Error; err != nil {
+			return fmt.Errorf("gagal membuat user %s: %w", u.Email, err)
 		}
-		db.Create(&roles)
-		fmt.Println("✅ Seeding Selesai.")
+		report.Created = append(report.Created, fmt.Sprintf("user %s (role %d)", u.Email, u.RoleID))
 	}
+	return nil
 }
\ No newline at end of file
@@ -0,0 +1,144 @@
+package i18n
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+
+)
+
+// Katalog pesan per bahasa ada di locales/<bahasa>.yaml (key datar, misal "error.email_taken").
+// Placeholder ditulis {nama} dan diisi dari Params.
+
+//go:embed locales/*.yaml
+var localeFS embed.FS
+
+const (
+	Indonesian = "id"
+	English    = "en"
+
+	// DefaultLocale dipakai jika client tidak mengirim Accept-Language dan user belum memilih bahasa
+	DefaultLocale = Indonesian
+)
+
+// Supported urut sesuai prioritas; yang pertama jadi default matcher
+var Supported = []string{Indonesian, English}
+
+type Params map[string]interface{}
+
+var (
+	loadOnce sync.Once
+	catalogs map[string]map[string]string
+	loadErr  error
+	matcher  = language.NewMatcher([]language.Tag{language.Indonesian, language.English})
+)
+
+func load() {
+	catalogs = map[string]map[string]string{}
+	for _, locale := range Supported {
+		raw, err := localeFS.ReadFile("locales/" + locale + ".yaml")
+		if err != nil {
+			loadErr = err
+			return
+		}
+		messages := map[string]string{}
+		if err := yaml.Unmarshal(raw, &messages); err != nil {
+			loadErr = fmt.Errorf("katalog %s rusak: %w", locale, err)
+			return
+		}
+		catalogs[locale] = messages
+	}
+}
+
+// Keys mengembalikan semua key di katalog satu bahasa (dipakai test kelengkapan terjemahan)
+func Keys(locale string) ([]string, error) {
+	loadOnce.Do(load)
+	if loadErr != nil {
+		return nil, loadErr
+	}
+	keys := make([]string, 0, len(catalogs[locale]))
+	for key := range catalogs[locale] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Match memilih bahasa yang didukung dari header Accept-Language
+func Match(acceptLanguage string) string {
+	tags, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+	_, index, _ := matcher.Match(tags...)
+	return Supported[index]
+}
+
+// Normalize memvalidasi pilihan bahasa user ("en-US" -> "en"); false jika tidak didukung
+func Normalize(locale string) (string, bool) {
+	tag, err := language.Parse(strings.TrimSpace(locale))
+	if err != nil {
+		return "", false
+	}
+	base, _ := tag.Base()
+	for _, supported := range Supported {
+		if base.String() == supported {
+			return supported, true
+		}
+	}
+	return "", false
+}
+
+// Lookup menerjemahkan key; false jika key tidak ada di bahasa tsb maupun di bahasa default
+func Lookup(locale, key string, params Params) (string, bool) {
+	loadOnce.Do(load)
+	message, ok := catalogs[locale][key]
+	if !ok {
+		message, ok = catalogs[DefaultLocale][key]
+	}
+	if !ok {
+		return "", false
+	}
+	if len(params) > 0 {
+		pairs := make([]string, 0, len(params)*2)
+		for name, value := range params {
+			pairs = append(pairs, "{"+name+"}", fmt.Sprint(value))
+		}
+		message = strings.NewReplacer(pairs...).Replace(message)
+	}
+	return message, true
+}
+
+// T seperti Lookup, tapi mengembalikan key itu sendiri jika belum ada terjemahannya
+func T(locale, key string, params Params) string {
+	if message, ok := Lookup(locale, key, params); ok {
+		return message
+	}
+	return key
+}
+
+type contextKey struct{}
+
+// WithLocale menyimpan bahasa request di context (di-set oleh middleware.Locale)
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, contextKey{}, locale)
+}
+
+// FromContext mengembalikan bahasa request, atau DefaultLocale jika belum di-set
+func FromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(contextKey{}).(string); ok && locale != "" {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// ForUser: pesan untuk user tertentu (email/SMS) memakai bahasa pilihannya, jika kosong bahasa request
+func ForUser(ctx context.Context, userLocale string) string {
+	if locale, ok := Normalize(userLocale); ok {
+		return locale
+	}
+	return FromContext(ctx)
+}
\ No newline at end of file
@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+
+)
+
+// HeaderRequestID header untuk menerima & meneruskan request ID antar service
+const HeaderRequestID = "X-Request-ID"
+
+type Config struct {
+	Level  string // debug | info | warn | error
+	Format string // json | text
+}
+
+// New membuat logger slog dengan redaksi PII otomatis (email, nomor HP, token, hash password)
+func New(w io.Writer, cfg Config) (*slog.Logger, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: redactAttr}
+	switch strings.ToLower(cfg.Format) {
+	case "", "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("format log tidak dikenal: %s", cfg.Format)
+	}
+}
+
+// Setup memasang logger sebagai default. log.Printf lama (misal dari library)
+// ikut lewat handler ini sehingga tetap JSON dan tetap diredaksi.
+func Setup(cfg Config) *slog.Logger {
+	l, err := New(os.Stdout, cfg)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	slog.SetDefault(l)
+	return l
+}
+
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("level log tidak dikenal: %s", level)
+	}
+}
+
+type loggerKey struct{}
+type requestIDKey struct{}
+
+// WithContext menyimpan logger per-request di context
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext mengambil logger per-request (sudah berisi request_id), fallback ke default
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// With menambah atribut ke logger di context (misal user_id setelah autentikasi)
+func With(ctx context.Context, args ...any) context.Context {
+	return WithContext(ctx, FromContext(ctx).With(args...))
+}
+
+// WithRequestID menyimpan request ID dan logger yang sudah diberi atribut request_id
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey{}, requestID)
+	return WithContext(ctx, FromContext(ctx).With("request_id", requestID))
+}
+
+// RequestIDFromContext dipakai untuk meneruskan X-Request-ID ke service lain / job antrian
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
\ No newline at end of file
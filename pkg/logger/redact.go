@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+
+)
+
+const Redacted = "[REDACTED]"
+
+// Key yang nilainya tidak boleh muncul sama sekali di log
+var secretKeys = []string{"password", "secret", "token", "authorization", "cookie", "hash", "otp", "nonce"}
+
+var (
+	jwtPattern    = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+	bcryptPattern = regexp.MustCompile(`\$2[abxy]?\$\d{2}\$[./A-Za-z0-9]{53}`)
+	bearerPattern = regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._~+/=-]+`)
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// E.164 (+6281234567890) atau nomor lokal Indonesia (081234567890)
+	phonePattern = regexp.MustCompile(`\+\d{8,15}\b|\b0\d{8,13}\b`)
+)
+
+// redactAttr dipasang sebagai ReplaceAttr di handler slog
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() == slog.KindGroup {
+		return a
+	}
+
+	if IsSecretKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, RedactString(a.Value.String()))
+	case slog.KindAny:
+		// error & fmt.Stringer: pesan error sering memuat email/nomor (misal dari driver SMS)
+		switch v := a.Value.Any().(type) {
+		case error:
+			return slog.String(a.Key, RedactString(v.Error()))
+		case interface{ String() string }:
+			return slog.String(a.Key, RedactString(v.String()))
+		}
+	}
+	return a
+}
+
+// IsSecretKey true jika nilai dengan key ini tidak boleh dicatat (dipakai juga oleh audit log)
+func IsSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, secret := range secretKeys {
+		if strings.Contains(key, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactString menyamarkan email, nomor HP, JWT, bearer token dan hash bcrypt di teks bebas
+func RedactString(s string) string {
+	s = jwtPattern.ReplaceAllString(s, Redacted)
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+Redacted)
+	s = bcryptPattern.ReplaceAllString(s, Redacted)
+	s = emailPattern.ReplaceAllStringFunc(s, MaskEmail)
+	s = phonePattern.ReplaceAllStringFunc(s, MaskPhone)
+	return s
+}
+
+// MaskEmail: budi.santoso@gmail.com -> b***@gmail.com (domain tetap terlihat untuk debugging)
+func MaskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return Redacted
+	}
+	return local[:1] + "***@" + domain
+}
+
+// MaskPhone: +6281234567890 -> +62******7890
+func MaskPhone(phone string) string {
+	if len(phone) <= 7 {
+		return Redacted
+	}
+	return phone[:3] + strings.Repeat("*", len(phone)-7) + phone[len(phone)-4:]
+}
\ No newline at end of file
@@ -0,0 +1,44 @@
+package mailer
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"khalif-identify/pkg/logger"
+
+)
+
+// ConsoleMailer hanya mencetak email ke log (development)
+type ConsoleMailer struct{}
+
+func (ConsoleMailer) Send(ctx context.Context, to, subject, body string) error {
+	logger.FromContext(ctx).Info("📧 [MAIL]", "to", to, "subject", subject, "body", body)
+	return nil
+}
+
+// FileMailer menulis email sebagai JSON Lines ke file (development / test E2E)
+type FileMailer struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func (m *FileMailer) Send(ctx context.Context, to, subject, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return json.NewEncoder(f).Encode(map[string]interface{}{
+		"to":      to,
+		"subject": subject,
+		"body":    body,
+		"sent_at": time.Now().Format(time.RFC3339),
+	})
+}
\ No newline at end of file
@@ -0,0 +1,43 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+)
+
+// Mailer mengirim email teks biasa
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+type Config struct {
+	Driver       string // console | file | smtp
+	FilePath     string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	From         string
+}
+
+// New memilih driver sesuai konfigurasi (default: console untuk development)
+func New(cfg Config) (Mailer, error) {
+	switch strings.ToLower(cfg.Driver) {
+	case "", "console":
+		return ConsoleMailer{}, nil
+	case "file":
+		if cfg.FilePath == "" {
+			return nil, fmt.Errorf("MAIL_FILE_PATH wajib diisi untuk driver file")
+		}
+		return &FileMailer{Path: cfg.FilePath}, nil
+	case "smtp":
+		if cfg.SMTPHost == "" || cfg.From == "" {
+			return nil, fmt.Errorf("SMTP_HOST dan MAIL_FROM wajib diisi untuk driver smtp")
+		}
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From), nil
+	default:
+		return nil, fmt.Errorf("driver email tidak dikenal: %s", cfg.Driver)
+	}
+}
\ No newline at end of file
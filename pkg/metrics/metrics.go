@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+
+)
+
+const namespace = "khalif"
+
+// Registry khusus aplikasi (bukan DefaultRegisterer) agar test tidak bentrok
+// dan isi /metrics hanya berisi metric yang memang kita daftarkan.
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Durasi request HTTP per route.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "Jumlah request HTTP yang sedang diproses.",
+	})
+
+	Logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Percobaan login per metode, hasil dan role.",
+	}, []string{"method", "outcome", "role"})
+
+	Registrations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "registrations_total",
+		Help:      "Registrasi user yang berhasil per role.",
+	}, []string{"role"})
+
+	Logouts = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logouts_total",
+		Help:      "Logout (token masuk blacklist).",
+	})
+
+	SessionRevocations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "session_revocations_total",
+		Help:      "Pencabutan semua sesi user per alasan.",
+	}, []string{"reason"})
+
+	AccountDeletions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "account_deletions_total",
+		Help:      "Penghapusan akun per tahap (scheduled, cancelled, anonymized).",
+	}, []string{"stage"})
+
+	RateLimitBlocks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_blocked_total",
+		Help:      "Request yang ditolak rate limiter (429).",
+	}, []string{"limiter"})
+
+	ImageUploadDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "image_upload_duration_seconds",
+		Help:      "Durasi upload foto profil ke storage.",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	})
+
+	ImageJobFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "image_job_failures_total",
+		Help:      "Kegagalan job foto profil per tahap (upload, download, decode, save).",
+	}, []string{"stage"})
+
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Durasi query GORM per operasi.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"operation"})
+
+	RedisCommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "redis_command_duration_seconds",
+		Help:      "Durasi command Redis per nama command.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5},
+	}, []string{"command"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestDuration,
+		HTTPRequestsInFlight,
+		Logins,
+		Registrations,
+		Logouts,
+		SessionRevocations,
+		AccountDeletions,
+		RateLimitBlocks,
+		ImageUploadDuration,
+		ImageJobFailures,
+		DBQueryDuration,
+		RedisCommandDuration,
+	)
+}
+
+// Outcome login. Dipakai sebagai label, jadi nilainya harus terbatas (bukan pesan error).
+const (
+	LoginSuccess            = "success"
+	LoginInvalidCredentials = "invalid_credentials"
+	LoginDisabled           = "disabled"
+	LoginPendingDeletion    = "pending_deletion"
+	LoginInvalidOTP         = "invalid_otp"
+	LoginInvalidLink        = "invalid_link"
+	LoginError              = "error"
+)
+
+// ObserveLogin mencatat satu percobaan login. role kosong (login gagal) dicatat sebagai "unknown".
+func ObserveLogin(method, outcome, role string) {
+	if role == "" {
+		role = "unknown"
+	}
+	Logins.WithLabelValues(method, outcome, role).Inc()
+}
\ No newline at end of file
@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+)
+
+// InstrumentDB mendaftarkan statistik pool koneksi Postgres dan durasi query GORM
+func InstrumentDB(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	if err := Registry.Register(collectors.NewDBStatsCollector(sqlDB, "postgres")); err != nil {
+		return err
+	}
+	return db.Use(gormPlugin{})
+}
+
+// InstrumentRedis mendaftarkan statistik pool dan hook durasi command Redis
+func InstrumentRedis(rdb *redis.Client) error {
+	rdb.AddHook(redisHook{})
+	return Registry.Register(&redisPoolCollector{rdb: rdb})
+}
+
+// --- GORM ---
+
+const gormStartKey = "metrics:start"
+
+type gormPlugin struct{}
+
+func (gormPlugin) Name() string { return "metrics" }
+
+func (gormPlugin) Initialize(db *gorm.DB) error {
+	before := func(tx *gorm.DB) {
+		tx.InstanceSet(gormStartKey, time.Now())
+	}
+	after := func(operation string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			if start, ok := tx.InstanceGet(gormStartKey); ok {
+				DBQueryDuration.WithLabelValues(operation).Observe(time.Since(start.(time.Time)).Seconds())
+			}
+		}
+	}
+
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().Before("gorm:create").Register("metrics:before_create", before),
+		cb.Create().After("gorm:create").Register("metrics:after_create", after("create")),
+		cb.Query().Before("gorm:query").Register("metrics:before_query", before),
+		cb.Query().After("gorm:query").Register("metrics:after_query", after("query")),
+		cb.Update().Before("gorm:update").Register("metrics:before_update", before),
+		cb.Update().After("gorm:update").Register("metrics:after_update", after("update")),
+		cb.Delete().Before("gorm:delete").Register("metrics:before_delete", before),
+		cb.Delete().After("gorm:delete").Register("metrics:after_delete", after("delete")),
+		cb.Row().Before("gorm:row").Register("metrics:before_row", before),
+		cb.Row().After("gorm:row").Register("metrics:after_row", after("row")),
+		cb.Raw().Before("gorm:raw").Register("metrics:before_raw", before),
+		cb.Raw().After("gorm:raw").Register("metrics:after_raw", after("raw")),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// --- Redis ---
+
+type redisHook struct{}
+
+func (redisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		RedisCommandDuration.WithLabelValues(strings.ToLower(cmd.Name())).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+func (redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		RedisCommandDuration.WithLabelValues("pipeline").Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+var (
+	redisHitsDesc     = prometheus.NewDesc(namespace+"_redis_pool_hits_total", "Koneksi bebas ditemukan di pool.", nil, nil)
+	redisMissesDesc   = prometheus.NewDesc(namespace+"_redis_pool_misses_total", "Koneksi bebas tidak ditemukan di pool.", nil, nil)
+	redisTimeoutsDesc = prometheus.NewDesc(namespace+"_redis_pool_timeouts_total", "Timeout menunggu koneksi dari pool.", nil, nil)
+	redisTotalDesc    = prometheus.NewDesc(namespace+"_redis_pool_total_connections", "Jumlah koneksi di pool.", nil, nil)
+	redisIdleDesc     = prometheus.NewDesc(namespace+"_redis_pool_idle_connections", "Jumlah koneksi idle di pool.", nil, nil)
+	redisStaleDesc    = prometheus.NewDesc(namespace+"_redis_pool_stale_connections_total", "Koneksi stale yang dibuang dari pool.", nil, nil)
+)
+
+// redisPoolCollector membaca PoolStats setiap kali /metrics di-scrape
+type redisPoolCollector struct {
+	rdb *redis.Client
+}
+
+func (c *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{redisHitsDesc, redisMissesDesc, redisTimeoutsDesc, redisTotalDesc, redisIdleDesc, redisStaleDesc} {
+		ch <- d
+	}
+}
+
+func (c *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.rdb.PoolStats()
+	ch <- prometheus.MustNewConstMetric(redisHitsDesc, prometheus.CounterValue, float64(s.Hits))
+	ch <- prometheus.MustNewConstMetric(redisMissesDesc, prometheus.CounterValue, float64(s.Misses))
+	ch <- prometheus.MustNewConstMetric(redisTimeoutsDesc, prometheus.CounterValue, float64(s.Timeouts))
+	ch <- prometheus.MustNewConstMetric(redisTotalDesc, prometheus.GaugeValue, float64(s.TotalConns))
+	ch <- prometheus.MustNewConstMetric(redisIdleDesc, prometheus.GaugeValue, float64(s.IdleConns))
+	ch <- prometheus.MustNewConstMetric(redisStaleDesc, prometheus.CounterValue, float64(s.StaleConns))
+}
\ No newline at end of file
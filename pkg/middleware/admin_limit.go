@@ -1,8 +1,6 @@
 package middleware
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
 
 	"khalif-identify/internal/domain"
@@ -18,14 +16,12 @@ func MaxAdminLimit(repo domain.UserRepository) gin.HandlerFunc {
 		// Perbaikan: Tambahkan Context dari request
 		currentCount, err := repo.CountByRoleID(c.Request.Context(), AdminRoleID)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check admin quota"})
+			abortWithError(c, err)
 			return
 		}
 
 		if currentCount >= MaxAdminCount {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-				"error": "Admin quota is full (Max 3 admins allowed)",
-			})
+			abortWithError(c, domain.ErrAdminQuotaFull.WithParams(map[string]interface{}{"max": MaxAdminCount}))
 			return
 		}
 
@@ -2,34 +2,53 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/redis/go-redis/v9"
 
+	"khalif-identify/internal/domain"
+	"khalif-identify/pkg/logger"
+	"khalif-identify/pkg/reqinfo"
+
 )
 
+// SessionVersionFunc mengembalikan versi sesi user saat ini; token dengan claim "sv" lebih kecil
+// sudah dicabut (password diganti, akun dinonaktifkan, dll)
+type SessionVersionFunc func(ctx context.Context, userUUID string) (int, error)
+
 // Update: Menambahkan parameter Redis Client (rdb)
-func AuthMiddleware(secretKey string, rdb *redis.Client) gin.HandlerFunc {
+// Pengecekan blacklist dan versi sesi fail-closed: jika Redis/database tidak bisa dibaca, request
+// ditolak daripada meloloskan token yang mungkin sudah dicabut.
+func AuthMiddleware(secretKey string, rdb *redis.Client, sessionVersion SessionVersionFunc) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if !strings.Contains(authHeader, "Bearer") {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			abortWithError(c, domain.ErrUnauthorized)
 			return
 		}
 
-		tokenString := strings.Split(authHeader, " ")[1]
+		// Header "Bearer" tanpa token (atau skema lain yang kebetulan memuat kata Bearer) tidak valid
+		tokenString, ok := strings.CutPrefix(authHeader, "Bearer ")
+		if !ok || tokenString == "" {
+			abortWithError(c, domain.ErrTokenInvalid)
+			return
+		}
 
 		// --- LOGIC BLACKLIST CHECK ---
 		// Cek apakah token ini ada di daftar blacklist Redis?
-		ctx := context.Background()
+		ctx := c.Request.Context()
 		_, err := rdb.Get(ctx, "blacklist:"+tokenString).Result()
 		if err == nil {
 			// Jika ditemukan di Redis (err == nil), berarti token sudah logout/hangus
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token invalidated (Logged out)"})
+			abortWithError(c, domain.ErrTokenRevoked)
+			return
+		}
+		if !errors.Is(err, redis.Nil) {
+			abortWithError(c, fmt.Errorf("gagal membaca blacklist token: %w", err))
 			return
 		}
 		// -----------------------------
@@ -42,16 +61,42 @@ func AuthMiddleware(secretKey string, rdb *redis.Client) gin.HandlerFunc {
 		})
 
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid Token"})
+			abortWithError(c, domain.ErrTokenInvalid)
 			return
 		}
 
 		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+			actorID, _ := claims["user_id"].(string)
+			if actorID == "" {
+				abortWithError(c, domain.ErrTokenInvalid)
+				return
+			}
+
+			// Semua sesi user dicabut jika versi di token lebih lama dari versi user sekarang.
+			// Token tanpa "sv" (terbit sebelum kolom ini ada) dianggap versi 0.
+			current, err := sessionVersion(ctx, actorID)
+			if errors.Is(err, domain.ErrUserNotFound) {
+				abortWithError(c, domain.ErrTokenInvalid)
+				return
+			}
+			if err != nil {
+				abortWithError(c, fmt.Errorf("gagal membaca versi sesi: %w", err))
+				return
+			}
+			tokenVersion, _ := claims["sv"].(float64)
+			if int(tokenVersion) < current {
+				abortWithError(c, domain.ErrTokenRevoked)
+				return
+			}
+
 			c.Set("user_id", claims["user_id"])
 			c.Set("role", claims["role"])
+			// Log selanjutnya di request ini otomatis membawa user_id; audit log mencatatnya sebagai actor
+			reqCtx := logger.With(c.Request.Context(), "user_id", claims["user_id"])
+			c.Request = c.Request.WithContext(reqinfo.WithActor(reqCtx, actorID))
 			c.Next()
 		} else {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid Token Claims"})
+			abortWithError(c, domain.ErrTokenInvalid)
 		}
 	}
 }
\ No newline at end of file
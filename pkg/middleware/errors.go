@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"khalif-identify/internal/domain"
+	"khalif-identify/pkg/i18n"
+	"khalif-identify/pkg/logger"
+	"khalif-identify/pkg/utils"
+
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Problem adalah body error RFC 7807. Code stabil untuk dicek client,
+// Errors berisi detail per field untuk error validasi.
+type Problem struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Detail    string              `json:"detail,omitempty"`
+	Instance  string              `json:"instance,omitempty"`
+	Code      string              `json:"code"`
+	RequestID string              `json:"request_id,omitempty"`
+	Errors    []domain.FieldError `json:"errors,omitempty"`
+}
+
+var statusByKind = map[domain.ErrorKind]int{
+	domain.KindValidation:    http.StatusUnprocessableEntity,
+	domain.KindUnauthorized:  http.StatusUnauthorized,
+	domain.KindForbidden:     http.StatusForbidden,
+	domain.KindNotFound:      http.StatusNotFound,
+	domain.KindConflict:      http.StatusConflict,
+	domain.KindQuotaExceeded: http.StatusConflict,
+	domain.KindRateLimited:   http.StatusTooManyRequests,
+	domain.KindInternal:      http.StatusInternalServerError,
+}
+
+var registerTagNameOnce sync.Once
+
+// ErrorHandler memetakan error yang didaftarkan handler/middleware lewat c.Error
+// menjadi response problem+json. Dipasang sekali di router, sebelum middleware lain yang bisa abort.
+func ErrorHandler() gin.HandlerFunc {
+	// Nama field di detail validasi mengikuti tag json/form, bukan nama field struct Go
+	registerTagNameOnce.Do(func() {
+		if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+			v.RegisterTagNameFunc(fieldName)
+		}
+	})
+
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		ginErr := c.Errors.Last()
+		problem := problemFor(ginErr, RequestLocale(c))
+		problem.Instance = c.Request.URL.Path
+		problem.RequestID = logger.RequestIDFromContext(c.Request.Context())
+
+		if problem.Status >= http.StatusInternalServerError {
+			logger.FromContext(c.Request.Context()).Error("❌ Request gagal", "error", ginErr.Err)
+		}
+
+		var cooldown *domain.OTPCooldownError
+		if errors.As(ginErr.Err, &cooldown) {
+			c.Header("Retry-After", strconv.Itoa(int(cooldown.RetryAfter.Seconds())))
+		}
+		c.Header("Content-Type", ProblemContentType)
+		c.JSON(problem.Status, problem)
+	}
+}
+
+// abortWithError dipakai middleware di package ini; response ditulis oleh ErrorHandler
+func abortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// problemFor menyusun response; detail & pesan field diterjemahkan dari katalog "error.<code>"
+func problemFor(ginErr *gin.Error, locale string) Problem {
+	err := ginErr.Err
+	if ginErr.IsType(gin.ErrorTypeBind) {
+		return bindingProblem(err, locale)
+	}
+
+	var phoneErr *utils.PhoneError
+	if errors.As(err, &phoneErr) {
+		message := translate(locale, phoneErr.Code, i18n.Params{"region": phoneErr.Region}, phoneErr.Message)
+		return newProblem(http.StatusUnprocessableEntity, phoneErr.Code, message,
+			domain.FieldError{Field: "phone", Code: phoneErr.Code, Message: message})
+	}
+
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) {
+		status, ok := statusByKind[domainErr.Kind]
+		if !ok {
+			status = http.StatusInternalServerError
+		}
+		message := translate(locale, domainErr.Code, domainErr.Params, domainErr.Error())
+		fields := make([]domain.FieldError, len(domainErr.Fields))
+		for i, field := range domainErr.Fields {
+			field.Message = translate(locale, field.Code, domainErr.Params, field.Message)
+			fields[i] = field
+		}
+		return newProblem(status, domainErr.Code, message, fields...)
+	}
+
+	// Error tak dikenal: pesan asli hanya masuk log, bukan ke client
+	return newProblem(http.StatusInternalServerError, "internal_error", i18n.T(locale, "error.internal_error", nil))
+}
+
+// bindingProblem: body tidak bisa di-parse -> 400, field tidak lolos aturan binding -> 422 dengan semua field
+func bindingProblem(err error, locale string) Problem {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return newProblem(http.StatusBadRequest, "malformed_request", i18n.T(locale, "error.malformed_request", nil))
+	}
+
+	fields := make([]domain.FieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		fields = append(fields, domain.FieldError{
+			Field:   fe.Field(),
+			Code:    fe.Tag(),
+			Message: fieldMessage(fe, locale),
+		})
+	}
+	failed := domain.ValidationFailed(fields...)
+	return newProblem(http.StatusUnprocessableEntity, failed.Code, i18n.T(locale, "error."+failed.Code, nil), fields...)
+}
+
+func newProblem(status int, code, detail string, fields ...domain.FieldError) Problem {
+	return Problem{
+		Type:   "urn:khalif-identify:problem:" + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+		Errors: fields,
+	}
+}
+
+// translate memakai fallback (pesan bahasa Inggris dari error) jika kode belum ada di katalog
+func translate(locale, code string, params map[string]interface{}, fallback string) string {
+	if message, ok := i18n.Lookup(locale, "error."+code, params); ok {
+		return message
+	}
+	return fallback
+}
+
+func fieldMessage(fe validator.FieldError, locale string) string {
+	params := i18n.Params{"field": fe.Field(), "param": fe.Param()}
+	if message, ok := i18n.Lookup(locale, "validation."+fe.Tag(), params); ok {
+		return message
+	}
+	return i18n.T(locale, "validation.invalid", params)
+}
+
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		if name := strings.Split(field.Tag.Get(tag), ",")[0]; name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
\ No newline at end of file
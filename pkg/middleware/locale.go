@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+
+	"khalif-identify/pkg/i18n"
+	"khalif-identify/pkg/logger"
+
+)
+
+// localeKey bahasa response yang sudah dipilih, disimpan di gin.Context
+const localeKey = "locale"
+
+// Locale memilih bahasa response dari Accept-Language dan menyimpannya di context request,
+// supaya handler dan usecase (misal email saat registrasi) memakai bahasa yang sama
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		setLocale(c, i18n.Match(c.GetHeader("Accept-Language")))
+		c.Next()
+	}
+}
+
+// UserLocaleFunc mengembalikan bahasa yang disimpan user (kosong = belum memilih)
+type UserLocaleFunc func(ctx context.Context, userUUID string) (string, error)
+
+// UserLocale dipasang setelah AuthMiddleware: bahasa pilihan user menang atas Accept-Language.
+// Gagal membaca preferensi tidak menggagalkan request, bahasa dari Locale tetap dipakai.
+func UserLocale(lookup UserLocaleFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if userID := c.GetString("user_id"); userID != "" {
+			saved, err := lookup(c.Request.Context(), userID)
+			if err != nil {
+				logger.FromContext(c.Request.Context()).Warn("⚠️ Gagal membaca bahasa user", "error", err)
+			} else if locale, ok := i18n.Normalize(saved); ok {
+				setLocale(c, locale)
+			}
+		}
+		c.Next()
+	}
+}
+
+func setLocale(c *gin.Context, locale string) {
+	c.Set(localeKey, locale)
+	c.Request = c.Request.WithContext(i18n.WithLocale(c.Request.Context(), locale))
+	c.Header("Content-Language", locale)
+}
+
+// RequestLocale bahasa yang sudah dipilih Locale/UserLocale. Request yang berhenti sebelum
+// Locale berjalan (misal ditolak rate limit) memakai Accept-Language.
+func RequestLocale(c *gin.Context) string {
+	if locale := c.GetString(localeKey); locale != "" {
+		return locale
+	}
+	return i18n.Match(c.GetHeader("Accept-Language"))
+}
\ No newline at end of file
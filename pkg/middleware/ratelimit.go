@@ -3,17 +3,22 @@ package middleware
 import (
 	"context"
 	"fmt"
-	"log"
-	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 
+	"khalif-identify/internal/domain"
+	"khalif-identify/pkg/logger"
+	"khalif-identify/pkg/metrics"
+
 )
 
 type RateLimitConfig struct {
+	// Name memisahkan counter antar limiter (misal "global" dan "login"),
+	// tanpa ini request login ikut menghabiskan kuota global dan sebaliknya
+	Name   string
 	Limit  int
 	Window time.Duration
 }
@@ -25,10 +30,13 @@ func RateLimit(rdb *redis.Client, config RateLimitConfig) gin.HandlerFunc {
 
 		ip := c.ClientIP()
 		key := fmt.Sprintf("rl:%s", ip)
+		if config.Name != "" {
+			key = fmt.Sprintf("rl:%s:%s", config.Name, ip)
+		}
 
 		count, err := rdb.Incr(ctx, key).Result()
 		if err != nil {
-			log.Printf("⚠️ Redis Rate Limit Error: %v", err)
+			logger.FromContext(c.Request.Context()).Warn("rate limit redis error", "error", err)
 			c.Next()
 			return
 		}
@@ -46,9 +54,12 @@ func RateLimit(rdb *redis.Client, config RateLimitConfig) gin.HandlerFunc {
 		c.Header("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
 
 		if count > int64(config.Limit) {
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
-				"error": "Too many requests. Please slow down.",
-			})
+			limiter := config.Name
+			if limiter == "" {
+				limiter = "default"
+			}
+			metrics.RateLimitBlocks.WithLabelValues(limiter).Inc()
+			abortWithError(c, domain.ErrTooManyRequests)
 			return
 		}
 
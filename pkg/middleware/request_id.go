@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"khalif-identify/pkg/logger"
+	"khalif-identify/pkg/reqinfo"
+
+)
+
+// Request ID dari client/gateway hanya dipakai jika formatnya aman (mencegah log injection)
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID memakai X-Request-ID dari upstream (atau membuat UUID baru), mengembalikannya
+// di response, dan menyimpan logger per-request berisi request_id di context request.
+// IP & user agent client ikut disimpan (reqinfo) untuk riwayat login.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(logger.HeaderRequestID)
+		if !validRequestID.MatchString(id) {
+			id = uuid.New().String()
+		}
+
+		c.Set("request_id", id)
+		c.Header(logger.HeaderRequestID, id)
+		ctx := logger.WithRequestID(c.Request.Context(), id)
+		c.Request = c.Request.WithContext(reqinfo.With(ctx, c.ClientIP(), c.Request.UserAgent()))
+
+		c.Next()
+	}
+}
+
+// AccessLog mencatat satu baris log terstruktur per request (pengganti logger bawaan gin)
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("ip", c.ClientIP()),
+			slog.String("user_agent", c.Request.UserAgent()),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.String()))
+		}
+		logger.FromContext(c.Request.Context()).LogAttrs(c.Request.Context(), level, "http request", attrs...)
+	}
+}
\ No newline at end of file
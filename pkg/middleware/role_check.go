@@ -1,24 +1,22 @@
 package middleware
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
 
+	"khalif-identify/internal/domain"
+
 )
 
 func OnlyAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("role")
 		if !exists {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			abortWithError(c, domain.ErrUnauthorized)
 			return
 		}
 
 		if role != "Admin" {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-				"error": "Access denied. Admins only.",
-			})
+			abortWithError(c, domain.ErrAdminOnly)
 			return
 		}
 
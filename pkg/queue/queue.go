@@ -0,0 +1,337 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+
+	"khalif-identify/pkg/logger"
+
+)
+
+// Layout key Redis untuk satu antrian bernama <name>:
+//   queue:<name>             -> LIST job yang siap diproses
+//   queue:<name>:processing  -> LIST job yang sedang dikerjakan worker
+//   queue:<name>:leases      -> ZSET batas waktu heartbeat job di processing (score = unix ms)
+//   queue:<name>:retry       -> ZSET job yang menunggu back-off atau dijadwalkan (score = unix ms)
+//   queue:<name>:dead        -> LIST job yang gagal permanen (dead-letter, maksimal DeadLetterLimit terbaru)
+
+const DefaultMaxAttempts = 5
+
+// DeadLetterLimit jumlah job terbaru yang disimpan di dead-letter list; yang lebih lama dibuang
+const DeadLetterLimit = 1000
+
+type Job struct {
+	ID          string            `json:"id"`
+	Type        string            `json:"type"`
+	Payload     json.RawMessage   `json:"payload"`
+	Attempts    int               `json:"attempts"`
+	MaxAttempts int               `json:"max_attempts"`
+	LastError   string            `json:"last_error,omitempty"`
+	RequestID   string            `json:"request_id,omitempty"` // Request HTTP yang membuat job (untuk korelasi log)
+	Trace       map[string]string `json:"trace,omitempty"`      // Konteks trace W3C (traceparent) dari request asal
+	EnqueuedAt  time.Time         `json:"enqueued_at"`
+	FailedAt    *time.Time        `json:"failed_at,omitempty"`
+}
+
+// IsLastAttempt true jika percobaan yang sedang berjalan adalah yang terakhir
+func (j *Job) IsLastAttempt() bool {
+	return j.Attempts+1 >= j.MaxAttempts
+}
+
+type Queue struct {
+	rdb  *redis.Client
+	name string
+}
+
+func New(rdb *redis.Client, name string) *Queue {
+	return &Queue{rdb: rdb, name: name}
+}
+
+func (q *Queue) readyKey() string      { return "queue:" + q.name }
+func (q *Queue) processingKey() string { return "queue:" + q.name + ":processing" }
+func (q *Queue) retryKey() string      { return "queue:" + q.name + ":retry" }
+func (q *Queue) deadKey() string       { return "queue:" + q.name + ":dead" }
+func (q *Queue) leasesKey() string     { return "queue:" + q.name + ":leases" }
+
+// Enqueue memasukkan job baru ke antrian (payload di-encode sebagai JSON)
+func (q *Queue) Enqueue(ctx context.Context, jobType string, payload interface{}) error {
+	raw, err := newJob(ctx, jobType, payload)
+	if err != nil {
+		return err
+	}
+	return q.rdb.LPush(ctx, q.readyKey(), raw).Err()
+}
+
+// EnqueueAt menjadwalkan job untuk diproses setelah waktu tertentu. Job menunggu di ZSET
+// retry dan dipindah ke antrian utama oleh scheduler worker saat jatuh tempo.
+func (q *Queue) EnqueueAt(ctx context.Context, jobType string, payload interface{}, at time.Time) error {
+	raw, err := newJob(ctx, jobType, payload)
+	if err != nil {
+		return err
+	}
+	return q.rdb.ZAdd(ctx, q.retryKey(), redis.Z{Score: float64(at.UnixMilli()), Member: raw}).Err()
+}
+
+func newJob(ctx context.Context, jobType string, payload interface{}) ([]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("gagal encode payload job: %w", err)
+	}
+
+	job := Job{
+		ID:          uuid.New().String(),
+		Type:        jobType,
+		Payload:     data,
+		MaxAttempts: DefaultMaxAttempts,
+		RequestID:   logger.RequestIDFromContext(ctx),
+		EnqueuedAt:  time.Now(),
+	}
+	// Span worker nanti menjadi lanjutan trace request yang membuat job
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) > 0 {
+		job.Trace = carrier
+	}
+	return json.Marshal(job)
+}
+
+// Discard membuang job yang belum diambil worker (antrian utama & ZSET retry) dengan tipe tertentu
+// yang payload-nya cocok. Job yang sedang dikerjakan tidak tersentuh; handler-nya harus tetap
+// memeriksa sendiri apakah pekerjaannya masih relevan.
+func (q *Queue) Discard(ctx context.Context, jobType string, match func(payload json.RawMessage) bool) ([]json.RawMessage, error) {
+	ready, err := q.rdb.LRange(ctx, q.readyKey(), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	waiting, err := q.rdb.ZRange(ctx, q.retryKey(), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var discarded []json.RawMessage
+	remove := func(raw string, rem func(raw string) (int64, error)) error {
+		var job Job
+		if err := json.Unmarshal([]byte(raw), &job); err != nil || job.Type != jobType || !match(job.Payload) {
+			return nil
+		}
+		// Hanya yang berhasil menghapus yang mencatat, job yang keburu diambil worker dilewati
+		removed, err := rem(raw)
+		if err != nil {
+			return err
+		}
+		if removed == 1 {
+			discarded = append(discarded, job.Payload)
+		}
+		return nil
+	}
+	for _, raw := range ready {
+		if err := remove(raw, func(raw string) (int64, error) { return q.rdb.LRem(ctx, q.readyKey(), 1, raw).Result() }); err != nil {
+			return discarded, err
+		}
+	}
+	for _, raw := range waiting {
+		if err := remove(raw, func(raw string) (int64, error) { return q.rdb.ZRem(ctx, q.retryKey(), raw).Result() }); err != nil {
+			return discarded, err
+		}
+	}
+	return discarded, nil
+}
+
+// DeadLetters mengembalikan job di dead-letter list (terbaru lebih dulu)
+func (q *Queue) DeadLetters(ctx context.Context, limit int64) ([]Job, error) {
+	raws, err := q.rdb.LRange(ctx, q.deadKey(), 0, limit-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]Job, 0, len(raws))
+	for _, raw := range raws {
+		var job Job
+		if err := json.Unmarshal([]byte(raw), &job); err == nil {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+// reserve mengambil satu job (blocking sampai timeout) dan memindahkannya ke processing list
+func (q *Queue) reserve(ctx context.Context, timeout time.Duration) (string, error) {
+	raw, err := q.rdb.BLMove(ctx, q.readyKey(), q.processingKey(), "RIGHT", "LEFT", timeout).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return raw, err
+}
+
+func (q *Queue) ack(ctx context.Context, raw string) error {
+	pipe := q.rdb.TxPipeline()
+	pipe.LRem(ctx, q.processingKey(), 1, raw)
+	pipe.ZRem(ctx, q.leasesKey(), raw)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// lease mencatat batas waktu job yang baru diambil worker
+func (q *Queue) lease(ctx context.Context, raw string, timeout time.Duration) error {
+	return q.rdb.ZAdd(ctx, q.leasesKey(), redis.Z{Score: float64(time.Now().Add(timeout).UnixMilli()), Member: raw}).Err()
+}
+
+// extendLease memperpanjang batas waktu job yang sedang dikerjakan. XX: lease yang sudah
+// diambil alih reaper tidak dihidupkan lagi.
+func (q *Queue) extendLease(ctx context.Context, raw string, timeout time.Duration) error {
+	return q.rdb.ZAddXX(ctx, q.leasesKey(), redis.Z{Score: float64(time.Now().Add(timeout).UnixMilli()), Member: raw}).Err()
+}
+
+// retry mengeluarkan job dari processing dan menjadwalkan ulang setelah delay
+func (q *Queue) retry(ctx context.Context, raw string, job Job, delay time.Duration) error {
+	updated, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	pipe := q.rdb.TxPipeline()
+	pipe.LRem(ctx, q.processingKey(), 1, raw)
+	pipe.ZRem(ctx, q.leasesKey(), raw)
+	pipe.ZAdd(ctx, q.retryKey(), redis.Z{Score: float64(time.Now().Add(delay).UnixMilli()), Member: updated})
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// bury memindahkan job ke dead-letter list
+func (q *Queue) bury(ctx context.Context, raw string, job Job) error {
+	now := time.Now()
+	job.FailedAt = &now
+	updated, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	pipe := q.rdb.TxPipeline()
+	pipe.LRem(ctx, q.processingKey(), 1, raw)
+	pipe.ZRem(ctx, q.leasesKey(), raw)
+	pipe.LPush(ctx, q.deadKey(), updated)
+	pipe.LTrim(ctx, q.deadKey(), 0, DeadLetterLimit-1)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// promoteDue memindahkan job retry yang sudah jatuh tempo kembali ke antrian utama
+func (q *Queue) promoteDue(ctx context.Context) error {
+	due, err := q.rdb.ZRangeByScore(ctx, q.retryKey(), &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   fmt.Sprintf("%d", time.Now().UnixMilli()),
+		Count: 100,
+	}).Result()
+	if err != nil {
+		return err
+	}
+	for _, raw := range due {
+		// ZRem sebagai "lock": hanya worker yang berhasil menghapus yang boleh push
+		removed, err := q.rdb.ZRem(ctx, q.retryKey(), raw).Result()
+		if err != nil {
+			return err
+		}
+		if removed == 1 {
+			if err := q.rdb.LPush(ctx, q.readyKey(), raw).Err(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// reclaimScript memindah job yatim dari processing hanya jika lease-nya masih kedaluwarsa,
+// sehingga job yang baru saja di-heartbeat atau di-ack worker lain tidak ikut terambil.
+// KEYS: processing, leases, tujuan (retry ZSET atau dead LIST). ARGV: raw, job baru, now ms, score retry, batas dead-letter.
+var reclaimScript = redis.NewScript(`
+local deadline = redis.call('ZSCORE', KEYS[2], ARGV[1])
+if not deadline or tonumber(deadline) > tonumber(ARGV[3]) then
+	return 0
+end
+redis.call('ZREM', KEYS[2], ARGV[1])
+if redis.call('LREM', KEYS[1], 1, ARGV[1]) == 0 then
+	return 0
+end
+if ARGV[4] == '' then
+	redis.call('LPUSH', KEYS[3], ARGV[2])
+	redis.call('LTRIM', KEYS[3], 0, tonumber(ARGV[5]) - 1)
+else
+	redis.call('ZADD', KEYS[3], ARGV[4], ARGV[2])
+end
+return 1
+`)
+
+// reapStale mengembalikan job di processing yang lease-nya habis (worker crash sebelum
+// ack/retry/bury). Job tanpa lease diberi satu masa timeout dulu: worker baru saja
+// memindahnya lewat BLMove dan belum sempat menulis lease. Job yang terambil dihitung
+// sebagai satu percobaan gagal agar job yang selalu membuat worker crash berakhir di dead-letter.
+func (q *Queue) reapStale(ctx context.Context, timeout time.Duration) (int, error) {
+	raws, err := q.rdb.LRange(ctx, q.processingKey(), 0, -1).Result()
+	if err != nil || len(raws) == 0 {
+		return 0, err
+	}
+
+	now := time.Now()
+	grace := make([]redis.Z, 0, len(raws))
+	for _, raw := range raws {
+		grace = append(grace, redis.Z{Score: float64(now.Add(timeout).UnixMilli()), Member: raw})
+	}
+	if err := q.rdb.ZAddNX(ctx, q.leasesKey(), grace...).Err(); err != nil {
+		return 0, err
+	}
+
+	expired, err := q.rdb.ZRangeByScore(ctx, q.leasesKey(), &redis.ZRangeBy{
+		Min: "-inf",
+		Max: fmt.Sprintf("%d", now.UnixMilli()),
+	}).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	reclaimed := 0
+	for _, raw := range expired {
+		var job Job
+		if err := json.Unmarshal([]byte(raw), &job); err != nil {
+			job = Job{LastError: "payload tidak valid: " + err.Error()}
+		} else {
+			job.Attempts++
+			job.LastError = "worker berhenti sebelum job selesai"
+		}
+
+		dest, score := q.retryKey(), fmt.Sprintf("%d", now.Add(Backoff(job.Attempts)).UnixMilli())
+		if job.Attempts >= job.MaxAttempts {
+			failedAt := now
+			job.FailedAt = &failedAt
+			dest, score = q.deadKey(), ""
+		}
+		updated, err := json.Marshal(job)
+		if err != nil {
+			return reclaimed, err
+		}
+
+		moved, err := reclaimScript.Run(ctx, q.rdb, []string{q.processingKey(), q.leasesKey(), dest},
+			raw, updated, now.UnixMilli(), score, DeadLetterLimit).Int()
+		if err != nil {
+			return reclaimed, err
+		}
+		reclaimed += moved
+	}
+	return reclaimed, nil
+}
+
+// Backoff menghitung jeda sebelum percobaan ke-n (eksponensial, dibatasi maksimal 10 menit)
+func Backoff(attempt int) time.Duration {
+	delay := 2 * time.Second
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= 10*time.Minute {
+			return 10 * time.Minute
+		}
+	}
+	return delay
+}
\ No newline at end of file
@@ -0,0 +1,219 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"khalif-identify/pkg/logger"
+	"khalif-identify/pkg/tracing"
+
+)
+
+// HandlerFunc memproses satu job. Return error untuk retry (dengan back-off),
+// atau bungkus dengan Permanent() agar job langsung masuk dead-letter.
+type HandlerFunc func(ctx context.Context, job *Job) error
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent menandai error yang tidak perlu di-retry (misal: file bukan gambar)
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// DefaultVisibilityTimeout dipakai jika NewWorker diberi timeout <= 0
+const DefaultVisibilityTimeout = time.Minute
+
+type Worker struct {
+	queue       *Queue
+	handlers    map[string]HandlerFunc
+	concurrency int
+	visibility  time.Duration // Lease job di-heartbeat tiap visibility/3; lewat dari ini job dianggap yatim
+}
+
+func NewWorker(q *Queue, concurrency int, visibility time.Duration) *Worker {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if visibility <= 0 {
+		visibility = DefaultVisibilityTimeout
+	}
+	return &Worker{queue: q, handlers: map[string]HandlerFunc{}, concurrency: concurrency, visibility: visibility}
+}
+
+func (w *Worker) Handle(jobType string, h HandlerFunc) {
+	w.handlers[jobType] = h
+}
+
+// Run menjalankan worker sampai ctx dibatalkan. Job yang sedang diproses tetap diselesaikan.
+func (w *Worker) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.scheduleRetries(ctx)
+	}()
+
+	for i := 0; i < w.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+
+	slog.Info("👷 Worker berjalan", "queue", w.queue.name, "concurrency", w.concurrency)
+	wg.Wait()
+	slog.Info("👷 Worker berhenti", "queue", w.queue.name)
+}
+
+func (w *Worker) scheduleRetries(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.queue.promoteDue(ctx); err != nil && ctx.Err() == nil {
+				slog.Warn("⚠️ Queue retry scheduler error", "queue", w.queue.name, "error", err)
+			}
+			reclaimed, err := w.queue.reapStale(ctx, w.visibility)
+			if err != nil && ctx.Err() == nil {
+				slog.Warn("⚠️ Queue reaper error", "queue", w.queue.name, "error", err)
+			}
+			if reclaimed > 0 {
+				slog.Warn("♻️ Job yatim di processing diantrikan ulang", "queue", w.queue.name, "count", reclaimed)
+			}
+		}
+	}
+}
+
+func (w *Worker) loop(ctx context.Context) {
+	for ctx.Err() == nil {
+		raw, err := w.queue.reserve(ctx, 5*time.Second)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Warn("⚠️ Queue reserve error", "queue", w.queue.name, "error", err)
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+		if raw == "" {
+			continue
+		}
+		// Job yang sudah diambil tetap diselesaikan walau ctx dibatalkan
+		w.process(context.WithoutCancel(ctx), raw)
+	}
+}
+
+func (w *Worker) process(ctx context.Context, raw string) {
+	stop := w.keepLease(ctx, raw)
+	defer stop()
+
+	var job Job
+	if err := json.Unmarshal([]byte(raw), &job); err != nil {
+		slog.Error("❌ Job rusak, dipindah ke dead-letter", "queue", w.queue.name, "error", err)
+		w.queue.bury(ctx, raw, Job{LastError: "payload tidak valid: " + err.Error()})
+		return
+	}
+
+	// Logger job membawa request_id asal agar log worker bisa dikorelasikan dengan request HTTP
+	if job.RequestID != "" {
+		ctx = logger.WithRequestID(ctx, job.RequestID)
+	}
+	if len(job.Trace) > 0 {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(job.Trace))
+	}
+	ctx, span := tracing.Tracer().Start(ctx, "queue."+job.Type,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "redis"),
+			attribute.String("messaging.destination.name", w.queue.name),
+			attribute.String("messaging.message.id", job.ID),
+			attribute.Int("job.attempt", job.Attempts+1),
+		),
+	)
+	defer span.End()
+
+	ctx = logger.With(ctx, "job_id", job.ID, "job_type", job.Type)
+	log := logger.FromContext(ctx)
+
+	handler, ok := w.handlers[job.Type]
+	if !ok {
+		job.LastError = fmt.Sprintf("tidak ada handler untuk job type %q", job.Type)
+		w.queue.bury(ctx, raw, job)
+		return
+	}
+
+	err := handler(ctx, &job)
+	if err == nil {
+		if err := w.queue.ack(ctx, raw); err != nil {
+			log.Warn("⚠️ Gagal ack job", "error", err)
+		}
+		return
+	}
+
+	job.Attempts++
+	job.LastError = err.Error()
+	tracing.RecordError(span, err)
+
+	var permanent permanentError
+	if errors.As(err, &permanent) || job.Attempts >= job.MaxAttempts {
+		log.Error("❌ Job gagal permanen", "attempts", job.Attempts, "error", err)
+		if err := w.queue.bury(ctx, raw, job); err != nil {
+			log.Warn("⚠️ Gagal memindah job ke dead-letter", "error", err)
+		}
+		return
+	}
+
+	delay := Backoff(job.Attempts)
+	log.Warn("🔁 Job gagal, akan di-retry", "attempts", job.Attempts, "retry_in", delay.String(), "error", err)
+	if err := w.queue.retry(ctx, raw, job, delay); err != nil {
+		log.Warn("⚠️ Gagal menjadwalkan retry job", "error", err)
+	}
+}
+
+
+// keepLease menulis lease job lalu memperbaruinya berkala selama handler berjalan.
+// Fungsi yang dikembalikan menghentikan heartbeat dan menunggu goroutine-nya selesai.
+func (w *Worker) keepLease(ctx context.Context, raw string) func() {
+	if err := w.queue.lease(ctx, raw, w.visibility); err != nil {
+		slog.Warn("⚠️ Gagal menulis lease job", "queue", w.queue.name, "error", err)
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(w.visibility / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := w.queue.extendLease(ctx, raw, w.visibility); err != nil {
+					slog.Warn("⚠️ Gagal memperpanjang lease job", "queue", w.queue.name, "error", err)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
\ No newline at end of file
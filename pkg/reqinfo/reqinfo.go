@@ -0,0 +1,37 @@
+package reqinfo
+
+import (
+	"context"
+
+	"khalif-identify/pkg/logger"
+
+)
+
+// Info adalah asal request HTTP yang perlu diketahui usecase (riwayat login, audit)
+type Info struct {
+	IP        string
+	UserAgent string
+	RequestID string
+	ActorID   string // UUID user yang login (AuthMiddleware), "cli" / "system" di luar HTTP
+}
+
+type contextKey struct{}
+
+// With menyimpan IP & user agent client di context (diisi middleware.RequestID)
+func With(ctx context.Context, ip, userAgent string) context.Context {
+	return context.WithValue(ctx, contextKey{}, Info{IP: ip, UserAgent: userAgent})
+}
+
+// WithActor mencatat siapa yang menjalankan aksi; IP & user agent yang sudah ada tetap dipertahankan
+func WithActor(ctx context.Context, actorID string) context.Context {
+	info, _ := ctx.Value(contextKey{}).(Info)
+	info.ActorID = actorID
+	return context.WithValue(ctx, contextKey{}, info)
+}
+
+// FromContext mengembalikan info request; kosong jika bukan dari request HTTP (CLI, worker)
+func FromContext(ctx context.Context) Info {
+	info, _ := ctx.Value(contextKey{}).(Info)
+	info.RequestID = logger.RequestIDFromContext(ctx)
+	return info
+}
\ No newline at end of file
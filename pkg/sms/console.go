@@ -0,0 +1,43 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"khalif-identify/pkg/logger"
+
+)
+
+// ConsoleSender hanya mencetak SMS ke log (development)
+type ConsoleSender struct{}
+
+func (ConsoleSender) Send(ctx context.Context, to, message string) error {
+	logger.FromContext(ctx).Info("📱 [SMS]", "to", to, "message", message)
+	return nil
+}
+
+// FileSender menulis SMS sebagai JSON Lines ke file (development / test E2E)
+type FileSender struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func (s *FileSender) Send(ctx context.Context, to, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return json.NewEncoder(f).Encode(map[string]interface{}{
+		"to":      to,
+		"message": message,
+		"sent_at": time.Now().Format(time.RFC3339),
+	})
+}
\ No newline at end of file
@@ -0,0 +1,67 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"khalif-identify/pkg/logger"
+
+)
+
+// HTTPSender adalah driver generik: POST JSON {"to","message","sender_id"} ke URL provider
+// dengan header Authorization: Bearer <token> (jika token diisi). Status non-2xx = gagal.
+type HTTPSender struct {
+	URL      string
+	Token    string
+	SenderID string
+	Client   *http.Client
+}
+
+func NewHTTPSender(url, token, senderID string) *HTTPSender {
+	return &HTTPSender{
+		URL:      url,
+		Token:    token,
+		SenderID: senderID,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *HTTPSender) Send(ctx context.Context, to, message string) error {
+	body, err := json.Marshal(map[string]string{
+		"to":        to,
+		"message":   message,
+		"sender_id": s.SenderID,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if requestID := logger.RequestIDFromContext(ctx); requestID != "" {
+		req.Header.Set(logger.HeaderRequestID, requestID)
+	}
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("gagal mengirim SMS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("provider SMS menolak (status %d): %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+	return nil
+}
\ No newline at end of file
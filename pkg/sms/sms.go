@@ -0,0 +1,41 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+)
+
+// Sender mengirim SMS/WhatsApp ke nomor E.164
+type Sender interface {
+	Send(ctx context.Context, to, message string) error
+}
+
+type Config struct {
+	Driver    string // console | file | http
+	FilePath  string
+	HTTPURL   string
+	HTTPToken string
+	SenderID  string
+}
+
+// New memilih driver sesuai konfigurasi (default: console untuk development)
+func New(cfg Config) (Sender, error) {
+	switch strings.ToLower(cfg.Driver) {
+	case "", "console":
+		return ConsoleSender{}, nil
+	case "file":
+		if cfg.FilePath == "" {
+			return nil, fmt.Errorf("SMS_FILE_PATH wajib diisi untuk driver file")
+		}
+		return &FileSender{Path: cfg.FilePath}, nil
+	case "http":
+		if cfg.HTTPURL == "" {
+			return nil, fmt.Errorf("SMS_HTTP_URL wajib diisi untuk driver http")
+		}
+		return NewHTTPSender(cfg.HTTPURL, cfg.HTTPToken, cfg.SenderID), nil
+	default:
+		return nil, fmt.Errorf("driver SMS tidak dikenal: %s", cfg.Driver)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"khalif-identify/pkg/utils"
+
+)
+
+// WrapStorage menambahkan span di sekitar akses ke storage (Azure / local)
+func WrapStorage(s utils.Storage) utils.Storage {
+	return &tracedStorage{Storage: s, driver: fmt.Sprintf("%T", s)}
+}
+
+type tracedStorage struct {
+	utils.Storage
+	driver string
+}
+
+func (s *tracedStorage) UploadFile(ctx context.Context, file io.Reader, filename string) (string, error) {
+	ctx, span := Tracer().Start(ctx, "storage.upload",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("storage.driver", s.driver),
+			attribute.String("storage.object", filename),
+		),
+	)
+	url, err := s.Storage.UploadFile(ctx, file, filename)
+	End(span, err)
+	return url, err
+}
+
+func (s *tracedStorage) Open(ctx context.Context, stored string) (io.ReadCloser, error) {
+	ctx, span := Tracer().Start(ctx, "storage.open",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("storage.driver", s.driver)),
+	)
+	file, err := s.Storage.Open(ctx, stored)
+	if errors.Is(err, utils.ErrNotInStorage) {
+		// Bukan kegagalan, file memang di luar storage (misal avatar UI Avatars)
+		End(span, nil)
+	} else {
+		End(span, err)
+	}
+	return file, err
+}
+
+func (s *tracedStorage) Delete(ctx context.Context, stored string) error {
+	ctx, span := Tracer().Start(ctx, "storage.delete",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("storage.driver", s.driver)),
+	)
+	err := s.Storage.Delete(ctx, stored)
+	if errors.Is(err, utils.ErrNotInStorage) {
+		End(span, nil)
+	} else {
+		End(span, err)
+	}
+	return err
+}
+
+func (s *tracedStorage) Ping(ctx context.Context) error {
+	ctx, span := Tracer().Start(ctx, "storage.ping", trace.WithAttributes(attribute.String("storage.driver", s.driver)))
+	err := s.Storage.Ping(ctx)
+	End(span, err)
+	return err
+}
\ No newline at end of file
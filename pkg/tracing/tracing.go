@@ -0,0 +1,103 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"khalif-identify/pkg/logger"
+
+)
+
+const instrumentationName = "khalif-identify"
+
+type Config struct {
+	Exporter     string // none | stdout | otlp
+	OTLPEndpoint string // host:port collector OTLP/HTTP, misal localhost:4318
+	OTLPInsecure bool   // true = http (collector lokal), false = https
+	ServiceName  string
+	Environment  string
+	SampleRatio  float64 // 0..1, dipakai untuk trace baru (tanpa parent)
+}
+
+// Setup memasang TracerProvider global dan propagator W3C (traceparent + baggage).
+// Exporter "none" tetap memasang propagator supaya trace dari upstream diteruskan.
+// Fungsi yang dikembalikan wajib dipanggil saat shutdown untuk flush span.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch strings.ToLower(cfg.Exporter) {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "otlp":
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.OTLPEndpoint)}
+		if cfg.OTLPInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("exporter tracing tidak dikenal: %s", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat exporter tracing: %w", err)
+	}
+
+	res := resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.DeploymentEnvironment(cfg.Environment),
+	)
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Tracer aplikasi. Selalu ambil dari provider global agar Setup berlaku walau dipanggil belakangan.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start membuat child span dari span di ctx
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End menutup span dan menandainya error jika err tidak nil.
+// Pesan error diredaksi dulu (bisa berisi email/nomor HP) sebelum dikirim ke collector.
+func End(span trace.Span, err error) {
+	RecordError(span, err)
+	span.End()
+}
+
+// RecordError menandai span error tanpa menutupnya (pesan error diredaksi seperti End)
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	message := logger.RedactString(err.Error())
+	span.RecordError(errors.New(message))
+	span.SetStatus(codes.Error, message)
+}
\ No newline at end of file
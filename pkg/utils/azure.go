@@ -2,30 +2,120 @@ package utils
 
 import (
 	"context"
-	"mime/multipart"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
 
 )
 
 type AzureUploader struct {
 	Client        *azblob.Client
 	ContainerName string
+
+	// Private: container tidak public, URL foto dibuat sebagai SAS URL yang kedaluwarsa
+	Private      bool
+	SignedURLTTL time.Duration
 }
 
-func NewAzureUploader(connStr, containerName string) (*AzureUploader, error) {
+func NewAzureUploader(connStr, containerName string, private bool, signedURLTTL time.Duration) (*AzureUploader, error) {
 	client, err := azblob.NewClientFromConnectionString(connStr, nil)
 	if err != nil {
 		return nil, err
 	}
-	return &AzureUploader{Client: client, ContainerName: containerName}, nil
+	return &AzureUploader{
+		Client:        client,
+		ContainerName: containerName,
+		Private:       private,
+		SignedURLTTL:  signedURLTTL,
+	}, nil
 }
 
-func (a *AzureUploader) UploadFile(file multipart.File, filename string) (string, error) {
-	ctx := context.Background()
+func (a *AzureUploader) UploadFile(ctx context.Context, file io.Reader, filename string) (string, error) {
 	_, err := a.Client.UploadStream(ctx, a.ContainerName, filename, file, nil)
 	if err != nil {
 		return "", err
 	}
-	return "https://" + a.Client.URL() + "/" + a.ContainerName + "/" + filename, nil
+	return a.blobClient(filename).URL(), nil
+}
+
+// ResolveURL mengubah URL yang tersimpan di DB menjadi URL yang bisa dibuka client.
+// Untuk container private, blob milik container ini ditandatangani (SAS read-only).
+// URL lain (misal UI Avatars) dikembalikan apa adanya. expiresAt nil = tidak kedaluwarsa.
+func (a *AzureUploader) ResolveURL(stored string) (url string, expiresAt *time.Time) {
+	if !a.Private || stored == "" {
+		return stored, nil
+	}
+
+	blobName, ok := a.blobName(stored)
+	if !ok {
+		return stored, nil
+	}
+
+	start, expiry := SASWindow(time.Now(), a.SignedURLTTL)
+	signed, err := a.blobClient(blobName).GetSASURL(sas.BlobPermissions{Read: true}, expiry, &blob.GetSASURLOptions{StartTime: &start})
+	if err != nil {
+		slog.Warn("⚠️ Gagal membuat SAS URL", "blob", blobName, "error", err)
+		return "", nil
+	}
+	return signed, &expiry
+}
+
+// SASWindow menghitung st/se SAS dari window TTL yang sama, jadi URL identik selama satu window
+// (bisa di-cache client) dan tetap berlaku minimal satu TTL penuh. Start mundur 5 menit untuk clock skew.
+func SASWindow(now time.Time, ttl time.Duration) (start, expiry time.Time) {
+	bucket := now.UTC().Truncate(ttl)
+	return bucket.Add(-5 * time.Minute), bucket.Add(2 * ttl)
+}
+
+// Open mengunduh blob milik container ini; URL lain -> ErrNotInStorage
+func (a *AzureUploader) Open(ctx context.Context, stored string) (io.ReadCloser, error) {
+	blobName, ok := a.blobName(stored)
+	if !ok {
+		return nil, ErrNotInStorage
+	}
+	resp, err := a.Client.DownloadStream(ctx, a.ContainerName, blobName, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete menghapus blob milik container ini; URL lain -> ErrNotInStorage
+func (a *AzureUploader) Delete(ctx context.Context, stored string) error {
+	blobName, ok := a.blobName(stored)
+	if !ok {
+		return ErrNotInStorage
+	}
+	_, err := a.Client.DeleteBlob(ctx, a.ContainerName, blobName, nil)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return nil
+	}
+	return err
+}
+
+// Ping mengecek container bisa diakses (kredensial & jaringan)
+func (a *AzureUploader) Ping(ctx context.Context) error {
+	_, err := a.Client.ServiceClient().NewContainerClient(a.ContainerName).GetProperties(ctx, nil)
+	return err
+}
+
+// blobName mengambil nama blob dari URL tersimpan (query string SAS lama dibuang)
+func (a *AzureUploader) blobName(stored string) (string, bool) {
+	prefix := a.Client.ServiceClient().NewContainerClient(a.ContainerName).URL() + "/"
+	name, ok := strings.CutPrefix(stored, prefix)
+	if !ok {
+		return "", false
+	}
+	name, _, _ = strings.Cut(name, "?")
+	return name, name != ""
+}
+
+func (a *AzureUploader) blobClient(filename string) *blob.Client {
+	return a.Client.ServiceClient().NewContainerClient(a.ContainerName).NewBlobClient(filename)
 }
\ No newline at end of file
@@ -1,37 +1,211 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 	"image"
+	_ "image/gif"  // Support decode GIF
 	_ "image/jpeg" // Support decode JPEG
 	_ "image/png"  // Support decode PNG
-	"mime/multipart"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/EdlinOrg/prominentcolor"
+	"github.com/buckket/go-blurhash"
+	"github.com/nfnt/resize"
 
 )
 
-func ExtractDominantColor(file multipart.File) (string, error) {
+const (
+	// PaletteSize jumlah cluster K-Means untuk palet tema profil
+	PaletteSize = 5
+
+	// Ukuran thumbnail untuk BlurHash (cukup kecil agar encode cepat)
+	blurHashThumbSize = 32
+)
+
+var ErrInvalidImage = errors.New("file gambar tidak dapat dibaca")
+
+// PaletteColor adalah satu warna hasil K-Means beserta bobot & warna teks yang kontras
+type PaletteColor struct {
+	Hex       string  `json:"hex"`
+	Weight    float64 `json:"weight"`     // Proporsi piksel (0..1)
+	TextColor string  `json:"text_color"` // #ffffff atau #000000
+	Contrast  float64 `json:"contrast"`   // Rasio kontras WCAG antara Hex & TextColor
+}
+
+// ColorTheme menampung tema warna lengkap milik user (disimpan sebagai JSON)
+type ColorTheme struct {
+	Palette  []PaletteColor `json:"palette"`
+	Vibrant  string         `json:"vibrant"`
+	Muted    string         `json:"muted"`
+	BlurHash string         `json:"blurhash,omitempty"`
+}
+
+// Dominant mengembalikan warna dengan bobot terbesar (atau hitam jika palet kosong)
+func (t ColorTheme) Dominant() string {
+	if len(t.Palette) == 0 {
+		return "#000000"
+	}
+	return t.Palette[0].Hex
+}
+
+func ExtractDominantColor(file io.Reader) (string, error) {
+	theme, err := ExtractColorTheme(file)
+	if err != nil {
+		return "", err
+	}
+	return theme.Dominant(), nil
+}
+
+// ExtractColorTheme men-decode gambar lalu menghitung palet, aksen & BlurHash
+func ExtractColorTheme(file io.Reader) (ColorTheme, error) {
 	// 1. Decode gambar dari stream file
 	img, _, err := image.Decode(file)
 	if err != nil {
-		return "", err
+		return ColorTheme{}, ErrInvalidImage
 	}
 
-	// 2. Proses K-Means (Mencari 1 warna paling dominan)
-	// Parameter: k=3 (jumlah cluster), mask=prominentcolor.ArgumentNoCropping
-	cols, err := prominentcolor.Kmeans(img)
+	// 2. Proses K-Means dengan k=PaletteSize (tanpa cropping background)
+	cols, err := prominentcolor.KmeansWithAll(PaletteSize, img, prominentcolor.ArgumentNoCropping, prominentcolor.DefaultSize, prominentcolor.GetDefaultMasks())
 	if err != nil {
-		return "", err
+		return ColorTheme{}, err
+	}
+
+	total := 0
+	for _, c := range cols {
+		total += c.Cnt
 	}
 
-	if len(cols) == 0 {
-		return "#000000", nil // Fallback Black
+	var theme ColorTheme
+	for _, c := range cols {
+		weight := 0.0
+		if total > 0 {
+			weight = math.Round(float64(c.Cnt)/float64(total)*1000) / 1000
+		}
+		theme.Palette = append(theme.Palette, newPaletteColor(c.Color.R, c.Color.G, c.Color.B, weight))
 	}
+	if len(theme.Palette) == 0 {
+		return ThemeFromHex("#000000"), nil
+	}
+
+	// Urutkan dari bobot terbesar (jangan bergantung ke urutan dari library)
+	sort.SliceStable(theme.Palette, func(i, j int) bool {
+		return theme.Palette[i].Weight > theme.Palette[j].Weight
+	})
+	theme.Vibrant, theme.Muted = pickAccents(theme.Palette)
 
-	// 3. Ambil warna pertama (paling dominan) dan format ke Hex
-	bestColor := cols[0]
-	hexColor := fmt.Sprintf("#%02x%02x%02x", bestColor.Color.R, bestColor.Color.G, bestColor.Color.B)
+	// 3. BlurHash dari thumbnail kecil
+	thumb := resize.Thumbnail(blurHashThumbSize, blurHashThumbSize, img, resize.Bilinear)
+	if hash, err := blurhash.Encode(4, 3, thumb); err == nil {
+		theme.BlurHash = hash
+	}
 
-	return hexColor, nil
+	return theme, nil
+}
+
+// ThemeFromHex membuat tema satu warna (dipakai untuk avatar tanpa foto)
+func ThemeFromHex(hex string) ColorTheme {
+	r, g, b, err := parseHex(hex)
+	if err != nil {
+		r, g, b = 0, 0, 0
+	}
+	base := newPaletteColor(r, g, b, 1)
+	return ColorTheme{
+		Palette: []PaletteColor{base},
+		Vibrant: base.Hex,
+		Muted:   base.Hex,
+	}
+}
+
+func newPaletteColor(r, g, b uint32, weight float64) PaletteColor {
+	textColor, contrast := ReadableTextColor(r, g, b)
+	return PaletteColor{
+		Hex:       fmt.Sprintf("#%02x%02x%02x", r, g, b),
+		Weight:    weight,
+		TextColor: textColor,
+		Contrast:  math.Round(contrast*100) / 100,
+	}
+}
+
+// ReadableTextColor memilih hitam/putih dengan rasio kontras WCAG tertinggi
+func ReadableTextColor(r, g, b uint32) (string, float64) {
+	l := relativeLuminance(r, g, b)
+	onWhite := ContrastRatio(1, l)
+	onBlack := ContrastRatio(l, 0)
+	if onWhite >= onBlack {
+		return "#ffffff", onWhite
+	}
+	return "#000000", onBlack
+}
+
+// ContrastRatio menghitung rasio kontras WCAG 2.x dari dua luminance relatif
+func ContrastRatio(l1, l2 float64) float64 {
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+	return (l1 + 0.05) / (l2 + 0.05)
+}
+
+func relativeLuminance(r, g, b uint32) float64 {
+	channel := func(c uint32) float64 {
+		v := float64(c) / 255
+		if v <= 0.03928 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(r) + 0.7152*channel(g) + 0.0722*channel(b)
+}
+
+// pickAccents memilih warna paling "hidup" (saturasi tinggi, lightness sedang)
+// dan paling "kalem" (saturasi rendah) dari palet
+func pickAccents(palette []PaletteColor) (vibrant, muted string) {
+	bestVibrant, bestMuted := -1.0, -1.0
+	for _, p := range palette {
+		r, g, b, err := parseHex(p.Hex)
+		if err != nil {
+			continue
+		}
+		s, l := saturationLightness(r, g, b)
+		lightnessScore := 1 - math.Abs(l-0.5)*2
+
+		if score := s*0.7 + lightnessScore*0.3; score > bestVibrant {
+			bestVibrant, vibrant = score, p.Hex
+		}
+		if score := (1-s)*0.7 + lightnessScore*0.3; score > bestMuted {
+			bestMuted, muted = score, p.Hex
+		}
+	}
+	return vibrant, muted
+}
+
+func saturationLightness(r, g, b uint32) (float64, float64) {
+	rf, gf, bf := float64(r)/255, float64(g)/255, float64(b)/255
+	maxC := math.Max(rf, math.Max(gf, bf))
+	minC := math.Min(rf, math.Min(gf, bf))
+	l := (maxC + minC) / 2
+	if maxC == minC {
+		return 0, l
+	}
+	d := maxC - minC
+	if l > 0.5 {
+		return d / (2 - maxC - minC), l
+	}
+	return d / (maxC + minC), l
+}
+
+func parseHex(hex string) (uint32, uint32, uint32, error) {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) != 6 {
+		return 0, 0, 0, fmt.Errorf("hex warna tidak valid: %q", hex)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return uint32(v >> 16 & 0xff), uint32(v >> 8 & 0xff), uint32(v & 0xff), nil
 }
\ No newline at end of file
@@ -1,39 +1,237 @@
 package utils
 
 import (
-	"fmt"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
 
 	"github.com/biter777/countries"
+	"github.com/nyaruka/phonenumbers"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
 
 )
 
 type Country struct {
-	Name     string `json:"name"`
-	ISOCode  string `json:"iso_code"`  // ID, US, SG
-	DialCode string `json:"dial_code"` // +62, +1
+	Name          string   `json:"name"`                     // Nama sesuai bahasa request
+	ISOCode       string   `json:"iso_code"`                 // ID, US, SG
+	DialCode      string   `json:"dial_code"`                // Kode utama: +62, +1
+	DialCodes     []string `json:"dial_codes"`               // Semua kode: +1809, +1829, +1849
+	Flag          string   `json:"flag"`                     // 🇮🇩
+	ExampleNumber string   `json:"example_number,omitempty"` // Contoh nomor HP format nasional
+	MaxLength     int      `json:"max_length,omitempty"`     // Digit maksimal nomor HP (tanpa kode negara)
 }
 
-func GetCountryList() []Country {
-	var list []Country
+// CountryList adalah hasil GetCountryList beserta ETag untuk cache HTTP
+type CountryList struct {
+	Language  string
+	Countries []Country
+	ETag      string
+}
+
+const (
+	CountrySortName     = "name"
+	CountrySortDialCode = "dial_code"
+	CountrySortISOCode  = "iso_code"
+)
+
+// Bahasa yang didukung untuk nama negara; yang pertama jadi default
+var countryLanguages = []language.Tag{language.Indonesian, language.English}
+
+type countryCatalog struct {
+	lang      string
+	countries []Country // Sudah diurutkan berdasarkan nama
+	search    []string  // Teks pencarian per negara (nama semua bahasa + ISO + kode), sudah dinormalisasi
+	etag      string
+}
+
+var (
+	countryOnce     sync.Once
+	countryCatalogs map[string]*countryCatalog
+	countryMatcher  = language.NewMatcher(countryLanguages)
+)
+
+// GetCountryList mengembalikan daftar negara untuk Accept-Language tertentu, difilter dengan
+// query (nama, kode ISO atau kode telepon) dan diurutkan. Data dasar dihitung sekali saja.
+func GetCountryList(acceptLanguage, query, sortBy string) CountryList {
+	countryOnce.Do(buildCountryCatalogs)
+
+	tags, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+	_, index, _ := countryMatcher.Match(tags...)
+	catalog := countryCatalogs[countryLanguages[index].String()]
+
+	query = foldSearch(strings.TrimSpace(query))
+	if query == "" && (sortBy == "" || sortBy == CountrySortName) {
+		return CountryList{Language: catalog.lang, Countries: catalog.countries, ETag: catalog.etag}
+	}
+
+	result := make([]Country, 0, len(catalog.countries))
+	for i, c := range catalog.countries {
+		if query == "" || strings.Contains(catalog.search[i], query) {
+			result = append(result, c)
+		}
+	}
+
+	switch sortBy {
+	case CountrySortDialCode:
+		sort.SliceStable(result, func(i, j int) bool {
+			return dialCodeValue(result[i].DialCode) < dialCodeValue(result[j].DialCode)
+		})
+	case CountrySortISOCode:
+		sort.SliceStable(result, func(i, j int) bool {
+			return result[i].ISOCode < result[j].ISOCode
+		})
+	}
+
+	return CountryList{
+		Language:  catalog.lang,
+		Countries: result,
+		ETag:      hashETag(catalog.etag, query, sortBy),
+	}
+}
 
-	// Library ini punya daftar semua negara (All)
-	allCountries := countries.All()
+func buildCountryCatalogs() {
+	// 1. Data yang tidak bergantung bahasa (kode telepon, bendera, contoh nomor)
+	mobileLengths := mobileMaxLengths()
 
-	for _, c := range allCountries {
-		// Validasi: Hanya ambil negara yang punya kode telepon valid
+	var base []Country
+	var englishNames []string
+	for _, c := range countries.All() {
+		// Hanya negara yang punya kode telepon valid
 		// (Menghindari wilayah antartika atau pulau kosong)
-		if c.CallCodes() != nil && len(c.CallCodes()) > 0 {
-			
-			// Ambil Call Code pertama (biasanya negara cuma punya 1 kode utama)
-			dialCode := c.CallCodes()[0]
+		callCodes := c.CallCodes()
+		if len(callCodes) == 0 || callCodes[0] == countries.CallCodeUnknown {
+			continue
+		}
+
+		var dialCodes []string
+		for _, code := range callCodes {
+			dialCodes = append(dialCodes, code.String())
+		}
+
+		iso := c.Alpha2()
+		country := Country{
+			ISOCode:   iso,
+			DialCode:  dialCodes[0],
+			DialCodes: dialCodes,
+			Flag:      flagEmoji(iso),
+			MaxLength: mobileLengths[iso],
+		}
+		if example := phonenumbers.GetExampleNumberForType(iso, phonenumbers.MOBILE); example != nil {
+			country.ExampleNumber = phonenumbers.Format(example, phonenumbers.NATIONAL)
+		}
 
-			list = append(list, Country{
-				Name:     c.String(),      // Contoh: "Indonesia"
-				ISOCode:  c.Alpha2(),      // Contoh: "ID"
-				DialCode: fmt.Sprintf("+%s", dialCode), // Format: "+62"
-			})
+		base = append(base, country)
+		englishNames = append(englishNames, c.String())
+	}
+
+	// 2. Nama negara di semua bahasa; pencarian mencocokkan nama dalam bahasa apa pun
+	names := make(map[string][]string, len(countryLanguages))
+	searchTexts := make(map[string]string, len(base))
+	for _, tag := range countryLanguages {
+		namer := display.Regions(tag)
+		for i, c := range base {
+			name := englishNames[i]
+			if region, err := language.ParseRegion(c.ISOCode); err == nil {
+				if localized := namer.Name(region); localized != "" {
+					name = localized
+				}
+			}
+			names[tag.String()] = append(names[tag.String()], name)
+		}
+	}
+	for i, c := range base {
+		parts := []string{englishNames[i], c.ISOCode, strings.Join(c.DialCodes, " ")}
+		for _, tag := range countryLanguages {
+			parts = append(parts, names[tag.String()][i])
+		}
+		searchTexts[c.ISOCode] = foldSearch(strings.Join(parts, " "))
+	}
+
+	// 3. Satu katalog per bahasa: urutan sesuai collation bahasa itu + ETag
+	countryCatalogs = make(map[string]*countryCatalog, len(countryLanguages))
+	for _, tag := range countryLanguages {
+		list := make([]Country, len(base))
+		for i, c := range base {
+			c.Name = names[tag.String()][i]
+			list[i] = c
+		}
+
+		collator := collate.New(tag, collate.Loose)
+		sort.SliceStable(list, func(i, j int) bool {
+			return collator.CompareString(list[i].Name, list[j].Name) < 0
+		})
+
+		catalog := &countryCatalog{lang: tag.String(), countries: list}
+		for _, c := range list {
+			catalog.search = append(catalog.search, searchTexts[c.ISOCode])
+		}
+		body, _ := json.Marshal(list)
+		catalog.etag = hashETag(string(body))
+		countryCatalogs[catalog.lang] = catalog
+	}
+}
+
+// mobileMaxLengths membaca panjang maksimal nomor HP per negara dari metadata libphonenumber
+func mobileMaxLengths() map[string]int {
+	lengths := make(map[string]int)
+	collection, err := phonenumbers.MetadataCollection()
+	if err != nil || collection == nil {
+		return lengths
+	}
+	for _, meta := range collection.GetMetadata() {
+		possible := meta.GetMobile().GetPossibleLength()
+		if len(possible) == 0 {
+			possible = meta.GetGeneralDesc().GetPossibleLength()
 		}
+		max := 0
+		for _, l := range possible {
+			if int(l) > max {
+				max = int(l)
+			}
+		}
+		lengths[meta.GetId()] = max
+	}
+	return lengths
+}
+
+// flagEmoji mengubah kode ISO 2 huruf menjadi pasangan regional indicator (🇮🇩)
+func flagEmoji(iso string) string {
+	if len(iso) != 2 {
+		return ""
+	}
+	var b strings.Builder
+	for _, r := range strings.ToUpper(iso) {
+		b.WriteRune(0x1F1E6 + (r - 'A'))
 	}
+	return b.String()
+}
+
+// foldSearch: huruf kecil + buang diakritik, supaya "aland" cocok dengan "Åland"
+func foldSearch(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, s)
+	if err != nil {
+		folded = s
+	}
+	return strings.ToLower(folded)
+}
+
+func dialCodeValue(code string) int {
+	v, _ := strconv.Atoi(strings.TrimPrefix(code, "+"))
+	return v
+}
 
-	return list
+func hashETag(parts ...string) string {
+	h := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return `"` + hex.EncodeToString(h[:8]) + `"`
 }
\ No newline at end of file
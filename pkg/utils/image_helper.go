@@ -11,21 +11,22 @@ import (
 // ProcessProfileImageResult menampung hasil pemrosesan gambar
 type ProcessProfileImageResult struct {
 	DominantColor string
+	Theme         ColorTheme
 	AvatarURL     string // Terisi hanya jika pakai UI Avatar
 }
 
-// HandleProfileImageLogic menentukan warna dominan dan avatar URL
+// HandleProfileImageLogic menentukan tema warna dan avatar URL
 func HandleProfileImageLogic(file multipart.File, name, email string) (ProcessProfileImageResult, error) {
 	var result ProcessProfileImageResult
 
 	if file != nil {
-		// A. KASUS ADA FILE: Ekstrak Warna
-		extractedColor, err := ExtractDominantColor(file)
-		if err == nil {
-			result.DominantColor = extractedColor
-		} else {
-			result.DominantColor = "#000000" // Fallback
+		// A. KASUS ADA FILE: Ekstrak palet warna (gagal decode = file bukan gambar)
+		theme, err := ExtractColorTheme(file)
+		if err != nil {
+			return result, err
 		}
+		result.Theme = theme
+		result.DominantColor = theme.Dominant()
 
 		// Reset pointer file agar bisa diupload nanti oleh Azure
 		file.Seek(0, io.SeekStart)
@@ -33,7 +34,8 @@ func HandleProfileImageLogic(file multipart.File, name, email string) (ProcessPr
 	} else {
 		// B. KASUS TIDAK ADA FILE: Generate Random
 		randomHex := GenerateRandomHexColor()
-		result.DominantColor = "#" + randomHex
+		result.Theme = ThemeFromHex("#" + randomHex)
+		result.DominantColor = result.Theme.Dominant()
 
 		cleanName := name
 		if cleanName == "" {
@@ -41,8 +43,9 @@ func HandleProfileImageLogic(file multipart.File, name, email string) (ProcessPr
 		}
 		encodedName := url.QueryEscape(cleanName)
 
-		// Set URL Avatar
-		result.AvatarURL = fmt.Sprintf("https://ui-avatars.com/api/?name=%s&background=%s&color=fff&size=128", encodedName, randomHex)
+		// Set URL Avatar (warna teks mengikuti hasil cek kontras)
+		textHex := result.Theme.Palette[0].TextColor[1:]
+		result.AvatarURL = fmt.Sprintf("https://ui-avatars.com/api/?name=%s&background=%s&color=%s&size=128", encodedName, randomHex, textHex)
 	}
 
 	return result, nil
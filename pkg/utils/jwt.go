@@ -8,8 +8,18 @@ import (
 
 )
 
+// TokenTTL masa berlaku access token default
+const TokenTTL = 24 * time.Hour
+
+// DefaultBcryptCost dipakai jika cost tidak diatur lewat konfigurasi
+const DefaultBcryptCost = 14
+
 func HashPassword(p string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(p), 14)
+	return HashPasswordWithCost(p, DefaultBcryptCost)
+}
+
+func HashPasswordWithCost(p string, cost int) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(p), cost)
 	return string(bytes), err
 }
 
@@ -18,11 +28,16 @@ func CheckPasswordHash(p, hash string) bool {
 	return err == nil
 }
 
-func GenerateToken(userID string, role, secret string) (string, error) {
+// GenerateToken: sessionVersion (claim sv) adalah versi sesi user saat token terbit; token
+// ditolak AuthMiddleware setelah versi user dinaikkan (semua sesi dicabut)
+func GenerateToken(userID string, role, secret string, sessionVersion int, ttl time.Duration) (string, error) {
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"user_id": userID,
 		"role":    role,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
+		"sv":      sessionVersion,
+		"iat":     now.Unix(),
+		"exp":     now.Add(ttl).Unix(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(secret))
@@ -1,28 +1,92 @@
 package utils
 
 import (
-	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/nyaruka/phonenumbers"
 
 )
 
-// FormatPhoneNumber memvalidasi dan mengubah format ke E.164 (Internasional)
-// defaultRegion contoh: "ID", "US", "SG"
-func FormatPhoneNumber(phone, defaultRegion string) (string, error) {
+// Kode error nomor telepon (stabil, boleh dipakai client untuk menampilkan pesan sendiri)
+const (
+	PhoneErrRequired         = "phone_required"
+	PhoneErrUnknownRegion    = "phone_unknown_region"
+	PhoneErrInvalidFormat    = "phone_invalid_format"
+	PhoneErrTooShort         = "phone_too_short"
+	PhoneErrTooLong          = "phone_too_long"
+	PhoneErrInvalidForRegion = "phone_invalid_for_region"
+	PhoneErrNotMobile        = "phone_not_mobile"
+)
+
+// DefaultPhoneRegion dipakai jika client belum mengirim kode negara
+const DefaultPhoneRegion = "ID"
+
+// PhoneError adalah error validasi nomor telepon yang terstruktur
+type PhoneError struct {
+	Code    string `json:"code"`
+	Region  string `json:"region"`
+	Message string `json:"message"`
+}
+
+func (e *PhoneError) Error() string {
+	return e.Message
+}
+
+func newPhoneError(code, region, format string, args ...interface{}) *PhoneError {
+	return &PhoneError{Code: code, Region: region, Message: fmt.Sprintf(format, args...)}
+}
+
+// NormalizeRegion merapikan kode ISO negara ("id" -> "ID"), kosong = DefaultPhoneRegion
+func NormalizeRegion(region string) string {
+	region = strings.ToUpper(strings.TrimSpace(region))
+	if region == "" {
+		return DefaultPhoneRegion
+	}
+	return region
+}
+
+// FormatPhoneNumber memvalidasi nomor terhadap negara yang dipilih dan mengubah format ke E.164
+// region contoh: "ID", "US", "SG". Error yang dikembalikan selalu *PhoneError.
+func FormatPhoneNumber(phone, region string) (string, error) {
+	region = NormalizeRegion(region)
+	if strings.TrimSpace(phone) == "" {
+		return "", newPhoneError(PhoneErrRequired, region, "phone number is required")
+	}
+
+	// 0. Pastikan negara dikenal oleh library nomor telepon
+	if phonenumbers.GetCountryCodeForRegion(region) == 0 {
+		return "", newPhoneError(PhoneErrUnknownRegion, region, "unknown country code %q", region)
+	}
+
 	// 1. Parse nomor
-	num, err := phonenumbers.Parse(phone, defaultRegion)
+	num, err := phonenumbers.Parse(phone, region)
 	if err != nil {
-		return "", errors.New("format nomor telepon tidak valid")
+		return "", newPhoneError(PhoneErrInvalidFormat, region, "phone number format is invalid")
+	}
+
+	// 2. Cek panjang nomor
+	switch phonenumbers.IsPossibleNumberWithReason(num) {
+	case phonenumbers.TOO_SHORT:
+		return "", newPhoneError(PhoneErrTooShort, region, "phone number is too short for %s", region)
+	case phonenumbers.TOO_LONG:
+		return "", newPhoneError(PhoneErrTooLong, region, "phone number is too long for %s", region)
+	}
+
+	// 3. Validasi apakah nomor itu asli/mungkin ada di negara yang dipilih
+	if !phonenumbers.IsValidNumberForRegion(num, region) {
+		return "", newPhoneError(PhoneErrInvalidForRegion, region, "phone number is not valid for %s", region)
 	}
 
-	// 2. Validasi apakah nomor itu asli/mungkin ada
-	if !phonenumbers.IsValidNumber(num) {
-		return "", errors.New("nomor telepon tidak valid atau tidak terdaftar")
+	// 4. Hanya nomor seluler (dipakai untuk OTP / WhatsApp)
+	switch phonenumbers.GetNumberType(num) {
+	case phonenumbers.MOBILE, phonenumbers.FIXED_LINE_OR_MOBILE:
+	default:
+		return "", newPhoneError(PhoneErrNotMobile, region, "phone number is not a mobile number")
 	}
 
-	// 3. Format ke E.164 (Contoh: +62812345678)
+	// 5. Format ke E.164 (Contoh: +62812345678)
 	formattedPhone := phonenumbers.Format(num, phonenumbers.E164)
-	
+
 	return formattedPhone, nil
 }
\ No newline at end of file
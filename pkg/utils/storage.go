@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+)
+
+// Storage tempat menyimpan foto profil. Driver dipilih lewat storage.driver (azure | local).
+type Storage interface {
+	UploadFile(ctx context.Context, file io.Reader, filename string) (string, error)
+	// ResolveURL mengubah URL tersimpan menjadi URL yang bisa dibuka client (expiresAt nil = tidak kedaluwarsa)
+	ResolveURL(stored string) (url string, expiresAt *time.Time)
+	// Open membaca file yang disimpan storage ini (dipakai export data); URL luar -> ErrNotInStorage
+	Open(ctx context.Context, stored string) (io.ReadCloser, error)
+	// Delete menghapus file (dipakai saat anonimisasi akun); file yang sudah tidak ada dianggap berhasil
+	Delete(ctx context.Context, stored string) error
+	// Ping dipakai /readyz untuk memastikan storage bisa diakses
+	Ping(ctx context.Context) error
+}
+
+// ErrNotInStorage: URL bukan milik storage ini (misal avatar UI Avatars)
+var ErrNotInStorage = errors.New("storage: file is not stored here")
+
+var (
+	_ Storage = (*AzureUploader)(nil)
+	_ Storage = (*LocalStorage)(nil)
+)
+
+// LocalStorage menyimpan file di disk, dilayani route /uploads lewat ServeHTTP.
+// Cocok untuk development tanpa akun Azure.
+type LocalStorage struct {
+	Dir     string
+	BaseURL string // Prefix URL publik, kosong = path relatif /uploads/<file>
+
+	// Private: file hanya bisa dibuka lewat URL bertanda tangan HMAC yang kedaluwarsa (setara SAS Azure)
+	Private      bool
+	SignedURLTTL time.Duration
+	signingKey   []byte
+}
+
+func NewLocalStorage(dir, baseURL string, private bool, signedURLTTL time.Duration, signingKey string) (*LocalStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{
+		Dir:          dir,
+		BaseURL:      strings.TrimRight(baseURL, "/"),
+		Private:      private,
+		SignedURLTTL: signedURLTTL,
+		signingKey:   []byte("local_storage:" + signingKey),
+	}, nil
+}
+
+func (s *LocalStorage) UploadFile(ctx context.Context, file io.Reader, filename string) (string, error) {
+	// Nama file dibuat server (UUID), tetap dibersihkan agar tidak bisa keluar dari Dir
+	name := filepath.Base(filename)
+	if name == "." || name == string(filepath.Separator) {
+		return "", fmt.Errorf("nama file tidak valid: %q", filename)
+	}
+
+	out, err := os.Create(filepath.Join(s.Dir, name))
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, file); err != nil {
+		return "", err
+	}
+	return s.BaseURL + "/uploads/" + name, nil
+}
+
+// ResolveURL: untuk storage private, file milik storage ini diberi ?expires=&sig= dengan window
+// yang sama seperti SAS Azure (URL stabil selama satu window). URL lain dikembalikan apa adanya.
+func (s *LocalStorage) ResolveURL(stored string) (string, *time.Time) {
+	if !s.Private {
+		return stored, nil
+	}
+	name, ok := s.fileName(stored)
+	if !ok {
+		return stored, nil
+	}
+	_, expiry := SASWindow(time.Now(), s.SignedURLTTL)
+	expires := strconv.FormatInt(expiry.Unix(), 10)
+	return s.BaseURL + "/uploads/" + name + "?expires=" + expires + "&sig=" + s.sign(name, expires), &expiry
+}
+
+// ServeHTTP melayani GET /uploads/<file>. Untuk storage private, tanda tangan dan masa berlaku
+// dicek dulu; URL tanpa tanda tangan, yang diubah, atau yang kedaluwarsa ditolak 403.
+func (s *LocalStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name, ok := strings.CutPrefix(r.URL.Path, "/uploads/")
+	if !ok || name == "" || filepath.Base(name) != name {
+		http.NotFound(w, r)
+		return
+	}
+	if s.Private {
+		expires, signature := r.URL.Query().Get("expires"), r.URL.Query().Get("sig")
+		unix, err := strconv.ParseInt(expires, 10, 64)
+		if err != nil || !hmac.Equal([]byte(signature), []byte(s.sign(name, expires))) || time.Now().Unix() > unix {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Cache-Control", "private, max-age="+strconv.FormatInt(unix-time.Now().Unix(), 10))
+	}
+	http.ServeFile(w, r, filepath.Join(s.Dir, name))
+}
+
+func (s *LocalStorage) sign(name, expires string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(name + "\n" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// fileName mengambil nama file dari URL tersimpan (query string URL bertanda tangan dibuang)
+func (s *LocalStorage) fileName(stored string) (string, bool) {
+	name, ok := strings.CutPrefix(stored, s.BaseURL+"/uploads/")
+	name, _, _ = strings.Cut(name, "?")
+	if !ok || name == "" || filepath.Base(name) != name {
+		return "", false
+	}
+	return name, true
+}
+
+func (s *LocalStorage) Open(ctx context.Context, stored string) (io.ReadCloser, error) {
+	name, ok := s.fileName(stored)
+	if !ok {
+		return nil, ErrNotInStorage
+	}
+	return os.Open(filepath.Join(s.Dir, name))
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, stored string) error {
+	name, ok := s.fileName(stored)
+	if !ok {
+		return ErrNotInStorage
+	}
+	err := os.Remove(filepath.Join(s.Dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *LocalStorage) Ping(ctx context.Context) error {
+	info, err := os.Stat(s.Dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s bukan direktori", s.Dir)
+	}
+	return nil
+}
\ No newline at end of file